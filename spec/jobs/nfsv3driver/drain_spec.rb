@@ -0,0 +1,69 @@
+require 'rspec'
+require 'bosh/template/test'
+
+describe 'nfsv3driver job' do
+  let(:release) {Bosh::Template::Test::ReleaseDir.new(File.join(File.dirname(__FILE__), '../../..'))}
+  let(:job) {release.job('nfsv3driver')}
+
+  describe 'drain' do
+    let(:template) {job.template('bin/drain')}
+
+    context 'when admin requests are served on a unix socket' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {}
+        }
+      end
+
+      it 'evacuates through the socket' do
+        tpl_output = template.render(manifest_properties)
+
+        expect(tpl_output).to include("ADMIN_SOCKET=/var/vcap/sys/run/nfsv3driver-admin/admin.sock")
+        expect(tpl_output).to include("CURL_OPTS=(--unix-socket $ADMIN_SOCKET)")
+      end
+
+      it 'fails when the evacuation is refused' do
+        tpl_output = template.render(manifest_properties)
+
+        expect(tpl_output).to include("echo \"Evacuation was refused\"\n   exit 1")
+      end
+    end
+
+    context 'when admin requests are served over tcp with a ca cert' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {
+                "admin_transport" => "tcp",
+                "admin_addr" => "127.0.0.1:7590",
+                "tls" => {
+                    "ca_cert" => "some-ca-cert",
+                },
+            }
+        }
+      end
+
+      it 'evacuates with mutual TLS' do
+        tpl_output = template.render(manifest_properties)
+
+        expect(tpl_output).to include("ADMIN_URL=https://127.0.0.1:7590")
+        expect(tpl_output).to include("--cert $CERTS_DIR/client.crt --key $CERTS_DIR/client.key")
+      end
+    end
+
+    context 'when admin requests are served over tcp without a ca cert' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {
+                "admin_transport" => "tcp",
+            }
+        }
+      end
+
+      it 'refuses to render' do
+        expect {
+          template.render(manifest_properties)
+        }.to raise_error(/admin_transport tcp requires nfsv3driver.tls.ca_cert/)
+      end
+    end
+  end
+end
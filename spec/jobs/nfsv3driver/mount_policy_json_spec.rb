@@ -0,0 +1,50 @@
+require 'rspec'
+require 'json'
+require 'bosh/template/test'
+
+describe 'nfsv3driver job' do
+  let(:release) {Bosh::Template::Test::ReleaseDir.new(File.join(File.dirname(__FILE__), '../../..'))}
+  let(:job) {release.job('nfsv3driver')}
+
+  describe 'mount_policy.json.erb' do
+    let(:template) {job.template('config/mount_policy.json')}
+
+    context 'when a mount policy is configured' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {
+                "mount_policy" => {
+                    "forced" => ["nosuid", "nodev"],
+                    "forbidden" => ["suid"],
+                    "version_defaults" => {"3" => ["nolock"]},
+                },
+            }
+        }
+      end
+
+      it 'renders the policy as JSON' do
+        tpl_output = template.render(manifest_properties)
+
+        expect(JSON.parse(tpl_output)).to eq({
+            "forced" => ["nosuid", "nodev"],
+            "forbidden" => ["suid"],
+            "version_defaults" => {"3" => ["nolock"]},
+        })
+      end
+    end
+
+    context 'when no mount policy is configured' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {}
+        }
+      end
+
+      it 'renders an empty policy' do
+        tpl_output = template.render(manifest_properties)
+
+        expect(JSON.parse(tpl_output)).to eq({})
+      end
+    end
+  end
+end
@@ -45,6 +45,21 @@ describe 'nfsv3driver job' do
               "ldap_proto" => "udp",
               "ldap_user_fqdn" => "cn=Users,dc=corp,dc=test,dc=com",
               "ldap_ca_cert" => "some-ca-cert",
+              "ldap_tls_mode" => "starttls",
+              "ldap_pool_size" => 8,
+              "ldap_pool_idle_timeout" => 60,
+              "ldap_cache_ttl" => 120,
+              "ldap_cache_negative_ttl" => 15,
+              "ldap_srv_domain" => "corp.test.com",
+              "ldap_failover_cooldown" => 90,
+              "ldap_user_filter" => "(&(objectClass=posixAccount)(uid={username}))",
+              "ldap_uid_attribute" => "uidNum",
+              "ldap_gid_attribute" => "gidNum",
+              "ldap_group_filter" => "(&(objectClass=posixGroup)(memberUid={username}))",
+              "ldap_group_base_dn" => "ou=Groups,dc=corp,dc=test,dc=com",
+              "ldap_group_gid_attribute" => "gid",
+              "ldap_supplementary_group_filter" => "(&(objectClass=posixGroup)(memberUid={username}))",
+              "ldap_member_of_attribute" => "memberOf",
             }
           }
         end
@@ -59,6 +74,21 @@ describe 'nfsv3driver job' do
           expect(tpl_output).to include("export LDAP_PROTO=\"udp\"")
           expect(tpl_output).to include("export LDAP_USER_FQDN=\"cn=Users,dc=corp,dc=test,dc=com\"")
           expect(tpl_output).to include("export LDAP_CA_CERT=\"some-ca-cert\"")
+          expect(tpl_output).to include("export LDAP_TLS_MODE=\"starttls\"")
+          expect(tpl_output).to include("export LDAP_POOL_SIZE=\"8\"")
+          expect(tpl_output).to include("export LDAP_POOL_IDLE_TIMEOUT=\"60\"")
+          expect(tpl_output).to include("export LDAP_CACHE_TTL=\"120\"")
+          expect(tpl_output).to include("export LDAP_CACHE_NEGATIVE_TTL=\"15\"")
+          expect(tpl_output).to include("export LDAP_SRV_DOMAIN=\"corp.test.com\"")
+          expect(tpl_output).to include("export LDAP_FAILOVER_COOLDOWN=\"90\"")
+          expect(tpl_output).to include("export LDAP_USER_FILTER='(&(objectClass=posixAccount)(uid={username}))'")
+          expect(tpl_output).to include("export LDAP_UID_ATTRIBUTE=\"uidNum\"")
+          expect(tpl_output).to include("export LDAP_GID_ATTRIBUTE=\"gidNum\"")
+          expect(tpl_output).to include("export LDAP_GROUP_FILTER='(&(objectClass=posixGroup)(memberUid={username}))'")
+          expect(tpl_output).to include("export LDAP_GROUP_BASE_DN=\"ou=Groups,dc=corp,dc=test,dc=com\"")
+          expect(tpl_output).to include("export LDAP_GROUP_GID_ATTRIBUTE=\"gid\"")
+          expect(tpl_output).to include("export LDAP_SUPPLEMENTARY_GROUP_FILTER='(&(objectClass=posixGroup)(memberUid={username}))'")
+          expect(tpl_output).to include("export LDAP_MEMBER_OF_ATTRIBUTE=\"memberOf\"")
         end
       end
 
@@ -84,6 +114,317 @@ describe 'nfsv3driver job' do
           expect(tpl_output).to include("export LDAP_SVC_PASS='!que&pasa!${xxx}$?'")
         end
       end
+
+      context 'when further named ldap resolvers are configured' do
+        let(:manifest_properties) do
+          {
+            "nfsv3driver" => {
+              "ldap_resolvers" => {
+                "corp" => {
+                  "svc_user" => "service-user",
+                  "svc_password" => "it's-secret",
+                  "host" => "ldap.corp.test.com",
+                  "user_fqdn" => "cn=Users,dc=corp,dc=test,dc=com",
+                },
+                "lab" => {
+                  "svc_user" => "lab-user",
+                  "svc_password" => "lab-password",
+                  "srv_domain" => "lab.test.com",
+                  "user_fqdn" => "cn=Users,dc=lab,dc=test,dc=com",
+                },
+              },
+              "id_resolver_order" => ["ldap:corp", "ldap:lab"],
+            }
+          }
+        end
+
+        it 'passes the settings of each resolver in its own environment variables' do
+          tpl_output = template.render(manifest_properties, consumes: mapfs_link)
+
+          expect(tpl_output).to include("export LDAP_RESOLVERS=\"corp,lab\"")
+          expect(tpl_output).to include("export LDAP_RESOLVER_CORP_SVC_USER='service-user'")
+          expect(tpl_output).to include("export LDAP_RESOLVER_CORP_SVC_PASS='it'\"'\"'s-secret'")
+          expect(tpl_output).to include("export LDAP_RESOLVER_CORP_HOST='ldap.corp.test.com'")
+          expect(tpl_output).to include("export LDAP_RESOLVER_CORP_PORT=\"389\"")
+          expect(tpl_output).to include("export LDAP_RESOLVER_LAB_SRV_DOMAIN='lab.test.com'")
+          expect(tpl_output).not_to include("LDAP_RESOLVER_LAB_PORT")
+          expect(tpl_output).to include("--idResolverOrder=\"ldap:corp,ldap:lab\"")
+        end
+      end
+    context 'when configured with a uid mapping' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {
+                "uid_mapping" => "idmap",
+            }
+        }
+      end
+
+      it 'passes the uid mapping to the driver' do
+        tpl_output = template.render(manifest_properties, consumes: mapfs_link)
+
+        expect(tpl_output).to include("--uidMapping=\"idmap\"")
+      end
+    end
+
+    context 'when configured to probe exports' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {
+                "probe_exports" => true,
+                "probe_timeout" => "5s",
+            }
+        }
+      end
+
+      it 'passes the probe settings to the driver' do
+        tpl_output = template.render(manifest_properties, consumes: mapfs_link)
+
+        expect(tpl_output).to include("--probeExports=true")
+        expect(tpl_output).to include("--probeTimeout=\"5s\"")
+      end
+    end
+
+    context 'when configured with a health check interval' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {
+                "health_check_interval" => "1m",
+            }
+        }
+      end
+
+      it 'passes the interval to the driver' do
+        tpl_output = template.render(manifest_properties, consumes: mapfs_link)
+
+        expect(tpl_output).to include("--healthCheckInterval=\"1m\"")
+      end
+    end
+
+    context 'when configured with mapfs supervision' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {
+                "mapfs_supervision_interval" => "10s",
+                "mapfs_restart_max_backoff" => "1m",
+            }
+        }
+      end
+
+      it 'passes the supervision settings to the driver' do
+        tpl_output = template.render(manifest_properties, consumes: mapfs_link)
+
+        expect(tpl_output).to include("--mapfsSupervisionInterval=\"10s\"")
+        expect(tpl_output).to include("--mapfsRestartMaxBackoff=\"1m\"")
+      end
+    end
+
+    context 'when configured to mount with syscalls' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {
+                "mount_syscalls" => true,
+            }
+        }
+      end
+
+      it 'enables mount syscalls in the driver' do
+        tpl_output = template.render(manifest_properties, consumes: mapfs_link)
+
+        expect(tpl_output).to include("--mountSyscalls=true")
+      end
+    end
+
+    context 'when configured to pass supplementary groups' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {
+                "supplementary_groups" => true,
+            }
+        }
+      end
+
+      it 'enables supplementary groups in the driver' do
+        tpl_output = template.render(manifest_properties, consumes: mapfs_link)
+
+        expect(tpl_output).to include("--supplementaryGroups=true")
+      end
+    end
+
+    context 'when configured with a mount policy' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {
+                "mount_policy" => {
+                    "forced" => ["nosuid", "nodev"],
+                },
+            }
+        }
+      end
+
+      it 'passes the policy file to the driver' do
+        tpl_output = template.render(manifest_properties, consumes: mapfs_link)
+
+        expect(tpl_output).to include("--mountPolicyFile=\"/var/vcap/jobs/nfsv3driver/config/mount_policy.json\"")
+      end
+    end
+
+    context 'when configured with allowed servers' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {
+                "allowed_servers" => ["nfs.example.com", ".storage.example.com", "10.0.16.0/20"],
+            }
+        }
+      end
+
+      it 'passes the allowed servers to the driver' do
+        tpl_output = template.render(manifest_properties, consumes: mapfs_link)
+
+        expect(tpl_output).to include("--allowedServers=\"nfs.example.com,.storage.example.com,10.0.16.0/20\"")
+      end
+    end
+
+    context 'when configured with static users' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {
+                "static_users" => [{"username" => "alice", "password_hash" => "$2a$10$hash", "uid" => 1001, "gid" => 1001}],
+            }
+        }
+      end
+
+      it 'passes the users file to the driver' do
+        tpl_output = template.render(manifest_properties, consumes: mapfs_link)
+
+        expect(tpl_output).to include("--staticUsersFile=\"/var/vcap/jobs/nfsv3driver/config/static_users.json\"")
+      end
+    end
+
+    context 'when configured with an id resolver order' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {
+                "id_resolver_order" => ["ldap", "static"],
+            }
+        }
+      end
+
+      it 'passes the order to the driver' do
+        tpl_output = template.render(manifest_properties, consumes: mapfs_link)
+
+        expect(tpl_output).to include("--idResolverOrder=\"ldap,static\"")
+      end
+    end
+
+    context 'when the admin server is served on the default unix socket' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {}
+        }
+      end
+
+      it 'passes the socket to the driver' do
+        tpl_output = template.render(manifest_properties, consumes: mapfs_link)
+
+        expect(tpl_output).to include("--adminTransport=\"unix\"")
+        expect(tpl_output).to include("ADMIN_SOCKET=/var/vcap/sys/run/nfsv3driver-admin/admin.sock")
+        expect(tpl_output).to include("--adminAddr=\"$ADMIN_SOCKET\"")
+      end
+    end
+
+    context 'when the admin server is served over tcp with a ca cert' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {
+                "admin_transport" => "tcp",
+                "admin_addr" => "127.0.0.1:7777",
+                "tls" => {
+                    "ca_cert" => "some-ca-cert",
+                },
+            }
+        }
+      end
+
+      it 'requires mutual TLS for the admin server' do
+        tpl_output = template.render(manifest_properties, consumes: mapfs_link)
+
+        expect(tpl_output).not_to include("--adminTransport")
+        expect(tpl_output).to include("--adminAddr=\"127.0.0.1:7777\"")
+        expect(tpl_output).to include("--adminCaFile=\"${SERVER_CERTS_DIR}/ca.crt\"")
+        expect(tpl_output).to include("--adminCertFile=\"${SERVER_CERTS_DIR}/server.crt\"")
+        expect(tpl_output).to include("--adminKeyFile=\"${SERVER_CERTS_DIR}/server.key\"")
+      end
+    end
+
+    context 'when the audit log is enabled' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {
+                "audit_log" => true,
+                "audit_log_max_size_mb" => 10,
+                "audit_log_max_backups" => 3,
+            }
+        }
+      end
+
+      it 'passes the audit log settings to the driver' do
+        tpl_output = template.render(manifest_properties, consumes: mapfs_link)
+
+        expect(tpl_output).to include("--auditLogFile=\"$LOG_DIR/audit.jsonl\"")
+        expect(tpl_output).to include("--auditLogMaxSize=10485760")
+        expect(tpl_output).to include("--auditLogMaxBackups=3")
+      end
+    end
+
+    context 'when the audit log is not enabled' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {}
+        }
+      end
+
+      it 'does not pass an audit log to the driver' do
+        tpl_output = template.render(manifest_properties, consumes: mapfs_link)
+
+        expect(tpl_output).not_to include("--auditLogFile")
+      end
+    end
+
+    context 'when failed login throttling is configured' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {
+                "login_max_failures" => 3,
+                "login_backoff" => "2s",
+                "login_lockout" => "30m",
+            }
+        }
+      end
+
+      it 'passes the throttling settings to the driver' do
+        tpl_output = template.render(manifest_properties, consumes: mapfs_link)
+
+        expect(tpl_output).to include("--loginMaxFailures=3")
+        expect(tpl_output).to include("--loginBackoff=\"2s\"")
+        expect(tpl_output).to include("--loginLockout=\"30m\"")
+      end
+    end
+
+    context 'when no mount policy is configured' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {}
+        }
+      end
+
+      it 'does not pass a policy file to the driver' do
+        tpl_output = template.render(manifest_properties, consumes: mapfs_link)
+
+        expect(tpl_output).not_to include("--mountPolicyFile")
+      end
+    end
+
     context 'when configured with ldap with a null ca cert' do
       let(:manifest_properties) do
         {
@@ -0,0 +1,48 @@
+require 'rspec'
+require 'json'
+require 'bosh/template/test'
+
+describe 'nfsv3driver job' do
+  let(:release) {Bosh::Template::Test::ReleaseDir.new(File.join(File.dirname(__FILE__), '../../..'))}
+  let(:job) {release.job('nfsv3driver')}
+
+  describe 'static_users.json.erb' do
+    let(:template) {job.template('config/static_users.json')}
+
+    context 'when static users are configured' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {
+                "static_users" => [
+                    {"username" => "alice", "password_hash" => "$2a$10$hash", "uid" => 1001, "gid" => 1001, "groups" => [2000]},
+                ],
+            }
+        }
+      end
+
+      it 'renders the users as JSON' do
+        tpl_output = template.render(manifest_properties)
+
+        expect(JSON.parse(tpl_output)).to eq({
+            "users" => [
+                {"username" => "alice", "password_hash" => "$2a$10$hash", "uid" => 1001, "gid" => 1001, "groups" => [2000]},
+            ],
+        })
+      end
+    end
+
+    context 'when no static users are configured' do
+      let(:manifest_properties) do
+        {
+            "nfsv3driver" => {}
+        }
+      end
+
+      it 'renders no users' do
+        tpl_output = template.render(manifest_properties)
+
+        expect(JSON.parse(tpl_output)).to eq({"users" => []})
+      end
+    end
+  end
+end
@@ -0,0 +1,196 @@
+package nfsv3driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/goshims/osshim"
+	"code.cloudfoundry.org/goshims/timeshim"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+const DefaultAuditLogMaxSize = 100 * 1024 * 1024
+const DefaultAuditLogMaxBackups = 5
+
+const (
+	AuditEventMount        = "mount"
+	AuditEventRemount      = "remount"
+	AuditEventUnmount      = "unmount"
+	AuditEventForceUnmount = "force-unmount"
+	AuditEventResolve      = "resolve"
+
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+
+	AuditErrorInvalidCredentials = "INVALID_CREDENTIALS"
+	AuditErrorUserNotFound       = "USER_NOT_FOUND"
+	AuditErrorLoginThrottled     = "LOGIN_THROTTLED"
+	AuditErrorFailed             = "FAILED"
+)
+
+// AuditRecord is a line of the audit log. It never holds a password or mount options, which may carry
+// credentials.
+type AuditRecord struct {
+	Time       time.Time `json:"time"`
+	Event      string    `json:"event"`
+	Volume     string    `json:"volume,omitempty"`
+	Remote     string    `json:"remote,omitempty"`
+	Target     string    `json:"target,omitempty"`
+	Uid        string    `json:"uid,omitempty"`
+	Gid        string    `json:"gid,omitempty"`
+	Username   string    `json:"username,omitempty"`
+	Outcome    string    `json:"outcome"`
+	DurationMs int64     `json:"duration_ms"`
+	ErrorCode  string    `json:"error_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// finished returns r completed with the outcome of an operation that started at start and returned err.
+func (r AuditRecord) finished(start time.Time, err error) AuditRecord {
+	r.DurationMs = time.Since(start).Milliseconds()
+	r.Outcome = AuditOutcomeSuccess
+	if err != nil {
+		r.Outcome = AuditOutcomeFailure
+		r.ErrorCode = auditErrorCode(err)
+		r.Error = err.Error()
+	}
+	return r
+}
+
+func auditErrorCode(err error) string {
+	if code := MountErrorCodeOf(err); code != "" {
+		return string(code)
+	}
+	switch {
+	case isInvalidCredentials(err):
+		return AuditErrorInvalidCredentials
+	case isUserDoesNotExist(err):
+		return AuditErrorUserNotFound
+	case isLoginThrottled(err):
+		return AuditErrorLoginThrottled
+	default:
+		return AuditErrorFailed
+	}
+}
+
+//counterfeiter:generate -o nfsdriverfakes/fake_audit_log.go . AuditLog
+
+// AuditLog records which shares were mounted where, for whom and when, for compliance.
+type AuditLog interface {
+	Record(env dockerdriver.Env, record AuditRecord)
+}
+
+// fileAuditLog appends AuditRecords as JSON lines to a file. Once the file would grow over maxSize, it is
+// rotated to path.1, path.1 to path.2 and so on, keeping maxBackups rotated files.
+type fileAuditLog struct {
+	os         osshim.Os
+	clock      timeshim.Time
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	lock sync.Mutex
+	file osshim.File
+	size int64
+}
+
+func NewFileAuditLog(os osshim.Os, clock timeshim.Time, path string, maxSize int64, maxBackups int) (AuditLog, error) {
+	a := &fileAuditLog{os: os, clock: clock, path: path, maxSize: maxSize, maxBackups: maxBackups}
+
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.open()
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *fileAuditLog) Record(env dockerdriver.Env, record AuditRecord) {
+	logger := env.Logger().Session("audit")
+
+	if record.Time.IsZero() {
+		record.Time = a.clock.Now().UTC()
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		logger.Error("marshal-audit-record-failed", err)
+		return
+	}
+	line = append(line, '\n')
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.file == nil || (a.size > 0 && a.size+int64(len(line)) > a.maxSize) {
+		err = a.rotate()
+		if err != nil {
+			logger.Error("rotate-audit-log-failed", err, lager.Data{"path": a.path})
+		}
+		if a.file == nil {
+			logger.Error("audit-record-lost", err, lager.Data{"event": record.Event, "target": record.Target, "username": record.Username})
+			return
+		}
+	}
+
+	n, err := a.file.Write(line)
+	a.size += int64(n)
+	if err != nil {
+		logger.Error("write-audit-log-failed", err, lager.Data{"path": a.path})
+	}
+}
+
+func (a *fileAuditLog) open() error {
+	file, err := a.os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	a.file = file
+	a.size = info.Size()
+	return nil
+}
+
+// rotate closes the current file, shifts the backups and opens a new, empty file. When the current file
+// cannot be moved away, records keep being appended to it.
+func (a *fileAuditLog) rotate() error {
+	if a.file != nil {
+		_ = a.file.Close()
+		a.file = nil
+	}
+
+	var err error
+	if a.maxBackups > 0 {
+		_ = a.os.Remove(a.backup(a.maxBackups))
+		for i := a.maxBackups - 1; i > 0; i-- {
+			_ = a.os.Rename(a.backup(i), a.backup(i+1))
+		}
+		err = a.os.Rename(a.path, a.backup(1))
+	} else {
+		err = a.os.Remove(a.path)
+	}
+
+	openErr := a.open()
+	if openErr != nil {
+		return openErr
+	}
+	return err
+}
+
+func (a *fileAuditLog) backup(i int) string {
+	return fmt.Sprintf("%s.%d", a.path, i)
+}
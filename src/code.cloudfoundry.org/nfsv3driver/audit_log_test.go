@@ -0,0 +1,133 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/osshim"
+	"code.cloudfoundry.org/goshims/osshim/os_fake"
+	"code.cloudfoundry.org/goshims/timeshim/time_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FileAuditLog", func() {
+	var (
+		logger   *lagertest.TestLogger
+		env      dockerdriver.Env
+		fakeOs   *os_fake.FakeOs
+		fakeTime *time_fake.FakeTime
+		files    []*os_fake.FakeFile
+		written  []string
+		size     int64
+
+		subject nfsv3driver.AuditLog
+		err     error
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("audit-log")
+		env = driverhttp.NewHttpDriverEnv(logger, context.TODO())
+
+		fakeTime = &time_fake.FakeTime{}
+		fakeTime.NowReturns(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+
+		files = nil
+		written = nil
+		size = 0
+		fakeOs = &os_fake.FakeOs{}
+		fakeOs.OpenFileStub = func(string, int, os.FileMode) (osshim.File, error) {
+			file := &os_fake.FakeFile{}
+			file.StatReturns(fakeFileInfo{size: size}, nil)
+			file.WriteStub = func(b []byte) (int, error) {
+				written = append(written, string(b))
+				return len(b), nil
+			}
+			files = append(files, file)
+			return file, nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		subject, err = nfsv3driver.NewFileAuditLog(fakeOs, fakeTime, "/var/vcap/sys/log/nfsv3driver/audit.jsonl", 200, 2)
+	})
+
+	record := func() nfsv3driver.AuditRecord {
+		return nfsv3driver.AuditRecord{Event: nfsv3driver.AuditEventMount, Volume: "vol", Target: "/mnt/vol", Outcome: nfsv3driver.AuditOutcomeSuccess}
+	}
+
+	It("opens the log for appending, readable only by root", func() {
+		Expect(err).NotTo(HaveOccurred())
+		dir, _ := fakeOs.MkdirAllArgsForCall(0)
+		Expect(dir).To(Equal("/var/vcap/sys/log/nfsv3driver"))
+		path, flag, perm := fakeOs.OpenFileArgsForCall(0)
+		Expect(path).To(Equal("/var/vcap/sys/log/nfsv3driver/audit.jsonl"))
+		Expect(flag & os.O_APPEND).NotTo(BeZero())
+		Expect(perm).To(Equal(os.FileMode(0600)))
+	})
+
+	It("writes a timestamped JSON line per record", func() {
+		subject.Record(env, record())
+
+		Expect(written).To(HaveLen(1))
+		Expect(written[0]).To(HaveSuffix("\n"))
+		var line map[string]interface{}
+		Expect(json.Unmarshal([]byte(written[0]), &line)).To(Succeed())
+		Expect(line).To(HaveKeyWithValue("time", "2024-03-01T12:00:00Z"))
+		Expect(line).To(HaveKeyWithValue("event", "mount"))
+		Expect(line).To(HaveKeyWithValue("volume", "vol"))
+		Expect(line).To(HaveKeyWithValue("outcome", "success"))
+		Expect(line).NotTo(HaveKey("username"))
+	})
+
+	Context("when a record would grow the log over its maximum size", func() {
+		BeforeEach(func() {
+			size = 150
+		})
+
+		It("rotates the log, keeping the configured number of backups", func() {
+			subject.Record(env, record())
+
+			Expect(files[0].CloseCallCount()).To(Equal(1))
+			Expect(fakeOs.RemoveArgsForCall(0)).To(Equal("/var/vcap/sys/log/nfsv3driver/audit.jsonl.2"))
+			from, to := fakeOs.RenameArgsForCall(0)
+			Expect([]string{from, to}).To(Equal([]string{"/var/vcap/sys/log/nfsv3driver/audit.jsonl.1", "/var/vcap/sys/log/nfsv3driver/audit.jsonl.2"}))
+			from, to = fakeOs.RenameArgsForCall(1)
+			Expect([]string{from, to}).To(Equal([]string{"/var/vcap/sys/log/nfsv3driver/audit.jsonl", "/var/vcap/sys/log/nfsv3driver/audit.jsonl.1"}))
+
+			Expect(files).To(HaveLen(2))
+			Expect(files[0].WriteCallCount()).To(Equal(0))
+			Expect(files[1].WriteCallCount()).To(Equal(1))
+		})
+
+		Context("when the log cannot be rotated", func() {
+			BeforeEach(func() {
+				fakeOs.RenameReturns(errors.New("read-only file system"))
+			})
+
+			It("keeps appending to it", func() {
+				subject.Record(env, record())
+				Expect(written).To(HaveLen(1))
+				Expect(logger.LogMessages()).To(ContainElement("audit-log.audit.rotate-audit-log-failed"))
+			})
+		})
+	})
+
+	Context("when the log cannot be opened", func() {
+		BeforeEach(func() {
+			fakeOs.OpenFileReturns(nil, errors.New("permission denied"))
+			fakeOs.OpenFileStub = nil
+		})
+
+		It("fails to create it", func() {
+			Expect(err).To(MatchError("permission denied"))
+		})
+	})
+})
@@ -3,9 +3,14 @@ package main
 import (
 	"encoding/json"
 	"flag"
+	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"code.cloudfoundry.org/tlsconfig"
@@ -22,8 +27,10 @@ import (
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/lager/v3/lagerflags"
 	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
 	"code.cloudfoundry.org/nfsv3driver/driveradmin/driveradminhttp"
 	"code.cloudfoundry.org/nfsv3driver/driveradmin/driveradminlocal"
+	"code.cloudfoundry.org/nfsv3driver/sunrpc"
 	"code.cloudfoundry.org/volumedriver"
 	"code.cloudfoundry.org/volumedriver/invoker"
 	"code.cloudfoundry.org/volumedriver/mountchecker"
@@ -46,6 +53,30 @@ var adminAddress = flag.String(
 	"host:port to serve process admin functions",
 )
 
+var adminTransport = flag.String(
+	"adminTransport",
+	"tcp",
+	"Transport protocol to serve process admin functions over: tcp, or unix to serve them on the socket at adminAddr",
+)
+
+var adminCaFile = flag.String(
+	"adminCaFile",
+	"",
+	"the certificate authority public key file admin clients must present a certificate of; without it, process admin functions that change the driver are only served over unix",
+)
+
+var adminCertFile = flag.String(
+	"adminCertFile",
+	"",
+	"the public key file the admin server uses with ssl authentication",
+)
+
+var adminKeyFile = flag.String(
+	"adminKeyFile",
+	"",
+	"the private key file the admin server uses with ssl authentication",
+)
+
 var driversPath = flag.String(
 	"driversPath",
 	"",
@@ -111,44 +142,221 @@ var insecureSkipVerify = flag.Bool(
 	"whether SSL communication should skip verification of server IP addresses in the certificate",
 )
 
+var kerberosCacheDir = flag.String(
+	"kerberosCacheDir",
+	"/var/vcap/data/nfsv3driver/kerberos",
+	"Path to a private directory where the Kerberos keytabs of mounted volumes are kept",
+)
+
+var gssdCacheDir = flag.String(
+	"gssdCacheDir",
+	nfsv3driver.DefaultGssdCacheDir,
+	"Path to the directory rpc.gssd reads Kerberos credential caches from (its -d option)",
+)
+
+var kerberosRenewInterval = flag.Duration(
+	"kerberosRenewInterval",
+	nfsv3driver.DefaultKerberosRenewInterval,
+	"How often Kerberos tickets are renewed while their volume stays mounted",
+)
+
+var probeExports = flag.Bool(
+	"probeExports",
+	false,
+	"Check that an NFSv3 share exists and allows this cell with the server's MOUNT service before mounting it",
+)
+
+var probeTimeout = flag.Duration(
+	"probeTimeout",
+	nfsv3driver.DefaultProbeTimeout,
+	"How long the export check waits for the NFS server before failing the mount",
+)
+
+var healthCheckInterval = flag.Duration(
+	"healthCheckInterval",
+	nfsv3driver.DefaultHealthCheckInterval,
+	"How often mounted volumes are checked for stale file handles and remounted (0 disables the check)",
+)
+
+var mapfsSupervisionInterval = flag.Duration(
+	"mapfsSupervisionInterval",
+	nfsv3driver.DefaultMapfsSupervisionInterval,
+	"How often mapfs processes are checked and restarted when they have exited (0 disables supervision)",
+)
+
+var mapfsRestartMaxBackoff = flag.Duration(
+	"mapfsRestartMaxBackoff",
+	nfsv3driver.DefaultMapfsRestartMaxBackoff,
+	"The longest delay between attempts to restart a mapfs process that keeps failing to start",
+)
+
+var mountSyscalls = flag.Bool(
+	"mountSyscalls",
+	false,
+	"Mount and unmount with the mount(2) and umount2(2) syscalls instead of running mount, umount and mountpoint",
+)
+
+var mountPolicyFile = flag.String(
+	"mountPolicyFile",
+	"",
+	"Path to a JSON file of mount options that are forced on, forbidden in, or defaulted for every NFS mount",
+)
+
+var allowedServers = flag.String(
+	"allowedServers",
+	"",
+	"Comma separated NFS server host names, domains (.example.com), addresses and CIDRs that shares may be mounted from (empty allows any server)",
+)
+
+var supplementaryGroups = flag.Bool(
+	"supplementaryGroups",
+	false,
+	"Pass the supplementary groups of LDAP users to mapfs, which must support -groups",
+)
+
+var staticUsersFile = flag.String(
+	"staticUsersFile",
+	"",
+	"Path to a JSON file of users with bcrypt password hashes, uids and gids, used to resolve usernames when LDAP is not configured",
+)
+
+var idResolverOrder = flag.String(
+	"idResolverOrder",
+	"static,ldap",
+	"Comma separated order in which the configured id resolvers ('static', 'ldap' and 'ldap:<name>' for each name in LDAP_RESOLVERS) are asked for a username, moving on to the next only when a resolver does not know the user",
+)
+
+var loginMaxFailures = flag.Int(
+	"loginMaxFailures",
+	nfsv3driver.DefaultLoginMaxFailures,
+	"Number of wrong passwords in a row after which a username is locked out of mounting for loginLockout (0 disables throttling of failed logins)",
+)
+
+var loginBackoff = flag.Duration(
+	"loginBackoff",
+	nfsv3driver.DefaultLoginBackoff,
+	"How long a username is rejected after its first wrong password, doubling with every further failure in a row",
+)
+
+var loginLockout = flag.Duration(
+	"loginLockout",
+	nfsv3driver.DefaultLoginLockout,
+	"How long a username is locked out after loginMaxFailures wrong passwords in a row, and after which its failures are forgotten",
+)
+
+var auditLogFile = flag.String(
+	"auditLogFile",
+	"",
+	"Path of a JSON lines audit log of every mount, remount, unmount and LDAP identity resolution (empty disables the audit log)",
+)
+
+var auditLogMaxSize = flag.Int64(
+	"auditLogMaxSize",
+	nfsv3driver.DefaultAuditLogMaxSize,
+	"Size in bytes over which the audit log is rotated",
+)
+
+var auditLogMaxBackups = flag.Int(
+	"auditLogMaxBackups",
+	nfsv3driver.DefaultAuditLogMaxBackups,
+	"Number of rotated audit logs that are kept",
+)
+
+var uidMapping = flag.String(
+	"uidMapping",
+	"mapfs",
+	"How uid/gid mapped volumes are presented: 'mapfs' (FUSE) or 'idmap' (kernel idmapped mounts, falling back to mapfs when unsupported)",
+)
+
+var idmapUid = flag.Uint(
+	"idmapUid",
+	uint(nfsv3driver.DefaultIdmapAppId),
+	"uid that app processes use to access idmapped volumes",
+)
+
+var idmapGid = flag.Uint(
+	"idmapGid",
+	uint(nfsv3driver.DefaultIdmapAppId),
+	"gid that app processes use to access idmapped volumes",
+)
+
 const fsType = "nfs"
 const mountOptions = "rsize=1048576,wsize=1048576,hard,timeo=600,retrans=2,actimeo=0"
 
-var (
-	ldapSvcUser  string
-	ldapSvcPass  string
-	ldapUserFqdn string
-	ldapHost     string
-	ldapPort     int
-	ldapCACert   string
-	ldapProto    string
-	ldapTimeout  int
-)
+// ldapConfig holds the settings of one LDAP id resolver, read from environment variables sharing a prefix.
+type ldapConfig struct {
+	svcUser  string
+	svcPass  string
+	userFqdn string
+	host     string
+	port     int
+	caCert   string
+	proto    string
+	timeout  int
+
+	poolSize        int
+	poolIdleTimeout int
+
+	cacheTTL         int
+	cacheNegativeTTL int
+
+	srvDomain        string
+	failoverCooldown int
+
+	search nfsv3driver.LdapSearch
+
+	tlsMode nfsv3driver.LdapTLSMode
+}
+
+func (c ldapConfig) enabled() bool {
+	return c.host != "" || c.srvDomain != ""
+}
+
+// ldapConfigs are the configured LDAP id resolvers by name: "ldap" for the LDAP_* variables, and "ldap:<name>"
+// for the LDAP_RESOLVER_<NAME>_* variables of each name listed in LDAP_RESOLVERS.
+var ldapConfigs map[string]ldapConfig
+
+var ldapResolverName = regexp.MustCompile(`^[a-z0-9][a-z0-9_]*$`)
 
 func main() {
 	parseCommandLine()
 	parseEnvironment()
 
 	var nfsDriverServer ifrit.Runner
-	var idResolver nfsv3driver.IdResolver
-	var mounter volumedriver.Mounter
+	resolvers := map[string]nfsv3driver.IdResolver{}
+	var mounter nfsv3driver.RecoverableMounter
 
 	logger, logSink := newLogger()
 	logger.Info("start")
 	defer logger.Info("end")
 
-	if ldapHost != "" {
-		idResolver = nfsv3driver.NewLdapIdResolver(
-			ldapSvcUser,
-			ldapSvcPass,
-			ldapHost,
-			ldapPort,
-			ldapProto,
-			ldapUserFqdn,
-			ldapCACert,
-			&ldapshim.LdapShim{},
-			time.Duration(ldapTimeout)*time.Second,
-		)
+	var auditLog nfsv3driver.AuditLog
+	if *auditLogFile != "" {
+		var err error
+		auditLog, err = nfsv3driver.NewFileAuditLog(&osshim.OsShim{}, &timeshim.TimeShim{}, *auditLogFile, *auditLogMaxSize, *auditLogMaxBackups)
+		if err != nil {
+			exitOnFailure(logger, err)
+		}
+	}
+
+	for name, config := range ldapConfigs {
+		resolvers[name] = newLdapIdResolver(logger, config, auditLog)
+	}
+
+	if *staticUsersFile != "" {
+		fileResolver, err := nfsv3driver.NewFileIdResolver(&osshim.OsShim{}, *staticUsersFile)
+		if err != nil {
+			exitOnFailure(logger, err)
+		}
+		resolvers["static"] = fileResolver
+	}
+
+	idResolver, err := chainIdResolvers(resolvers, strings.Split(*idResolverOrder, ","))
+	if err != nil {
+		exitOnFailure(logger, err)
+	}
+	if idResolver != nil && *loginMaxFailures > 0 {
+		idResolver = nfsv3driver.NewThrottlingIdResolver(idResolver, &timeshim.TimeShim{}, *loginMaxFailures, *loginBackoff, *loginLockout)
 	}
 
 	mask, err := nfsv3driver.NewMapFsVolumeMountMask()
@@ -157,17 +365,81 @@ func main() {
 	}
 
 	processGroupInvoker := invoker.NewProcessGroupInvoker()
-	mounter = nfsv3driver.NewMapfsMounter(
-		processGroupInvoker,
-		&osshim.OsShim{},
-		&syscallshim.SyscallShim{},
-		mountchecker.NewChecker(&bufioshim.BufioShim{}, &osshim.OsShim{}),
-		fsType,
-		mountOptions,
-		idResolver,
-		mask,
-		*mapfsPath,
-	)
+	mounterOptions := []nfsv3driver.MapfsMounterOption{
+		nfsv3driver.WithKerberosCredentials(nfsv3driver.NewKinitCredentials(
+			processGroupInvoker,
+			&osshim.OsShim{},
+			*kerberosCacheDir,
+			*gssdCacheDir,
+			*kerberosRenewInterval,
+		)),
+		nfsv3driver.WithMapfsProcessRegistry(logger, filepath.Join(*mountDir, "mapfs-processes.json")),
+	}
+	if auditLog != nil {
+		mounterOptions = append(mounterOptions, nfsv3driver.WithAuditLog(auditLog))
+	}
+	if *probeExports {
+		mounterOptions = append(mounterOptions, nfsv3driver.WithExportProbe(nfsv3driver.NewRpcExportProber(sunrpc.PortmapperPort, *probeTimeout)))
+	}
+	if *mountPolicyFile != "" {
+		policy, err := nfsv3driver.LoadMountPolicy(&osshim.OsShim{}, *mountPolicyFile)
+		if err != nil {
+			exitOnFailure(logger, err)
+		}
+		mounterOptions = append(mounterOptions, nfsv3driver.WithMountPolicy(policy))
+	}
+	if *allowedServers != "" {
+		allowlist, err := nfsv3driver.NewServerAllowlist(net.DefaultResolver, strings.Split(*allowedServers, ","))
+		if err != nil {
+			exitOnFailure(logger, err)
+		}
+		mounterOptions = append(mounterOptions, nfsv3driver.WithServerAllowlist(allowlist))
+	}
+	if *mountSyscalls {
+		mounterOptions = append(mounterOptions, nfsv3driver.WithMountSyscalls(&nfsv3driver.MountSyscallShim{}))
+	}
+	if *supplementaryGroups {
+		mounterOptions = append(mounterOptions, nfsv3driver.WithSupplementaryGroups())
+	}
+	if *mapfsSupervisionInterval > 0 {
+		mounterOptions = append(mounterOptions, nfsv3driver.WithMapfsSupervision(logger, *mapfsSupervisionInterval, *mapfsRestartMaxBackoff))
+	}
+
+	idMapper := nfsv3driver.NewKernelIdMapper(uint32(*idmapUid), uint32(*idmapGid))
+	if *uidMapping == "idmap" && idMapper.Supported() == nil {
+		mounter = nfsv3driver.NewIdmapMounter(
+			processGroupInvoker,
+			&osshim.OsShim{},
+			&syscallshim.SyscallShim{},
+			mountchecker.NewChecker(&bufioshim.BufioShim{}, &osshim.OsShim{}),
+			fsType,
+			mountOptions,
+			idResolver,
+			mask,
+			*mapfsPath,
+			idMapper,
+			mounterOptions...,
+		)
+	} else {
+		if *uidMapping == "idmap" {
+			logger.Info("idmap-unsupported-using-mapfs", lager.Data{"reason": idMapper.Supported().Error()})
+		} else if *uidMapping != "mapfs" {
+			exitOnFailure(logger, fmt.Errorf("invalid uidMapping %q", *uidMapping))
+		}
+
+		mounter = nfsv3driver.NewMapfsMounter(
+			processGroupInvoker,
+			&osshim.OsShim{},
+			&syscallshim.SyscallShim{},
+			mountchecker.NewChecker(&bufioshim.BufioShim{}, &osshim.OsShim{}),
+			fsType,
+			mountOptions,
+			idResolver,
+			mask,
+			*mapfsPath,
+			mounterOptions...,
+		)
+	}
 
 	client := volumedriver.NewVolumeDriver(
 		logger,
@@ -192,6 +464,15 @@ func main() {
 		{Name: "nfsdriver-server", Runner: nfsDriverServer},
 	}
 
+	var healthMonitor *nfsv3driver.HealthMonitor
+	if *healthCheckInterval > 0 {
+		healthMonitor = nfsv3driver.NewHealthMonitor(logger, client, mounter, *healthCheckInterval)
+		servers = append(servers, grouper.Member{
+			Name:   "health-monitor",
+			Runner: healthMonitor,
+		})
+	}
+
 	if dbgAddr := cf_debug_server.DebugAddress(flag.CommandLine); dbgAddr != "" {
 		servers = append(grouper.Members{
 			{Name: "debug-server", Runner: cf_debug_server.Runner(dbgAddr, logSink)},
@@ -199,8 +480,7 @@ func main() {
 	}
 
 	adminClient := driveradminlocal.NewDriverAdminLocal()
-	adminHandler, _ := driveradminhttp.NewHandler(logger, adminClient)
-	adminServer := http_server.New(*adminAddress, adminHandler)
+	adminServer := createAdminServer(logger, adminClient)
 
 	servers = append(grouper.Members{
 		{Name: "driveradmin", Runner: adminServer},
@@ -211,6 +491,30 @@ func main() {
 
 	adminClient.SetServerProc(process)
 	adminClient.RegisterDrainable(client)
+	if restartCounter, ok := mounter.(driveradmin.RestartCounter); ok {
+		adminClient.SetRestartCounter(restartCounter)
+	}
+	adminClient.SetVolumeLister(client)
+	if mountInspector, ok := mounter.(driveradmin.MountInspector); ok {
+		adminClient.SetMountInspector(mountInspector)
+	}
+	if mountRecoverer, ok := mounter.(driveradmin.MountRecoverer); ok {
+		adminClient.SetMountRecoverer(mountRecoverer)
+	}
+	if unmountFailures, ok := mounter.(driveradmin.UnmountFailureReporter); ok {
+		adminClient.SetUnmountFailureReporter(unmountFailures)
+	}
+	if healthMonitor != nil {
+		adminClient.SetHealthReporter(healthMonitor)
+	}
+	adminClient.SetReadinessChecker(nfsv3driver.NewHostReadinessChecker(
+		&osshim.OsShim{},
+		net.JoinHostPort("127.0.0.1", strconv.Itoa(sunrpc.PortmapperPort)),
+		nfsv3driver.DefaultReadinessTimeout,
+		nfsv3driver.MountNfsPath,
+		*mapfsPath,
+		*mountDir,
+	))
 
 	untilTerminated(logger, process)
 }
@@ -286,6 +590,65 @@ func createNfsDriverUnixServer(logger lager.Logger, client dockerdriver.Driver,
 	return http_server.NewUnixServer(atAddress, handler)
 }
 
+// createAdminServer serves the admin functions on a unix socket only its owner can reach, or over tcp. Over tcp,
+// the functions that change the driver are only served to clients presenting a certificate of adminCaFile.
+func createAdminServer(logger lager.Logger, client driveradmin.DriverAdmin) ifrit.Runner {
+	if *adminTransport == "unix" {
+		err := prepareAdminSocket(*adminAddress)
+		exitOnFailure(logger, err)
+
+		handler, err := driveradminhttp.NewHandler(logger, client, driveradminhttp.WithAuthenticator(driveradminhttp.TrustedTransport))
+		exitOnFailure(logger, err)
+		return http_server.NewUnixServer(*adminAddress, handler)
+	}
+
+	handler, err := driveradminhttp.NewHandler(logger, client)
+	exitOnFailure(logger, err)
+
+	if *adminCaFile == "" {
+		logger.Info("admin-server-read-only", lager.Data{"address": *adminAddress})
+		return http_server.New(*adminAddress, handler)
+	}
+
+	tlsConfig, err := tlsconfig.
+		Build(
+			tlsconfig.WithIdentityFromFile(*adminCertFile, *adminKeyFile),
+			tlsconfig.WithInternalServiceDefaults(),
+		).
+		Server(tlsconfig.WithClientAuthenticationFromFile(*adminCaFile))
+	if err != nil {
+		logger.Fatal("admin-tls-configuration-failed", err)
+	}
+	return http_server.NewTLSServer(*adminAddress, handler, tlsConfig)
+}
+
+// prepareAdminSocket creates the directory of the admin socket at path, refusing one that another user owns or
+// can enter, and removes the socket a previous run left behind.
+func prepareAdminSocket(path string) error {
+	dir := filepath.Dir(path)
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("admin socket directory %s must only be accessible by its owner, but has mode %s", dir, info.Mode().Perm())
+	}
+	if stat, ok := info.Sys().(*syscall.Stat_t); !ok || int(stat.Uid) != os.Geteuid() {
+		return fmt.Errorf("admin socket directory %s must be owned by the user running the driver (uid %d)", dir, os.Geteuid())
+	}
+
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
 func newLogger() (lager.Logger, *lager.ReconfigurableSink) {
 	lagerConfig := lagerflags.ConfigFromFlags()
 	lagerConfig.RedactSecrets = true
@@ -300,31 +663,203 @@ func parseCommandLine() {
 }
 
 func parseEnvironment() {
-	ldapSvcUser, _ = os.LookupEnv("LDAP_SVC_USER")
-	ldapSvcPass, _ = os.LookupEnv("LDAP_SVC_PASS")
-	ldapUserFqdn, _ = os.LookupEnv("LDAP_USER_FQDN")
-	ldapHost, _ = os.LookupEnv("LDAP_HOST")
-	port, _ := os.LookupEnv("LDAP_PORT")
-	ldapPort, _ = strconv.Atoi(port)
-	ldapCACert, _ = os.LookupEnv("LDAP_CA_CERT")
-	ldapProto, _ = os.LookupEnv("LDAP_PROTO")
-	timeout, _ := os.LookupEnv("LDAP_TIMEOUT")
-	ldapTimeout, _ = strconv.Atoi(timeout)
-
-	if ldapProto == "" {
-		ldapProto = "tcp"
-	}
-
-	if ldapHost != "" && (ldapSvcUser == "" || ldapSvcPass == "" || ldapUserFqdn == "" || ldapPort == 0) {
+	ldapConfigs = map[string]ldapConfig{}
+	if config := parseLdapEnvironment("LDAP_"); config.enabled() {
+		ldapConfigs["ldap"] = config
+	}
+
+	names, _ := os.LookupEnv("LDAP_RESOLVERS")
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !ldapResolverName.MatchString(name) {
+			panic(fmt.Sprintf("LDAP_RESOLVERS contains the invalid name %q, expected lowercase letters, digits and underscores", name))
+		}
+		prefix := "LDAP_RESOLVER_" + strings.ToUpper(name) + "_"
+		config := parseLdapEnvironment(prefix)
+		if !config.enabled() {
+			panic(fmt.Sprintf("LDAP resolver %s is listed in LDAP_RESOLVERS but neither %sHOST nor %sSRV_DOMAIN is set", name, prefix, prefix))
+		}
+		ldapConfigs["ldap:"+name] = config
+	}
+}
+
+// parseLdapEnvironment reads the settings of an LDAP id resolver from the environment variables with the given prefix.
+func parseLdapEnvironment(prefix string) ldapConfig {
+	var c ldapConfig
+	c.svcUser, _ = os.LookupEnv(prefix + "SVC_USER")
+	c.svcPass, _ = os.LookupEnv(prefix + "SVC_PASS")
+	c.userFqdn, _ = os.LookupEnv(prefix + "USER_FQDN")
+	c.host, _ = os.LookupEnv(prefix + "HOST")
+	port, _ := os.LookupEnv(prefix + "PORT")
+	c.port, _ = strconv.Atoi(port)
+	c.caCert, _ = os.LookupEnv(prefix + "CA_CERT")
+	c.proto, _ = os.LookupEnv(prefix + "PROTO")
+	timeout, _ := os.LookupEnv(prefix + "TIMEOUT")
+	c.timeout, _ = strconv.Atoi(timeout)
+
+	if c.proto == "" {
+		c.proto = "tcp"
+	}
+
+	c.srvDomain, _ = os.LookupEnv(prefix + "SRV_DOMAIN")
+
+	if c.host != "" && (c.svcUser == "" || c.svcPass == "" || c.userFqdn == "" || c.port == 0) {
 		panic("LDAP is enabled but required LDAP parameters are not set.")
 	}
 
-	if ldapTimeout < 0 {
-		panic("LDAP_TIMEOUT is set to negtive value")
+	if c.srvDomain != "" && (c.svcUser == "" || c.svcPass == "" || c.userFqdn == "") {
+		panic("LDAP is enabled but required LDAP parameters are not set.")
+	}
+
+	if c.timeout < 0 {
+		panic(prefix + "TIMEOUT is set to negtive value")
+	}
+
+	// if timeout is not set, use default value
+	if c.timeout == 0 {
+		c.timeout = 120
+	}
+
+	c.poolSize = nfsv3driver.DefaultLdapPoolSize
+	if poolSize, ok := os.LookupEnv(prefix + "POOL_SIZE"); ok && poolSize != "" {
+		c.poolSize, _ = strconv.Atoi(poolSize)
+	}
+	if c.poolSize < 0 {
+		panic(prefix + "POOL_SIZE is set to negative value")
+	}
+
+	idleTimeout, _ := os.LookupEnv(prefix + "POOL_IDLE_TIMEOUT")
+	c.poolIdleTimeout, _ = strconv.Atoi(idleTimeout)
+	if c.poolIdleTimeout < 0 {
+		panic(prefix + "POOL_IDLE_TIMEOUT is set to negative value")
+	}
+	if c.poolIdleTimeout == 0 {
+		c.poolIdleTimeout = int(nfsv3driver.DefaultLdapPoolIdleTimeout / time.Second)
+	}
+
+	c.cacheTTL = int(nfsv3driver.DefaultIdCacheTTL / time.Second)
+	if cacheTTL, ok := os.LookupEnv(prefix + "CACHE_TTL"); ok && cacheTTL != "" {
+		c.cacheTTL, _ = strconv.Atoi(cacheTTL)
+	}
+	c.cacheNegativeTTL = int(nfsv3driver.DefaultIdCacheNegativeTTL / time.Second)
+	if negativeTTL, ok := os.LookupEnv(prefix + "CACHE_NEGATIVE_TTL"); ok && negativeTTL != "" {
+		c.cacheNegativeTTL, _ = strconv.Atoi(negativeTTL)
+	}
+	if c.cacheTTL < 0 || c.cacheNegativeTTL < 0 {
+		panic(prefix + "CACHE_TTL or " + prefix + "CACHE_NEGATIVE_TTL is set to negative value")
+	}
+
+	c.failoverCooldown = int(nfsv3driver.DefaultLdapFailoverCooldown / time.Second)
+	if cooldown, ok := os.LookupEnv(prefix + "FAILOVER_COOLDOWN"); ok && cooldown != "" {
+		c.failoverCooldown, _ = strconv.Atoi(cooldown)
+	}
+	if c.failoverCooldown < 0 {
+		panic(prefix + "FAILOVER_COOLDOWN is set to negative value")
+	}
+
+	c.search.UserFilter, _ = os.LookupEnv(prefix + "USER_FILTER")
+	c.search.UidAttribute, _ = os.LookupEnv(prefix + "UID_ATTRIBUTE")
+	c.search.GidAttribute, _ = os.LookupEnv(prefix + "GID_ATTRIBUTE")
+	c.search.GroupFilter, _ = os.LookupEnv(prefix + "GROUP_FILTER")
+	c.search.GroupBaseDN, _ = os.LookupEnv(prefix + "GROUP_BASE_DN")
+	c.search.GroupGidAttribute, _ = os.LookupEnv(prefix + "GROUP_GID_ATTRIBUTE")
+	c.search.SupplementaryGroupFilter, _ = os.LookupEnv(prefix + "SUPPLEMENTARY_GROUP_FILTER")
+	c.search.MemberOfAttribute, _ = os.LookupEnv(prefix + "MEMBER_OF_ATTRIBUTE")
+	if err := c.search.Validate(); err != nil {
+		panic(err.Error())
+	}
+
+	tlsMode, _ := os.LookupEnv(prefix + "TLS_MODE")
+	var err error
+	c.tlsMode, err = nfsv3driver.ParseLdapTLSMode(tlsMode, c.caCert)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return c
+}
+
+func newLdapIdResolver(logger lager.Logger, c ldapConfig, auditLog nfsv3driver.AuditLog) nfsv3driver.IdResolver {
+	var ldapOptions []nfsv3driver.LdapIdResolverOption
+	if auditLog != nil {
+		ldapOptions = append(ldapOptions, nfsv3driver.WithLdapAuditLog(auditLog))
+	}
+	cooldown := time.Duration(c.failoverCooldown) * time.Second
+	if c.srvDomain != "" {
+		ldapOptions = append(ldapOptions, nfsv3driver.WithLdapServers(nfsv3driver.NewSrvLdapServers(net.DefaultResolver, c.srvDomain, cooldown)))
+	} else {
+		endpoints, err := nfsv3driver.ParseLdapEndpoints(c.host, c.port)
+		if err != nil {
+			exitOnFailure(logger, err)
+		}
+		ldapOptions = append(ldapOptions, nfsv3driver.WithLdapServers(nfsv3driver.NewStaticLdapServers(endpoints, cooldown)))
+	}
+	ldapOptions = append(ldapOptions, nfsv3driver.WithLdapSearch(c.search), nfsv3driver.WithLdapTLSMode(c.tlsMode))
+	if c.poolSize > 0 {
+		ldapOptions = append(ldapOptions, nfsv3driver.WithLdapConnectionPool(c.poolSize, time.Duration(c.poolIdleTimeout)*time.Second))
+	}
+
+	var ldapResolver nfsv3driver.IdResolver = nfsv3driver.NewLdapIdResolver(
+		c.svcUser,
+		c.svcPass,
+		c.host,
+		c.port,
+		c.proto,
+		c.userFqdn,
+		c.caCert,
+		&ldapshim.LdapShim{},
+		time.Duration(c.timeout)*time.Second,
+		ldapOptions...,
+	)
+
+	if c.cacheTTL > 0 {
+		cachingResolver, err := nfsv3driver.NewCachingIdResolver(
+			ldapResolver,
+			&timeshim.TimeShim{},
+			time.Duration(c.cacheTTL)*time.Second,
+			time.Duration(c.cacheNegativeTTL)*time.Second,
+		)
+		if err != nil {
+			exitOnFailure(logger, err)
+		}
+		ldapResolver = cachingResolver
+	}
+	return ldapResolver
+}
+
+// chainIdResolvers returns the configured resolvers in the given order, chained when there are several,
+// or nil when none is configured.
+func chainIdResolvers(resolvers map[string]nfsv3driver.IdResolver, order []string) (nfsv3driver.IdResolver, error) {
+	var chain []nfsv3driver.ChainedIdResolver
+	for _, name := range order {
+		name = strings.TrimSpace(name)
+		named := strings.HasPrefix(name, "ldap:")
+		if name != "static" && name != "ldap" && !named {
+			return nil, fmt.Errorf("unknown id resolver %q, expected static, ldap or ldap:<name>", name)
+		}
+		resolver, ok := resolvers[name]
+		if !ok && named {
+			return nil, fmt.Errorf("id resolver %q is in the id resolver order but LDAP_RESOLVERS does not configure it", name)
+		}
+		if ok {
+			chain = append(chain, nfsv3driver.ChainedIdResolver{Name: name, Resolver: resolver})
+			delete(resolvers, name)
+		}
+	}
+
+	for name := range resolvers {
+		return nil, fmt.Errorf("id resolver %q is configured but not in the id resolver order", name)
 	}
 
-	// if ldapTimeout is not set, use default value
-	if ldapTimeout == 0 {
-		ldapTimeout = 120
+	switch len(chain) {
+	case 0:
+		return nil, nil
+	case 1:
+		return chain[0].Resolver, nil
+	default:
+		return nfsv3driver.NewChainIdResolver(chain...), nil
 	}
 }
@@ -1,10 +1,13 @@
 package main_test
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -76,6 +79,22 @@ var _ = Describe("Main", func() {
 				}, 5).ShouldNot(HaveOccurred())
 			})
 
+			It("rejects admin requests that change the driver", func() {
+				EventuallyWithOffset(1, func() (int, error) {
+					resp, err := http.Post(fmt.Sprintf("http://%s/evacuate", adminAddr), "application/json", nil)
+					if err != nil {
+						return 0, err
+					}
+					defer resp.Body.Close()
+					return resp.StatusCode, nil
+				}, 5).Should(Equal(http.StatusForbidden))
+
+				resp, err := http.Get(fmt.Sprintf("http://%s/ping", adminAddr))
+				Expect(err).NotTo(HaveOccurred())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			})
+
 			Context("when they are invalid", func() {
 
 				BeforeEach(func() {
@@ -92,6 +111,70 @@ var _ = Describe("Main", func() {
 			})
 		})
 
+		Context("when the admin server is served on a unix socket", func() {
+			var socketDir, socketPath string
+
+			BeforeEach(func() {
+				var err error
+				socketDir, err = os.MkdirTemp("", "admin")
+				Expect(err).ToNot(HaveOccurred())
+				socketPath = filepath.Join(socketDir, "admin.sock")
+
+				command.Args = append(command.Args, fmt.Sprintf("-listenAddr=%s", listenAddr))
+				command.Args = append(command.Args, "-adminTransport=unix")
+				command.Args = append(command.Args, fmt.Sprintf("-adminAddr=%s", socketPath))
+			})
+
+			AfterEach(func() {
+				Expect(os.RemoveAll(socketDir)).To(Succeed())
+			})
+
+			It("serves every admin request on the socket", func() {
+				client := &http.Client{Transport: &http.Transport{
+					DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+						return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+					},
+				}}
+
+				EventuallyWithOffset(1, func() (int, error) {
+					resp, err := client.Post("http://admin/volumes/missing/unmount", "application/json", nil)
+					if err != nil {
+						return 0, err
+					}
+					defer resp.Body.Close()
+					return resp.StatusCode, nil
+				}, 5).Should(Equal(http.StatusNotFound))
+			})
+
+			Context("when other users can enter the socket directory", func() {
+				BeforeEach(func() {
+					Expect(os.Chmod(socketDir, 0755)).To(Succeed())
+					expectedStartOutput = "fatal-err-aborting"
+				})
+
+				It("refuses to start", func() {
+					Eventually(session.Exited).Should(BeClosed())
+					Expect(socketPath).NotTo(BeAnExistingFile())
+				})
+			})
+
+			Context("when another user owns the socket directory", func() {
+				BeforeEach(func() {
+					if os.Geteuid() != 0 {
+						Skip("changing the owner of the socket directory requires root")
+					}
+					Expect(os.Chown(socketDir, 1000, 1000)).To(Succeed())
+					expectedStartOutput = "fatal-err-aborting"
+				})
+
+				It("refuses to start", func() {
+					Eventually(session.Exited).Should(BeClosed())
+					Expect(session.Out).To(gbytes.Say("must be owned by the user running the driver"))
+					Expect(socketPath).NotTo(BeAnExistingFile())
+				})
+			})
+		})
+
 		Context("given correct LDAP arguments set in the environment", func() {
 			BeforeEach(func() {
 				Expect(os.Setenv("LDAP_SVC_USER", "user")).To(Succeed())
@@ -122,6 +205,78 @@ var _ = Describe("Main", func() {
 			})
 		})
 
+		Context("given a named LDAP resolver set in the environment", func() {
+			BeforeEach(func() {
+				Expect(os.Setenv("LDAP_RESOLVERS", "corp")).To(Succeed())
+				Expect(os.Setenv("LDAP_RESOLVER_CORP_SVC_USER", "user")).To(Succeed())
+				Expect(os.Setenv("LDAP_RESOLVER_CORP_SVC_PASS", "password")).To(Succeed())
+				Expect(os.Setenv("LDAP_RESOLVER_CORP_USER_FQDN", "cn=Users,dc=corp,dc=testdomain,dc=com")).To(Succeed())
+				Expect(os.Setenv("LDAP_RESOLVER_CORP_HOST", "ldap.corp.testdomain.com")).To(Succeed())
+				Expect(os.Setenv("LDAP_RESOLVER_CORP_PORT", "7593")).To(Succeed())
+
+				command.Args = append(command.Args, fmt.Sprintf("-listenAddr=%s", listenAddr))
+				command.Args = append(command.Args, fmt.Sprintf("-adminAddr=%s", adminAddr))
+				command.Args = append(command.Args, "-idResolverOrder=static,ldap:corp")
+			})
+
+			AfterEach(func() {
+				Expect(os.Unsetenv("LDAP_RESOLVERS")).To(Succeed())
+				Expect(os.Unsetenv("LDAP_RESOLVER_CORP_SVC_USER")).To(Succeed())
+				Expect(os.Unsetenv("LDAP_RESOLVER_CORP_SVC_PASS")).To(Succeed())
+				Expect(os.Unsetenv("LDAP_RESOLVER_CORP_USER_FQDN")).To(Succeed())
+				Expect(os.Unsetenv("LDAP_RESOLVER_CORP_HOST")).To(Succeed())
+				Expect(os.Unsetenv("LDAP_RESOLVER_CORP_PORT")).To(Succeed())
+			})
+
+			It("listens on provided arguments", func() {
+				EventuallyWithOffset(1, func() error {
+					_, err := net.Dial("tcp", listenAddr)
+					return err
+				}, 5).ShouldNot(HaveOccurred())
+			})
+
+			Context("when the id resolver order names an LDAP resolver that is not configured", func() {
+				BeforeEach(func() {
+					command.Args = append(command.Args, "-idResolverOrder=ldap:corp,ldap:lab")
+					expectedStartOutput = "fatal-err-aborting"
+				})
+
+				It("fails to start", func() {
+					Eventually(session.Exited).Should(BeClosed())
+					Expect(session.Out).To(gbytes.Say(`ldap:lab`))
+				})
+			})
+
+			Context("when the named LDAP resolver is not in the id resolver order", func() {
+				BeforeEach(func() {
+					command.Args = append(command.Args, "-idResolverOrder=static,ldap")
+					expectedStartOutput = "fatal-err-aborting"
+				})
+
+				It("fails to start", func() {
+					Eventually(session.Exited).Should(BeClosed())
+				})
+			})
+		})
+
+		Context("given a named LDAP resolver without a server set in the environment", func() {
+			BeforeEach(func() {
+				Expect(os.Setenv("LDAP_RESOLVERS", "corp")).To(Succeed())
+				command.Args = append(command.Args, fmt.Sprintf("-listenAddr=%s", listenAddr))
+				command.Args = append(command.Args, fmt.Sprintf("-adminAddr=%s", adminAddr))
+				expectedStartOutput = ""
+				expectedStartErrOutput = "neither LDAP_RESOLVER_CORP_HOST nor LDAP_RESOLVER_CORP_SRV_DOMAIN is set"
+			})
+
+			AfterEach(func() {
+				Expect(os.Unsetenv("LDAP_RESOLVERS")).To(Succeed())
+			})
+
+			It("fails to start", func() {
+				Eventually(session.Exited).Should(BeClosed())
+			})
+		})
+
 		Context("given incomplete LDAP arguments set in the environment", func() {
 			BeforeEach(func() {
 				Expect(os.Setenv("LDAP_HOST", "ldap.testdomain.com")).To(Succeed())
@@ -6,41 +6,129 @@ import (
 	"net/http"
 	"strconv"
 
+	"code.cloudfoundry.org/dockerdriver"
 	"code.cloudfoundry.org/dockerdriver/driverhttp"
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/nfsv3driver/driveradmin"
 	"github.com/tedsuo/rata"
 )
 
-func NewHandler(logger lager.Logger, client driveradmin.DriverAdmin) (http.Handler, error) {
+const UnauthenticatedErrorMessage = "an authenticated admin client is required"
+const EvacuateMethodErrorMessage = "use POST /evacuate to start an evacuation and GET /evacuate/status to follow it"
+
+// Authenticator tells whether the caller of an admin request may change the state of the driver.
+type Authenticator func(req *http.Request) bool
+
+// VerifiedClientCertificate authenticates callers that presented a client certificate the server has verified,
+// which only happens when the admin server requires mutual TLS.
+func VerifiedClientCertificate(req *http.Request) bool {
+	return req.TLS != nil && len(req.TLS.VerifiedChains) > 0
+}
+
+// TrustedTransport authenticates every caller, for transports that only let authorized callers connect, such as
+// a unix socket protected by filesystem permissions.
+func TrustedTransport(*http.Request) bool {
+	return true
+}
+
+type handlerOptions struct {
+	authenticate Authenticator
+}
+
+type HandlerOption func(*handlerOptions)
+
+// WithAuthenticator replaces VerifiedClientCertificate as the check of the callers of the routes that change
+// the state of the driver.
+func WithAuthenticator(authenticate Authenticator) HandlerOption {
+	return func(o *handlerOptions) {
+		o.authenticate = authenticate
+	}
+}
+
+func NewHandler(logger lager.Logger, client driveradmin.DriverAdmin, opts ...HandlerOption) (http.Handler, error) {
 	logger = logger.Session("server")
 	logger.Info("start")
 	defer logger.Info("end")
 
+	options := handlerOptions{authenticate: VerifiedClientCertificate}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	authenticated := func(handler http.HandlerFunc) http.HandlerFunc {
+		return newAuthenticatedHandler(logger, options.authenticate, handler)
+	}
+
 	var handlers = rata.Handlers{
-		driveradmin.EvacuateRoute: newEvacuateHandler(logger, client),
-		driveradmin.PingRoute:     newPingHandler(logger, client),
+		driveradmin.EvacuateRoute:         newEvacuateHandler(logger),
+		driveradmin.StartEvacuationRoute:  authenticated(newStartEvacuationHandler(logger, client)),
+		driveradmin.EvacuationStatusRoute: newEvacuationStatusHandler(logger, client),
+		driveradmin.PingRoute:             newPingHandler(logger, client),
+		driveradmin.ReadyRoute:            newReadyHandler(logger, client),
+		driveradmin.MapfsRestartsRoute:    newMapfsRestartsHandler(logger, client),
+		driveradmin.VolumesRoute:          newVolumesHandler(logger, client),
+		driveradmin.VolumeRoute:           newVolumeHandler(logger, client),
+		driveradmin.VolumeUnmountRoute:    authenticated(newVolumeOperationsHandler(logger, "force-unmount", client.ForceUnmount)),
+		driveradmin.VolumeRemountRoute:    authenticated(newVolumeOperationsHandler(logger, "force-remount", client.ForceRemount)),
+		driveradmin.ServerUnmountRoute:    authenticated(newVolumeOperationsHandler(logger, "force-unmount", client.ForceUnmount)),
+		driveradmin.ServerRemountRoute:    authenticated(newVolumeOperationsHandler(logger, "force-remount", client.ForceRemount)),
 	}
 
 	return rata.NewRouter(driveradmin.Routes, handlers)
 }
 
-func newEvacuateHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.HandlerFunc {
+// newAuthenticatedHandler rejects the requests whose caller authenticate does not accept before they reach
+// handler.
+func newAuthenticatedHandler(logger lager.Logger, authenticate Authenticator, handler http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		logger := logger.Session("handle-evacuate")
+		if !authenticate(req) {
+			logger.Info("rejected-unauthenticated-request", lager.Data{"method": req.Method, "path": req.URL.Path, "remote-addr": req.RemoteAddr})
+			writeJSONResponse(w, http.StatusForbidden, driveradmin.ErrorResponse{Err: UnauthenticatedErrorMessage})
+			return
+		}
+
+		handler(w, req)
+	}
+}
+
+// newEvacuateHandler rejects GET /evacuate, which used to drain the volumes before returning, in favor of
+// POST /evacuate.
+func newEvacuateHandler(logger lager.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger.Session("handle-evacuate").Info("rejected-blocking-evacuation", lager.Data{"remote-addr": req.RemoteAddr})
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSONResponse(w, http.StatusMethodNotAllowed, driveradmin.ErrorResponse{Err: EvacuateMethodErrorMessage})
+	}
+}
+
+func newStartEvacuationHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := logger.Session("handle-start-evacuation")
 		logger.Info("start")
 		defer logger.Info("end")
 
 		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
 
-		response := client.Evacuate(env)
-		if response.Err != "" {
-			logger.Error("failed-evacuating", errors.New(response.Err))
+		response := client.StartEvacuation(env)
+		// an evacuation that has drained with errors has still been started
+		if response.Err != "" && response.State == "" {
+			logger.Error("failed-starting-evacuation", errors.New(response.Err))
 			writeJSONResponse(w, http.StatusInternalServerError, response)
 			return
 		}
 
-		writeJSONResponse(w, http.StatusOK, response)
+		writeJSONResponse(w, http.StatusAccepted, response)
+	}
+}
+
+func newEvacuationStatusHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := logger.Session("handle-evacuation-status")
+		logger.Debug("start")
+		defer logger.Debug("end")
+
+		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
+
+		writeJSONResponse(w, http.StatusOK, client.EvacuationStatus(env))
 	}
 }
 
@@ -63,6 +151,128 @@ func newPingHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.Ha
 	}
 }
 
+// newReadyHandler answers 503 when the cell is not ready to mount NFS shares, so that it can be probed by
+// monitoring without parsing the checks.
+func newReadyHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := logger.Session("handle-ready")
+		logger.Info("start")
+		defer logger.Info("end")
+
+		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
+
+		response := client.Ready(env)
+		if response.Err != "" {
+			logger.Error("failed-checking-readiness", errors.New(response.Err))
+			writeJSONResponse(w, http.StatusInternalServerError, response)
+			return
+		}
+		if !response.Ready {
+			logger.Info("not-ready", lager.Data{"checks": response.Checks})
+			writeJSONResponse(w, http.StatusServiceUnavailable, response)
+			return
+		}
+
+		writeJSONResponse(w, http.StatusOK, response)
+	}
+}
+
+func newMapfsRestartsHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := logger.Session("handle-mapfs-restarts")
+		logger.Info("start")
+		defer logger.Info("end")
+
+		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
+
+		response := client.MapfsRestarts(env)
+		if response.Err != "" {
+			logger.Error("failed-listing-mapfs-restarts", errors.New(response.Err))
+			writeJSONResponse(w, http.StatusInternalServerError, response)
+			return
+		}
+
+		writeJSONResponse(w, http.StatusOK, response)
+	}
+}
+
+func newVolumesHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		logger := logger.Session("handle-volumes")
+		logger.Info("start")
+		defer logger.Info("end")
+
+		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
+
+		response := client.Volumes(env)
+		if response.Err != "" {
+			logger.Error("failed-listing-volumes", errors.New(response.Err))
+			writeJSONResponse(w, http.StatusInternalServerError, response)
+			return
+		}
+
+		writeJSONResponse(w, http.StatusOK, response)
+	}
+}
+
+func newVolumeHandler(logger lager.Logger, client driveradmin.DriverAdmin) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := rata.Param(req, "name")
+		logger := logger.Session("handle-volume", lager.Data{"volume": name})
+		logger.Info("start")
+		defer logger.Info("end")
+
+		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
+
+		response := client.Volume(env, name)
+		if response.Err != "" {
+			logger.Error("failed-inspecting-volume", errors.New(response.Err))
+			writeJSONResponse(w, http.StatusInternalServerError, response)
+			return
+		}
+		if response.Volume == nil {
+			writeJSONResponse(w, http.StatusNotFound, driveradmin.VolumeResponse{Err: "volume not found"})
+			return
+		}
+
+		writeJSONResponse(w, http.StatusOK, response)
+	}
+}
+
+// newVolumeOperationsHandler handles the routes that run operation on the volume named in the path, or on the
+// volumes of the server named in the path. It answers 500 when the operation failed on any volume.
+func newVolumeOperationsHandler(logger lager.Logger, name string, operation func(dockerdriver.Env, driveradmin.VolumeSelector) driveradmin.VolumeOperationsResponse) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		selector := driveradmin.VolumeSelector{Name: rata.Param(req, "name"), Server: rata.Param(req, "server")}
+		logger := logger.Session("handle-"+name, lager.Data{"volume": selector.Name, "server": selector.Server})
+		logger.Info("start")
+		defer logger.Info("end")
+
+		env := driverhttp.EnvWithMonitor(logger, req.Context(), w)
+
+		response := operation(env, selector)
+		if response.Err != "" {
+			logger.Error("failed-"+name, errors.New(response.Err))
+			writeJSONResponse(w, http.StatusInternalServerError, response)
+			return
+		}
+		if response.Results == nil {
+			writeJSONResponse(w, http.StatusNotFound, driveradmin.VolumeOperationsResponse{Err: "volume not found"})
+			return
+		}
+
+		for _, result := range response.Results {
+			if result.Err != "" {
+				logger.Error("failed-"+name, errors.New(result.Err), lager.Data{"failed-volume": result.Name})
+				writeJSONResponse(w, http.StatusInternalServerError, response)
+				return
+			}
+		}
+
+		writeJSONResponse(w, http.StatusOK, response)
+	}
+}
+
 func writeJSONResponse(w http.ResponseWriter, statusCode int, jsonObj interface{}) {
 	jsonBytes, err := json.Marshal(jsonObj)
 	if err != nil {
@@ -1,6 +1,7 @@
 package driveradminhttp_test
 
 import (
+	"crypto/x509"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -24,55 +25,156 @@ var _ = Describe("Volman Driver Handlers", func() {
 			httpRequest          *http.Request
 			httpResponseRecorder *httptest.ResponseRecorder
 			route                rata.Route
+			params               rata.Params
+			authenticate         driveradminhttp.Authenticator
 		)
 
 		BeforeEach(func() {
-			var err error
-			handler, err = driveradminhttp.NewHandler(testLogger, fakeDriverAdmin)
-			Expect(err).NotTo(HaveOccurred())
+			authenticate = driveradminhttp.TrustedTransport
 		})
 
 		JustBeforeEach(func() {
 			var err error
-			path := fmt.Sprintf("http://0.0.0.0%s", route.Path)
-			httpRequest, err = http.NewRequest("GET", path, nil)
+			handler, err = driveradminhttp.NewHandler(testLogger, fakeDriverAdmin, driveradminhttp.WithAuthenticator(authenticate))
+			Expect(err).NotTo(HaveOccurred())
+
+			routePath, err := route.CreatePath(params)
+			Expect(err).NotTo(HaveOccurred())
+			path := fmt.Sprintf("http://0.0.0.0%s", routePath)
+			httpRequest, err = http.NewRequest(route.Method, path, nil)
 			Expect(err).NotTo(HaveOccurred())
 
 			httpResponseRecorder = httptest.NewRecorder()
 			handler.ServeHTTP(httpResponseRecorder, httpRequest)
 		})
 
+		Context("when the caller is not authenticated", func() {
+			BeforeEach(func() {
+				authenticate = driveradminhttp.VerifiedClientCertificate
+				params = rata.Params{"name": "vol", "server": "server"}
+			})
+
+			AfterEach(func() {
+				params = nil
+			})
+
+			for _, name := range []string{
+				driveradmin.StartEvacuationRoute,
+				driveradmin.VolumeUnmountRoute,
+				driveradmin.VolumeRemountRoute,
+				driveradmin.ServerUnmountRoute,
+				driveradmin.ServerRemountRoute,
+			} {
+				Context(name, func() {
+					BeforeEach(func() {
+						var found bool
+						route, found = driveradmin.Routes.FindRouteByName(name)
+						Expect(found).To(BeTrue())
+						fakeDriverAdmin = &nfsdriverfakes.FakeDriverAdmin{}
+					})
+
+					It("should reject the request without touching the driver", func() {
+						Expect(httpResponseRecorder.Code).To(Equal(403))
+						Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Err":"an authenticated admin client is required"}`))
+						Expect(fakeDriverAdmin.Invocations()).To(BeEmpty())
+					})
+				})
+			}
+
+			Context(driveradmin.VolumesRoute, func() {
+				BeforeEach(func() {
+					var found bool
+					route, found = driveradmin.Routes.FindRouteByName(driveradmin.VolumesRoute)
+					Expect(found).To(BeTrue())
+				})
+
+				It("should still serve the read-only routes", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(200))
+				})
+			})
+		})
+
 		Context("Evacuate", func() {
 			BeforeEach(func() {
-				fakeDriverAdmin.EvacuateReturns(driveradmin.ErrorResponse{})
+				fakeDriverAdmin = &nfsdriverfakes.FakeDriverAdmin{}
 
 				var found bool
 				route, found = driveradmin.Routes.FindRouteByName(driveradmin.EvacuateRoute)
 				Expect(found).To(BeTrue())
 			})
 
-			It("should produce a handler with an evacuate route", func() {
-				Expect(httpResponseRecorder.Code).To(Equal(200))
-				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Err":""}`))
+			It("should point to the non-blocking evacuation without evacuating", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(405))
+				Expect(httpResponseRecorder.Header().Get("Allow")).To(Equal("POST"))
+				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Err":"use POST /evacuate to start an evacuation and GET /evacuate/status to follow it"}`))
+				Expect(fakeDriverAdmin.Invocations()).To(BeEmpty())
+			})
+		})
+
+		Context("StartEvacuation", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.StartEvacuationReturns(driveradmin.EvacuationStatusResponse{
+					State:     driveradmin.EvacuationDraining,
+					Remaining: []string{"vol"},
+				})
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.StartEvacuationRoute)
+				Expect(found).To(BeTrue())
 			})
 
-			Context("when invoking evacuate returns an error", func() {
+			It("should accept the evacuation and return its status", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(202))
+				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"State":"draining","StartedAt":"0001-01-01T00:00:00Z","ElapsedSeconds":0,"Remaining":["vol"],"Errors":null,"Err":""}`))
+			})
+
+			Context("when the evacuation has drained with errors", func() {
 				BeforeEach(func() {
-					fakeDriverAdmin.EvacuateReturns(driveradmin.ErrorResponse{
+					fakeDriverAdmin.StartEvacuationReturns(driveradmin.EvacuationStatusResponse{
+						State: driveradmin.EvacuationDrained,
+						Err:   "unable to purge",
+					})
+				})
+
+				It("should still accept it", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(202))
+				})
+			})
+
+			Context("when the evacuation cannot be started", func() {
+				BeforeEach(func() {
+					fakeDriverAdmin.StartEvacuationReturns(driveradmin.EvacuationStatusResponse{
 						Err: "unable to evacuate",
 					})
 				})
 
 				It("should return an http 500 response and an error string", func() {
 					Expect(httpResponseRecorder.Code).To(Equal(500))
-					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Err":"unable to evacuate"}`))
 				})
 			})
 		})
 
+		Context("EvacuationStatus", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.EvacuationStatusReturns(driveradmin.EvacuationStatusResponse{
+					State:  driveradmin.EvacuationDraining,
+					Errors: map[string]string{"vol": "device is busy"},
+				})
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.EvacuationStatusRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			It("should return the status of the evacuation", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"State":"draining","StartedAt":"0001-01-01T00:00:00Z","ElapsedSeconds":0,"Remaining":null,"Errors":{"vol":"device is busy"},"Err":""}`))
+			})
+		})
+
 		Context("Ping", func() {
 			BeforeEach(func() {
-				fakeDriverAdmin.EvacuateReturns(driveradmin.ErrorResponse{})
+				fakeDriverAdmin.PingReturns(driveradmin.ErrorResponse{})
 
 				var found bool
 				route, found = driveradmin.Routes.FindRouteByName(driveradmin.PingRoute)
@@ -98,5 +200,225 @@ var _ = Describe("Volman Driver Handlers", func() {
 			})
 		})
 
+		Context("Ready", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.ReadyReturns(driveradmin.ReadyResponse{
+					Ready:  true,
+					Checks: []driveradmin.ReadinessCheck{{Name: "rpcbind", Ready: true}},
+				})
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.ReadyRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			It("should produce a handler with a ready route", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Ready":true,"Checks":[{"Name":"rpcbind","Ready":true,"Err":""}],"Err":""}`))
+			})
+
+			Context("when a check fails", func() {
+				BeforeEach(func() {
+					fakeDriverAdmin.ReadyReturns(driveradmin.ReadyResponse{
+						Checks: []driveradmin.ReadinessCheck{{Name: "rpcbind", Err: "connection refused"}},
+					})
+				})
+
+				It("should return an http 503 response with the checks", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(503))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Ready":false,"Checks":[{"Name":"rpcbind","Ready":false,"Err":"connection refused"}],"Err":""}`))
+				})
+			})
+		})
+
+		Context("MapfsRestarts", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.MapfsRestartsReturns(driveradmin.MapfsRestartsResponse{
+					Restarts: map[string]int{"/mounts/target": 1},
+				})
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.MapfsRestartsRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			It("should produce a handler with a mapfs restarts route", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Restarts":{"/mounts/target":1},"Err":""}`))
+			})
+
+			Context("when listing the restarts returns an error", func() {
+				BeforeEach(func() {
+					fakeDriverAdmin.MapfsRestartsReturns(driveradmin.MapfsRestartsResponse{
+						Err: "unable to list restarts",
+					})
+				})
+
+				It("should return an http 500 response and an error string", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(500))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Restarts":null,"Err":"unable to list restarts"}`))
+				})
+			})
+		})
+
+		Context("Volumes", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.VolumesReturns(driveradmin.VolumesResponse{
+					Volumes: []driveradmin.VolumeDetails{{Name: "vol", Mountpoint: "/mounts/vol", MountCount: 1, Mount: driveradmin.MountDetails{Uid: "100", Gid: "200", MapfsPid: 42}}},
+				})
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.VolumesRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			It("should produce a handler with a volumes route", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Volumes":[{"Name":"vol","Mountpoint":"/mounts/vol","MountCount":1,"Mount":{"Source":"","Options":null,"Uid":"100","Gid":"200","MapfsPid":42,"IntermediateMount":"","IntermediateMounted":false},"HealthCheck":null}],"Err":""}`))
+			})
+
+			Context("when listing the volumes returns an error", func() {
+				BeforeEach(func() {
+					fakeDriverAdmin.VolumesReturns(driveradmin.VolumesResponse{Err: "unable to list volumes"})
+				})
+
+				It("should return an http 500 response and an error string", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(500))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Volumes":null,"Err":"unable to list volumes"}`))
+				})
+			})
+		})
+
+		Context("Volume", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.VolumeReturns(driveradmin.VolumeResponse{
+					Volume: &driveradmin.VolumeDetails{Name: "vol", Mountpoint: "/mounts/vol", MountCount: 1},
+				})
+				params = rata.Params{"name": "vol"}
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.VolumeRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			AfterEach(func() {
+				params = nil
+			})
+
+			It("should produce a handler with a volume route", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				Expect(httpResponseRecorder.Body.String()).To(ContainSubstring(`"Name":"vol"`))
+
+				_, name := fakeDriverAdmin.VolumeArgsForCall(fakeDriverAdmin.VolumeCallCount() - 1)
+				Expect(name).To(Equal("vol"))
+			})
+
+			Context("when the volume does not exist", func() {
+				BeforeEach(func() {
+					fakeDriverAdmin.VolumeReturns(driveradmin.VolumeResponse{})
+				})
+
+				It("should return an http 404 response", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(404))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Volume":null,"Err":"volume not found"}`))
+				})
+			})
+
+			Context("when inspecting the volume returns an error", func() {
+				BeforeEach(func() {
+					fakeDriverAdmin.VolumeReturns(driveradmin.VolumeResponse{Err: "unable to list volumes"})
+				})
+
+				It("should return an http 500 response and an error string", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(500))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Volume":null,"Err":"unable to list volumes"}`))
+				})
+			})
+		})
+
+		Context("ForceUnmount", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.ForceUnmountReturns(driveradmin.VolumeOperationsResponse{
+					Results: []driveradmin.VolumeOperationResult{{Name: "vol", Mountpoint: "/mounts/vol", Source: "server:/export"}},
+				})
+				params = rata.Params{"name": "vol"}
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.VolumeUnmountRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			AfterEach(func() {
+				params = nil
+			})
+
+			It("should force-unmount the named volume", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Results":[{"Name":"vol","Mountpoint":"/mounts/vol","Source":"server:/export","Err":""}],"Err":""}`))
+
+				_, selector := fakeDriverAdmin.ForceUnmountArgsForCall(fakeDriverAdmin.ForceUnmountCallCount() - 1)
+				Expect(selector).To(Equal(driveradmin.VolumeSelector{Name: "vol"}))
+			})
+
+			Context("when the volume does not exist", func() {
+				BeforeEach(func() {
+					fakeDriverAdmin.ForceUnmountReturns(driveradmin.VolumeOperationsResponse{})
+				})
+
+				It("should return an http 404 response", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(404))
+					Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Results":null,"Err":"volume not found"}`))
+				})
+			})
+
+			Context("when the unmount fails", func() {
+				BeforeEach(func() {
+					fakeDriverAdmin.ForceUnmountReturns(driveradmin.VolumeOperationsResponse{
+						Results: []driveradmin.VolumeOperationResult{{Name: "vol", Err: "device is busy"}},
+					})
+				})
+
+				It("should return an http 500 response with the results", func() {
+					Expect(httpResponseRecorder.Code).To(Equal(500))
+					Expect(httpResponseRecorder.Body.String()).To(ContainSubstring(`"Err":"device is busy"`))
+				})
+			})
+		})
+
+		Context("ForceRemount of a server", func() {
+			BeforeEach(func() {
+				fakeDriverAdmin.ForceRemountReturns(driveradmin.VolumeOperationsResponse{Results: []driveradmin.VolumeOperationResult{}})
+				params = rata.Params{"server": "nfs.example.com"}
+
+				var found bool
+				route, found = driveradmin.Routes.FindRouteByName(driveradmin.ServerRemountRoute)
+				Expect(found).To(BeTrue())
+			})
+
+			AfterEach(func() {
+				params = nil
+			})
+
+			It("should remount the volumes of the server", func() {
+				Expect(httpResponseRecorder.Code).To(Equal(200))
+				Expect(httpResponseRecorder.Body).Should(MatchJSON(`{"Results":[],"Err":""}`))
+
+				_, selector := fakeDriverAdmin.ForceRemountArgsForCall(fakeDriverAdmin.ForceRemountCallCount() - 1)
+				Expect(selector).To(Equal(driveradmin.VolumeSelector{Server: "nfs.example.com"}))
+			})
+		})
+
+	})
+
+	Context("VerifiedClientCertificate", func() {
+		It("should only authenticate callers with a verified client certificate", func() {
+			Expect(driveradminhttp.VerifiedClientCertificate(httptest.NewRequest("POST", "http://127.0.0.1/evacuate", nil))).To(BeFalse())
+
+			req := httptest.NewRequest("POST", "https://127.0.0.1/evacuate", nil)
+			Expect(driveradminhttp.VerifiedClientCertificate(req)).To(BeFalse())
+
+			req.TLS.VerifiedChains = [][]*x509.Certificate{{{}}}
+			Expect(driveradminhttp.VerifiedClientCertificate(req)).To(BeTrue())
+		})
 	})
 })
@@ -1,7 +1,9 @@
 package driveradminlocal
 
 import (
-	"os"
+	"sort"
+	"strings"
+	"sync"
 
 	"code.cloudfoundry.org/dockerdriver"
 	"code.cloudfoundry.org/nfsv3driver/driveradmin"
@@ -11,6 +13,16 @@ import (
 type DriverAdminLocal struct {
 	serverProcess ifrit.Process
 	drainables    []driveradmin.Drainable
+	restarts      driveradmin.RestartCounter
+	volumes       driveradmin.VolumeLister
+	mounts        driveradmin.MountInspector
+	health        driveradmin.HealthReporter
+	recoverer     driveradmin.MountRecoverer
+	unmounts      driveradmin.UnmountFailureReporter
+	readiness     driveradmin.ReadinessChecker
+
+	lock       sync.Mutex
+	evacuation *evacuation
 }
 
 func NewDriverAdminLocal() *DriverAdminLocal {
@@ -27,30 +39,229 @@ func (d *DriverAdminLocal) RegisterDrainable(rhs driveradmin.Drainable) {
 	d.drainables = append(d.drainables, rhs)
 }
 
-func (d *DriverAdminLocal) Evacuate(env dockerdriver.Env) driveradmin.ErrorResponse {
-	logger := env.Logger().Session("evacuate")
+func (d *DriverAdminLocal) SetRestartCounter(rhs driveradmin.RestartCounter) {
+	d.restarts = rhs
+}
+
+func (d *DriverAdminLocal) SetVolumeLister(rhs driveradmin.VolumeLister) {
+	d.volumes = rhs
+}
+
+func (d *DriverAdminLocal) SetMountInspector(rhs driveradmin.MountInspector) {
+	d.mounts = rhs
+}
+
+func (d *DriverAdminLocal) SetHealthReporter(rhs driveradmin.HealthReporter) {
+	d.health = rhs
+}
+
+func (d *DriverAdminLocal) SetMountRecoverer(rhs driveradmin.MountRecoverer) {
+	d.recoverer = rhs
+}
+
+func (d *DriverAdminLocal) SetUnmountFailureReporter(rhs driveradmin.UnmountFailureReporter) {
+	d.unmounts = rhs
+}
+
+func (d *DriverAdminLocal) SetReadinessChecker(rhs driveradmin.ReadinessChecker) {
+	d.readiness = rhs
+}
+
+func (d *DriverAdminLocal) Ping(env dockerdriver.Env) driveradmin.ErrorResponse {
+	logger := env.Logger().Session("ping")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	return driveradmin.ErrorResponse{}
+}
+
+func (d *DriverAdminLocal) Ready(env dockerdriver.Env) driveradmin.ReadyResponse {
+	logger := env.Logger().Session("ready")
 	logger.Info("start")
 	defer logger.Info("end")
 
-	if d.serverProcess == nil {
-		return driveradmin.ErrorResponse{Err: "unexpected error: server process not found"}
+	if d.readiness == nil {
+		return driveradmin.ReadyResponse{Ready: true, Checks: []driveradmin.ReadinessCheck{}}
 	}
 
-	for _, svr := range d.drainables {
-		if err := svr.Drain(env); err != nil {
-			logger.Error("failed-draining", err)
+	checks := d.readiness.CheckReadiness(env)
+	ready := true
+	for _, check := range checks {
+		ready = ready && check.Ready
+	}
+
+	return driveradmin.ReadyResponse{Ready: ready, Checks: checks}
+}
+
+func (d *DriverAdminLocal) MapfsRestarts(env dockerdriver.Env) driveradmin.MapfsRestartsResponse {
+	logger := env.Logger().Session("mapfs-restarts")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	if d.restarts == nil {
+		return driveradmin.MapfsRestartsResponse{Restarts: map[string]int{}}
+	}
+
+	return driveradmin.MapfsRestartsResponse{Restarts: d.restarts.MapfsRestarts()}
+}
+
+func (d *DriverAdminLocal) Volumes(env dockerdriver.Env) driveradmin.VolumesResponse {
+	logger := env.Logger().Session("volumes")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	if d.volumes == nil {
+		return driveradmin.VolumesResponse{Volumes: []driveradmin.VolumeDetails{}}
+	}
+
+	list := d.volumes.List(env)
+	if list.Err != "" {
+		return driveradmin.VolumesResponse{Err: list.Err}
+	}
+
+	volumes := []driveradmin.VolumeDetails{}
+	for _, volume := range list.Volumes {
+		volumes = append(volumes, d.volumeDetails(volume))
+	}
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Name < volumes[j].Name })
+
+	return driveradmin.VolumesResponse{Volumes: volumes}
+}
+
+func (d *DriverAdminLocal) Volume(env dockerdriver.Env, name string) driveradmin.VolumeResponse {
+	logger := env.Logger().Session("volume")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	if d.volumes == nil {
+		return driveradmin.VolumeResponse{}
+	}
+
+	list := d.volumes.List(env)
+	if list.Err != "" {
+		return driveradmin.VolumeResponse{Err: list.Err}
+	}
+
+	for _, volume := range list.Volumes {
+		if volume.Name == name {
+			details := d.volumeDetails(volume)
+			return driveradmin.VolumeResponse{Volume: &details}
 		}
 	}
 
-	d.serverProcess.Signal(os.Interrupt)
+	return driveradmin.VolumeResponse{}
+}
+
+func (d *DriverAdminLocal) volumeDetails(volume dockerdriver.VolumeInfo) driveradmin.VolumeDetails {
+	details := driveradmin.VolumeDetails{
+		Name:       volume.Name,
+		Mountpoint: volume.Mountpoint,
+		MountCount: volume.MountCount,
+	}
+
+	if d.mounts != nil && volume.Mountpoint != "" {
+		details.Mount = d.mounts.InspectMount(volume.Mountpoint)
+	}
 
-	return driveradmin.ErrorResponse{}
+	if d.health != nil {
+		if result, ok := d.health.LastHealthCheck(volume.Name); ok {
+			details.HealthCheck = &result
+		}
+	}
+
+	return details
 }
 
-func (d *DriverAdminLocal) Ping(env dockerdriver.Env) driveradmin.ErrorResponse {
-	logger := env.Logger().Session("ping")
+func (d *DriverAdminLocal) ForceUnmount(env dockerdriver.Env, selector driveradmin.VolumeSelector) driveradmin.VolumeOperationsResponse {
+	logger := env.Logger().Session("force-unmount")
 	logger.Info("start")
 	defer logger.Info("end")
 
-	return driveradmin.ErrorResponse{}
+	return d.recoverVolumes(env, selector, func(mountpoint string) error {
+		return d.recoverer.ForceUnmount(env, mountpoint)
+	})
+}
+
+func (d *DriverAdminLocal) ForceRemount(env dockerdriver.Env, selector driveradmin.VolumeSelector) driveradmin.VolumeOperationsResponse {
+	logger := env.Logger().Session("force-remount")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	return d.recoverVolumes(env, selector, func(mountpoint string) error {
+		if err := d.recoverer.ForceUnmount(env, mountpoint); err != nil {
+			return err
+		}
+		return d.recoverer.Remount(env, mountpoint)
+	})
+}
+
+// recoverVolumes runs operation on the mountpoint of each selected volume, one after the other.
+func (d *DriverAdminLocal) recoverVolumes(env dockerdriver.Env, selector driveradmin.VolumeSelector, operation func(mountpoint string) error) driveradmin.VolumeOperationsResponse {
+	if d.volumes == nil || d.mounts == nil || d.recoverer == nil {
+		return driveradmin.VolumeOperationsResponse{Err: "unexpected error: volume recovery is not available"}
+	}
+
+	list := d.volumes.List(env)
+	if list.Err != "" {
+		return driveradmin.VolumeOperationsResponse{Err: list.Err}
+	}
+
+	var results []driveradmin.VolumeOperationResult
+	for _, volume := range list.Volumes {
+		if selector.Name != "" && volume.Name != selector.Name {
+			continue
+		}
+
+		result := driveradmin.VolumeOperationResult{Name: volume.Name, Mountpoint: volume.Mountpoint}
+		if volume.Mountpoint == "" || volume.MountCount < 1 {
+			if selector.Name == "" {
+				continue
+			}
+			result.Err = "volume is not mounted"
+			results = append(results, result)
+			continue
+		}
+
+		result.Source = d.mounts.InspectMount(volume.Mountpoint).Source
+		if selector.Name == "" {
+			// A volume whose share is unknown might be on the server, so it is reported instead of skipped.
+			if result.Source == "" {
+				result.Err = "the server of the volume is unknown"
+				results = append(results, result)
+				continue
+			}
+			if !strings.EqualFold(serverOf(result.Source), selector.Server) {
+				continue
+			}
+		}
+
+		if err := operation(volume.Mountpoint); err != nil {
+			result.Err = err.Error()
+		}
+		results = append(results, result)
+	}
+
+	if selector.Name == "" && results == nil {
+		results = []driveradmin.VolumeOperationResult{}
+	}
+	return driveradmin.VolumeOperationsResponse{Results: results}
+}
+
+// serverOf returns the server of an NFS share such as server:/export or nfs://user@server/export.
+func serverOf(source string) string {
+	if i := strings.Index(source, "://"); i >= 0 {
+		source = source[i+3:]
+	}
+	if at := strings.Index(source, "@"); at >= 0 && !strings.Contains(source[:at], "/") {
+		source = source[at+1:]
+	}
+	if strings.HasPrefix(source, "[") {
+		if end := strings.Index(source, "]"); end >= 0 {
+			return source[1:end]
+		}
+	}
+	if i := strings.IndexAny(source, ":/"); i >= 0 {
+		return source[:i]
+	}
+	return source
 }
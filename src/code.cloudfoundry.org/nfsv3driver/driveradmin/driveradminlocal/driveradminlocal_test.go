@@ -2,6 +2,8 @@ package driveradminlocal_test
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"code.cloudfoundry.org/dockerdriver"
 	"code.cloudfoundry.org/dockerdriver/driverhttp"
@@ -32,49 +34,371 @@ var _ = Describe("Driver Admin Local", func() {
 			driverAdminLocal = driveradminlocal.NewDriverAdminLocal()
 		})
 
-		Describe("Evacuate", func() {
-			JustBeforeEach(func() {
-				err = driverAdminLocal.Evacuate(env)
-			})
+		Describe("StartEvacuation", func() {
 			Context("when the driver evacuates with no process set", func() {
 				It("should fail", func() {
-					Expect(err.Err).To(ContainSubstring("server process not found"))
+					status := driverAdminLocal.StartEvacuation(env)
+					Expect(status.Err).To(ContainSubstring("server process not found"))
+					Expect(status.State).To(BeEmpty())
+				})
+			})
+		})
+
+		Describe("StartEvacuation", func() {
+			var (
+				fakeProcess   *nfsdriverfakes.FakeProcess
+				fakeDrainable *nfsdriverfakes.FakeDrainable
+				fakeVolumes   *nfsdriverfakes.FakeVolumeLister
+				fakeUnmounts  *nfsdriverfakes.FakeUnmountFailureReporter
+				drain         chan error
+			)
+
+			BeforeEach(func() {
+				fakeProcess = &nfsdriverfakes.FakeProcess{}
+				driverAdminLocal.SetServerProc(fakeProcess)
+
+				drain = make(chan error, 1)
+				fakeDrainable = &nfsdriverfakes.FakeDrainable{}
+				fakeDrainable.DrainStub = func(dockerdriver.Env) error { return <-drain }
+				driverAdminLocal.RegisterDrainable(fakeDrainable)
+
+				fakeVolumes = &nfsdriverfakes.FakeVolumeLister{}
+				fakeVolumes.ListReturns(dockerdriver.ListResponse{Volumes: []dockerdriver.VolumeInfo{
+					{Name: "vol-b", Mountpoint: "/mounts/vol-b", MountCount: 1},
+					{Name: "vol-a", Mountpoint: "/mounts/vol-a", MountCount: 2},
+					{Name: "unmounted"},
+				}})
+				driverAdminLocal.SetVolumeLister(fakeVolumes)
+
+				fakeUnmounts = &nfsdriverfakes.FakeUnmountFailureReporter{}
+				fakeUnmounts.UnmountFailuresReturns(map[string]string{"/mounts/vol-b": "device is busy"})
+				driverAdminLocal.SetUnmountFailureReporter(fakeUnmounts)
+			})
+
+			AfterEach(func() {
+				close(drain)
+			})
+
+			It("reports that no evacuation has started", func() {
+				status := driverAdminLocal.EvacuationStatus(env)
+				Expect(status.State).To(Equal(driveradmin.EvacuationNotStarted))
+				Expect(status.Remaining).To(Equal([]string{"vol-a", "vol-b"}))
+				Expect(status.Errors).To(BeEmpty())
+			})
+
+			It("drains in the background and reports the progress", func() {
+				status := driverAdminLocal.StartEvacuation(env)
+				Expect(status.Err).To(BeEmpty())
+				Expect(status.State).To(Equal(driveradmin.EvacuationDraining))
+				Expect(status.StartedAt).NotTo(BeZero())
+				Expect(status.Remaining).To(Equal([]string{"vol-a", "vol-b"}))
+				Expect(status.Errors).To(Equal(map[string]string{"vol-b": "device is busy"}))
+				Expect(fakeUnmounts.UnmountFailuresArgsForCall(0)).To(Equal(status.StartedAt))
+
+				Eventually(fakeDrainable.DrainCallCount).Should(Equal(1))
+				Expect(fakeProcess.SignalCallCount()).To(BeZero())
+
+				fakeVolumes.ListReturns(dockerdriver.ListResponse{Volumes: []dockerdriver.VolumeInfo{}})
+				drain <- nil
+
+				Eventually(func() string { return driverAdminLocal.EvacuationStatus(env).State }).Should(Equal(driveradmin.EvacuationDrained))
+				Expect(driverAdminLocal.EvacuationStatus(env).Remaining).To(BeEmpty())
+				Eventually(fakeProcess.SignalCallCount).Should(Equal(1))
+			})
+
+			It("only drains once however often it is started", func() {
+				driverAdminLocal.StartEvacuation(env)
+				status := driverAdminLocal.StartEvacuation(env)
+				Expect(status.State).To(Equal(driveradmin.EvacuationDraining))
+
+				drain <- nil
+				Eventually(fakeProcess.SignalCallCount).Should(Equal(1))
+				Consistently(fakeDrainable.DrainCallCount).Should(Equal(1))
+			})
+
+			It("reports the errors of the drainables", func() {
+				driverAdminLocal.StartEvacuation(env)
+				drain <- errors.New("purge failed")
+
+				Eventually(func() string { return driverAdminLocal.EvacuationStatus(env).Err }).Should(Equal("purge failed"))
+				Expect(driverAdminLocal.EvacuationStatus(env).State).To(Equal(driveradmin.EvacuationDrained))
+			})
+		})
+
+		Describe("Ping", func() {
+			Context("when the driver pings", func() {
+				BeforeEach(func() {
+					err = driverAdminLocal.Ping(env)
+				})
+
+				It("should not fail", func() {
+					Expect(err.Err).To(Equal(""))
+				})
+			})
+		})
+
+		Describe("Ready", func() {
+			var response driveradmin.ReadyResponse
+
+			JustBeforeEach(func() {
+				response = driverAdminLocal.Ready(env)
+			})
+
+			Context("when no readiness checker is set", func() {
+				It("should be ready", func() {
+					Expect(response).To(Equal(driveradmin.ReadyResponse{Ready: true, Checks: []driveradmin.ReadinessCheck{}}))
 				})
 			})
-			Context("when the driver evacuates with a process set", func() {
-				var fakeProcess *nfsdriverfakes.FakeProcess
+
+			Context("when a readiness checker is set", func() {
+				var fakeReadiness *nfsdriverfakes.FakeReadinessChecker
 
 				BeforeEach(func() {
-					fakeProcess = &nfsdriverfakes.FakeProcess{}
-					driverAdminLocal.SetServerProc(fakeProcess)
+					fakeReadiness = &nfsdriverfakes.FakeReadinessChecker{}
+					fakeReadiness.CheckReadinessReturns([]driveradmin.ReadinessCheck{
+						{Name: "rpcbind", Ready: true},
+						{Name: "mount-root", Ready: true},
+					})
+					driverAdminLocal.SetReadinessChecker(fakeReadiness)
 				})
 
-				It("should signal the process to terminate", func() {
-					Expect(err.Err).To(BeEmpty())
-					Expect(fakeProcess.SignalCallCount()).NotTo(Equal(0))
+				It("should be ready when every check is", func() {
+					Expect(response.Ready).To(BeTrue())
+					Expect(response.Checks).To(HaveLen(2))
 				})
-				Context("when there is a drainable server registered", func() {
-					var fakeDrainable *nfsdriverfakes.FakeDrainable
+
+				Context("when a check fails", func() {
 					BeforeEach(func() {
-						fakeDrainable = &nfsdriverfakes.FakeDrainable{}
-						driverAdminLocal.RegisterDrainable(fakeDrainable)
+						fakeReadiness.CheckReadinessReturns([]driveradmin.ReadinessCheck{
+							{Name: "rpcbind", Err: "connection refused"},
+							{Name: "mount-root", Ready: true},
+						})
 					})
-					It("should drain", func() {
-						Expect(fakeDrainable.DrainCallCount()).NotTo(Equal(0))
+
+					It("should not be ready", func() {
+						Expect(response.Ready).To(BeFalse())
+						Expect(response.Checks[0]).To(Equal(driveradmin.ReadinessCheck{Name: "rpcbind", Err: "connection refused"}))
 					})
 				})
+			})
+		})
+
+		Describe("MapfsRestarts", func() {
+			var response driveradmin.MapfsRestartsResponse
 
+			JustBeforeEach(func() {
+				response = driverAdminLocal.MapfsRestarts(env)
+			})
+
+			Context("when no restart counter is set", func() {
+				It("should report no restarts", func() {
+					Expect(response.Err).To(BeEmpty())
+					Expect(response.Restarts).To(BeEmpty())
+				})
+			})
+
+			Context("when a restart counter is set", func() {
+				BeforeEach(func() {
+					fakeRestartCounter := &nfsdriverfakes.FakeRestartCounter{}
+					fakeRestartCounter.MapfsRestartsReturns(map[string]int{"/mounts/target": 2})
+					driverAdminLocal.SetRestartCounter(fakeRestartCounter)
+				})
+
+				It("should report its restarts", func() {
+					Expect(response.Err).To(BeEmpty())
+					Expect(response.Restarts).To(Equal(map[string]int{"/mounts/target": 2}))
+				})
 			})
 		})
 
-		Describe("Ping", func() {
-			Context("when the driver pings", func() {
+		Describe("Volumes", func() {
+			var (
+				fakeVolumes        *nfsdriverfakes.FakeVolumeLister
+				fakeMountInspector *nfsdriverfakes.FakeMountInspector
+				fakeHealthReporter *nfsdriverfakes.FakeHealthReporter
+				checked            time.Time
+			)
+
+			BeforeEach(func() {
+				fakeVolumes = &nfsdriverfakes.FakeVolumeLister{}
+				fakeVolumes.ListReturns(dockerdriver.ListResponse{Volumes: []dockerdriver.VolumeInfo{
+					{Name: "vol-b", Mountpoint: "/mounts/vol-b", MountCount: 2},
+					{Name: "vol-a"},
+				}})
+
+				fakeMountInspector = &nfsdriverfakes.FakeMountInspector{}
+				fakeMountInspector.InspectMountReturns(driveradmin.MountDetails{Source: "server:/export", Uid: "100", Gid: "200", MapfsPid: 42})
+
+				checked = time.Unix(1700000000, 0)
+				fakeHealthReporter = &nfsdriverfakes.FakeHealthReporter{}
+				fakeHealthReporter.LastHealthCheckStub = func(name string) (driveradmin.HealthCheckResult, bool) {
+					return driveradmin.HealthCheckResult{Time: checked, Healthy: true}, name == "vol-b"
+				}
+
+				driverAdminLocal.SetVolumeLister(fakeVolumes)
+				driverAdminLocal.SetMountInspector(fakeMountInspector)
+				driverAdminLocal.SetHealthReporter(fakeHealthReporter)
+			})
+
+			It("lists every volume by name with its mount and last health check", func() {
+				response := driverAdminLocal.Volumes(env)
+				Expect(response.Err).To(BeEmpty())
+				Expect(response.Volumes).To(HaveLen(2))
+
+				Expect(response.Volumes[0].Name).To(Equal("vol-a"))
+				Expect(response.Volumes[0].Mount).To(BeZero())
+				Expect(response.Volumes[0].HealthCheck).To(BeNil())
+
+				Expect(response.Volumes[1]).To(Equal(driveradmin.VolumeDetails{
+					Name:        "vol-b",
+					Mountpoint:  "/mounts/vol-b",
+					MountCount:  2,
+					Mount:       driveradmin.MountDetails{Source: "server:/export", Uid: "100", Gid: "200", MapfsPid: 42},
+					HealthCheck: &driveradmin.HealthCheckResult{Time: checked, Healthy: true},
+				}))
+				Expect(fakeMountInspector.InspectMountCallCount()).To(Equal(1))
+				Expect(fakeMountInspector.InspectMountArgsForCall(0)).To(Equal("/mounts/vol-b"))
+			})
+
+			It("describes a single volume", func() {
+				response := driverAdminLocal.Volume(env, "vol-b")
+				Expect(response.Err).To(BeEmpty())
+				Expect(response.Volume).NotTo(BeNil())
+				Expect(response.Volume.Mount.MapfsPid).To(Equal(42))
+			})
+
+			It("describes no volume for an unknown name", func() {
+				response := driverAdminLocal.Volume(env, "missing")
+				Expect(response.Err).To(BeEmpty())
+				Expect(response.Volume).To(BeNil())
+			})
+
+			Context("when the volumes cannot be listed", func() {
 				BeforeEach(func() {
-					err = driverAdminLocal.Ping(env)
+					fakeVolumes.ListReturns(dockerdriver.ListResponse{Err: "badness"})
 				})
 
-				It("should not fail", func() {
-					Expect(err.Err).To(Equal(""))
+				It("returns the error", func() {
+					Expect(driverAdminLocal.Volumes(env).Err).To(Equal("badness"))
+					Expect(driverAdminLocal.Volume(env, "vol-b").Err).To(Equal("badness"))
+				})
+			})
+		})
+
+		Describe("ForceUnmount and ForceRemount", func() {
+			var (
+				fakeVolumes        *nfsdriverfakes.FakeVolumeLister
+				fakeMountInspector *nfsdriverfakes.FakeMountInspector
+				fakeRecoverer      *nfsdriverfakes.FakeMountRecoverer
+			)
+
+			BeforeEach(func() {
+				fakeVolumes = &nfsdriverfakes.FakeVolumeLister{}
+				fakeVolumes.ListReturns(dockerdriver.ListResponse{Volumes: []dockerdriver.VolumeInfo{
+					{Name: "vol-a", Mountpoint: "/mounts/vol-a", MountCount: 1},
+					{Name: "vol-b", Mountpoint: "/mounts/vol-b", MountCount: 1},
+					{Name: "vol-c", Mountpoint: "/mounts/vol-c", MountCount: 1},
+					{Name: "unmounted"},
+				}})
+
+				fakeMountInspector = &nfsdriverfakes.FakeMountInspector{}
+				fakeMountInspector.InspectMountStub = func(mountpoint string) driveradmin.MountDetails {
+					switch mountpoint {
+					case "/mounts/vol-a":
+						return driveradmin.MountDetails{Source: "bad.example.com:/export/a"}
+					case "/mounts/vol-b":
+						return driveradmin.MountDetails{Source: "REDACTED@BAD.example.com:/export/b"}
+					default:
+						return driveradmin.MountDetails{Source: "good.example.com:/export/c"}
+					}
+				}
+
+				fakeRecoverer = &nfsdriverfakes.FakeMountRecoverer{}
+
+				driverAdminLocal.SetVolumeLister(fakeVolumes)
+				driverAdminLocal.SetMountInspector(fakeMountInspector)
+				driverAdminLocal.SetMountRecoverer(fakeRecoverer)
+			})
+
+			It("force-unmounts the named volume", func() {
+				response := driverAdminLocal.ForceUnmount(env, driveradmin.VolumeSelector{Name: "vol-c"})
+				Expect(response.Err).To(BeEmpty())
+				Expect(response.Results).To(Equal([]driveradmin.VolumeOperationResult{
+					{Name: "vol-c", Mountpoint: "/mounts/vol-c", Source: "good.example.com:/export/c"},
+				}))
+
+				Expect(fakeRecoverer.ForceUnmountCallCount()).To(Equal(1))
+				_, mountpoint := fakeRecoverer.ForceUnmountArgsForCall(0)
+				Expect(mountpoint).To(Equal("/mounts/vol-c"))
+				Expect(fakeRecoverer.RemountCallCount()).To(BeZero())
+			})
+
+			It("force-unmounts and remounts every volume of a server", func() {
+				fakeRecoverer.RemountReturnsOnCall(1, errors.New("mount timed out"))
+
+				response := driverAdminLocal.ForceRemount(env, driveradmin.VolumeSelector{Server: "bad.example.com"})
+				Expect(response.Err).To(BeEmpty())
+				Expect(response.Results).To(Equal([]driveradmin.VolumeOperationResult{
+					{Name: "vol-a", Mountpoint: "/mounts/vol-a", Source: "bad.example.com:/export/a"},
+					{Name: "vol-b", Mountpoint: "/mounts/vol-b", Source: "REDACTED@BAD.example.com:/export/b", Err: "mount timed out"},
+				}))
+				Expect(fakeRecoverer.ForceUnmountCallCount()).To(Equal(2))
+				Expect(fakeRecoverer.RemountCallCount()).To(Equal(2))
+			})
+
+			It("reports the volumes whose server is unknown when selecting the volumes of a server", func() {
+				fakeMountInspector.InspectMountStub = func(mountpoint string) driveradmin.MountDetails {
+					if mountpoint == "/mounts/vol-a" {
+						return driveradmin.MountDetails{Source: "bad.example.com:/export/a"}
+					}
+					return driveradmin.MountDetails{}
+				}
+
+				response := driverAdminLocal.ForceUnmount(env, driveradmin.VolumeSelector{Server: "bad.example.com"})
+				Expect(response.Err).To(BeEmpty())
+				Expect(response.Results).To(Equal([]driveradmin.VolumeOperationResult{
+					{Name: "vol-a", Mountpoint: "/mounts/vol-a", Source: "bad.example.com:/export/a"},
+					{Name: "vol-b", Mountpoint: "/mounts/vol-b", Err: "the server of the volume is unknown"},
+					{Name: "vol-c", Mountpoint: "/mounts/vol-c", Err: "the server of the volume is unknown"},
+				}))
+				Expect(fakeRecoverer.ForceUnmountCallCount()).To(Equal(1))
+			})
+
+			It("does not remount a volume that failed to unmount", func() {
+				fakeRecoverer.ForceUnmountReturns(errors.New("device is busy"))
+
+				response := driverAdminLocal.ForceRemount(env, driveradmin.VolumeSelector{Name: "vol-a"})
+				Expect(response.Results[0].Err).To(Equal("device is busy"))
+				Expect(fakeRecoverer.RemountCallCount()).To(BeZero())
+			})
+
+			It("reports a volume that is not mounted", func() {
+				response := driverAdminLocal.ForceUnmount(env, driveradmin.VolumeSelector{Name: "unmounted"})
+				Expect(response.Results).To(Equal([]driveradmin.VolumeOperationResult{{Name: "unmounted", Err: "volume is not mounted"}}))
+				Expect(fakeRecoverer.ForceUnmountCallCount()).To(BeZero())
+			})
+
+			It("has no results for an unknown volume", func() {
+				response := driverAdminLocal.ForceUnmount(env, driveradmin.VolumeSelector{Name: "missing"})
+				Expect(response.Err).To(BeEmpty())
+				Expect(response.Results).To(BeNil())
+			})
+
+			It("has empty results for a server without volumes", func() {
+				response := driverAdminLocal.ForceUnmount(env, driveradmin.VolumeSelector{Server: "other.example.com"})
+				Expect(response.Err).To(BeEmpty())
+				Expect(response.Results).To(BeEmpty())
+				Expect(response.Results).NotTo(BeNil())
+			})
+
+			Context("when no mount recoverer is set", func() {
+				BeforeEach(func() {
+					driverAdminLocal.SetMountRecoverer(nil)
+				})
+
+				It("fails", func() {
+					response := driverAdminLocal.ForceUnmount(env, driveradmin.VolumeSelector{Name: "vol-a"})
+					Expect(response.Err).To(ContainSubstring("not available"))
 				})
 			})
 		})
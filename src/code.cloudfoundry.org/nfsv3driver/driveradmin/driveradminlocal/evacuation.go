@@ -0,0 +1,134 @@
+package driveradminlocal
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+// evacuation drains the volumes once, in the background, and then stops the driver.
+type evacuation struct {
+	started  time.Time
+	finished time.Time
+	// names of the volumes mounted when the evacuation started, by mountpoint
+	names map[string]string
+	errs  []string
+}
+
+func (d *DriverAdminLocal) StartEvacuation(env dockerdriver.Env) driveradmin.EvacuationStatusResponse {
+	logger := env.Logger().Session("start-evacuation")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	err := d.startEvacuation(logger)
+	if err != "" {
+		return driveradmin.EvacuationStatusResponse{Err: err}
+	}
+	return d.EvacuationStatus(env)
+}
+
+func (d *DriverAdminLocal) EvacuationStatus(env dockerdriver.Env) driveradmin.EvacuationStatusResponse {
+	d.lock.Lock()
+	e := d.evacuation
+	var status driveradmin.EvacuationStatusResponse
+	if e == nil {
+		status.State = driveradmin.EvacuationNotStarted
+	} else {
+		status.StartedAt = e.started
+		end := time.Now()
+		status.State = driveradmin.EvacuationDraining
+		if !e.finished.IsZero() {
+			status.State = driveradmin.EvacuationDrained
+			end = e.finished
+		}
+		status.ElapsedSeconds = end.Sub(e.started).Seconds()
+		status.Err = strings.Join(e.errs, "; ")
+	}
+	d.lock.Unlock()
+
+	status.Remaining = []string{}
+	for _, volume := range d.mountedVolumes(env) {
+		status.Remaining = append(status.Remaining, volume.Name)
+	}
+	sort.Strings(status.Remaining)
+
+	status.Errors = map[string]string{}
+	if e != nil && d.unmounts != nil {
+		for mountpoint, err := range d.unmounts.UnmountFailures(e.started) {
+			name, ok := e.names[mountpoint]
+			if !ok {
+				name = mountpoint
+			}
+			status.Errors[name] = err
+		}
+	}
+
+	return status
+}
+
+// startEvacuation starts the evacuation unless it has been started already.
+func (d *DriverAdminLocal) startEvacuation(logger lager.Logger) string {
+	if d.serverProcess == nil {
+		return "unexpected error: server process not found"
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.evacuation != nil {
+		logger.Info("already-started", lager.Data{"started": d.evacuation.started})
+		return ""
+	}
+
+	env := driverhttp.NewHttpDriverEnv(logger, context.Background())
+	e := &evacuation{started: time.Now(), names: map[string]string{}}
+	for _, volume := range d.mountedVolumes(env) {
+		e.names[volume.Mountpoint] = volume.Name
+	}
+	d.evacuation = e
+
+	go d.evacuate(env, e)
+	return ""
+}
+
+func (d *DriverAdminLocal) evacuate(env dockerdriver.Env, e *evacuation) {
+	logger := env.Logger().Session("drain")
+	logger.Info("start")
+
+	var errs []string
+	for _, svr := range d.drainables {
+		if err := svr.Drain(env); err != nil {
+			logger.Error("failed-draining", err)
+			errs = append(errs, err.Error())
+		}
+	}
+
+	d.lock.Lock()
+	e.finished = time.Now()
+	e.errs = errs
+	d.lock.Unlock()
+
+	logger.Info("end", lager.Data{"elapsed": e.finished.Sub(e.started).String()})
+	d.serverProcess.Signal(os.Interrupt)
+}
+
+func (d *DriverAdminLocal) mountedVolumes(env dockerdriver.Env) []dockerdriver.VolumeInfo {
+	if d.volumes == nil {
+		return nil
+	}
+
+	var mounted []dockerdriver.VolumeInfo
+	for _, volume := range d.volumes.List(env).Volumes {
+		if volume.Mountpoint != "" && volume.MountCount > 0 {
+			mounted = append(mounted, volume)
+		}
+	}
+	return mounted
+}
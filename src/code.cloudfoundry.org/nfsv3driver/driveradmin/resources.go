@@ -1,33 +1,200 @@
 package driveradmin
 
 import (
+	"time"
+
 	"code.cloudfoundry.org/dockerdriver"
 	"github.com/tedsuo/rata"
 )
 
 const (
-	EvacuateRoute = "evacuate"
-	PingRoute     = "ping"
+	EvacuateRoute         = "evacuate"
+	StartEvacuationRoute  = "start_evacuation"
+	EvacuationStatusRoute = "evacuation_status"
+	PingRoute             = "ping"
+	ReadyRoute            = "ready"
+	MapfsRestartsRoute    = "mapfs_restarts"
+	VolumesRoute          = "volumes"
+	VolumeRoute           = "volume"
+	VolumeUnmountRoute    = "volume_unmount"
+	VolumeRemountRoute    = "volume_remount"
+	ServerUnmountRoute    = "server_unmount"
+	ServerRemountRoute    = "server_remount"
+)
+
+const (
+	EvacuationNotStarted = "not-started"
+	EvacuationDraining   = "draining"
+	EvacuationDrained    = "drained"
 )
 
 var Routes = rata.Routes{
 	{Path: "/evacuate", Method: "GET", Name: EvacuateRoute},
+	{Path: "/evacuate", Method: "POST", Name: StartEvacuationRoute},
+	{Path: "/evacuate/status", Method: "GET", Name: EvacuationStatusRoute},
 	{Path: "/ping", Method: "GET", Name: PingRoute},
+	{Path: "/ready", Method: "GET", Name: ReadyRoute},
+	{Path: "/mapfs/restarts", Method: "GET", Name: MapfsRestartsRoute},
+	{Path: "/volumes", Method: "GET", Name: VolumesRoute},
+	{Path: "/volumes/:name", Method: "GET", Name: VolumeRoute},
+	{Path: "/volumes/:name/unmount", Method: "POST", Name: VolumeUnmountRoute},
+	{Path: "/volumes/:name/remount", Method: "POST", Name: VolumeRemountRoute},
+	{Path: "/servers/:server/unmount", Method: "POST", Name: ServerUnmountRoute},
+	{Path: "/servers/:server/remount", Method: "POST", Name: ServerRemountRoute},
 }
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
 //counterfeiter:generate -o ../nfsdriverfakes/fake_driver_admin.go . DriverAdmin
 
 type DriverAdmin interface {
-	Evacuate(env dockerdriver.Env) ErrorResponse
+	// StartEvacuation starts to drain every volume in the background and then stops the driver, unless an
+	// evacuation has already been started.
+	StartEvacuation(env dockerdriver.Env) EvacuationStatusResponse
+	EvacuationStatus(env dockerdriver.Env) EvacuationStatusResponse
 	Ping(env dockerdriver.Env) ErrorResponse
+	// Ready checks that the cell can mount NFS shares, and lock files on them.
+	Ready(env dockerdriver.Env) ReadyResponse
+	MapfsRestarts(env dockerdriver.Env) MapfsRestartsResponse
+	Volumes(env dockerdriver.Env) VolumesResponse
+	Volume(env dockerdriver.Env, name string) VolumeResponse
+	ForceUnmount(env dockerdriver.Env, selector VolumeSelector) VolumeOperationsResponse
+	ForceRemount(env dockerdriver.Env, selector VolumeSelector) VolumeOperationsResponse
 }
 
 type ErrorResponse struct {
 	Err string
 }
 
+// EvacuationStatusResponse reports the progress of an evacuation. Remaining lists the volumes that are
+// still mounted, and Errors the volumes that failed to unmount, by name.
+type EvacuationStatusResponse struct {
+	State          string
+	StartedAt      time.Time
+	ElapsedSeconds float64
+	Remaining      []string
+	Errors         map[string]string
+	Err            string
+}
+
+// ReadyResponse is Ready when every check is.
+type ReadyResponse struct {
+	Ready  bool
+	Checks []ReadinessCheck
+	Err    string
+}
+
+// ReadinessCheck is the result of checking one prerequisite of NFS mounts. Err says why it is not Ready.
+type ReadinessCheck struct {
+	Name  string
+	Ready bool
+	Err   string
+}
+
+type MapfsRestartsResponse struct {
+	Restarts map[string]int
+	Err      string
+}
+
+type VolumesResponse struct {
+	Volumes []VolumeDetails
+	Err     string
+}
+
+// VolumeResponse has no Volume when the volume is not known to the driver.
+type VolumeResponse struct {
+	Volume *VolumeDetails
+	Err    string
+}
+
+type VolumeDetails struct {
+	Name        string
+	Mountpoint  string
+	MountCount  int
+	Mount       MountDetails
+	HealthCheck *HealthCheckResult
+}
+
+// MountDetails describes how the share of a volume is mounted on the cell. Credentials are never included.
+type MountDetails struct {
+	Source              string
+	Options             map[string]string
+	Uid                 string
+	Gid                 string
+	MapfsPid            int
+	IntermediateMount   string
+	IntermediateMounted bool
+}
+
+type HealthCheckResult struct {
+	Time       time.Time
+	Healthy    bool
+	Recoveries int
+}
+
+// VolumeSelector selects the volume named Name, or else every mounted volume whose share is on Server.
+type VolumeSelector struct {
+	Name   string
+	Server string
+}
+
+// VolumeOperationsResponse has a result per selected volume, and no Results when a volume selected by name is
+// not known to the driver. When volumes are selected by server, a mounted volume whose share is unknown has a
+// result with an error.
+type VolumeOperationsResponse struct {
+	Results []VolumeOperationResult
+	Err     string
+}
+
+type VolumeOperationResult struct {
+	Name       string
+	Mountpoint string
+	Source     string
+	Err        string
+}
+
 //counterfeiter:generate -o ../nfsdriverfakes/fake_drainable.go . Drainable
 type Drainable interface {
 	Drain(env dockerdriver.Env) error
 }
+
+//counterfeiter:generate -o ../nfsdriverfakes/fake_restart_counter.go . RestartCounter
+type RestartCounter interface {
+	MapfsRestarts() map[string]int
+}
+
+// VolumeLister lists the volumes known to the volume driver.
+type VolumeLister interface {
+	List(env dockerdriver.Env) dockerdriver.ListResponse
+}
+
+//counterfeiter:generate -o ../nfsdriverfakes/fake_mount_inspector.go . MountInspector
+type MountInspector interface {
+	InspectMount(mountpoint string) MountDetails
+}
+
+//counterfeiter:generate -o ../nfsdriverfakes/fake_health_reporter.go . HealthReporter
+type HealthReporter interface {
+	LastHealthCheck(name string) (HealthCheckResult, bool)
+}
+
+//counterfeiter:generate -o ../nfsdriverfakes/fake_mount_recoverer.go . MountRecoverer
+
+// MountRecoverer replaces the mounts of volumes whose NFS server has gone bad.
+type MountRecoverer interface {
+	ForceUnmount(env dockerdriver.Env, mountpoint string) error
+	Remount(env dockerdriver.Env, mountpoint string) error
+}
+
+//counterfeiter:generate -o ../nfsdriverfakes/fake_unmount_failure_reporter.go . UnmountFailureReporter
+
+// UnmountFailureReporter reports the mountpoints that failed to unmount, and why.
+type UnmountFailureReporter interface {
+	UnmountFailures(since time.Time) map[string]string
+}
+
+//counterfeiter:generate -o ../nfsdriverfakes/fake_readiness_checker.go . ReadinessChecker
+
+// ReadinessChecker checks the prerequisites of NFS mounts on the cell.
+type ReadinessChecker interface {
+	CheckReadiness(env dockerdriver.Env) []ReadinessCheck
+}
@@ -0,0 +1,168 @@
+package nfsv3driver
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/nfsv3driver/sunrpc"
+)
+
+const DefaultProbeTimeout = 2 * time.Second
+
+//counterfeiter:generate -o nfsdriverfakes/fake_export_prober.go . ExportProber
+
+// ExportProber checks that remote can be mounted before the kernel is asked to mount it, so that a
+// misconfigured share fails quickly with a precise error instead of waiting out the NFS retry timeouts.
+type ExportProber interface {
+	Probe(env dockerdriver.Env, remote string, version string) error
+}
+
+type rpcExportProber struct {
+	portmapperPort int
+	timeout        time.Duration
+}
+
+// NewRpcExportProber returns an ExportProber that asks the server's portmapper for the NFS and MOUNT
+// services and then asks MOUNT for the export, the same way mount.nfs does for NFSv3.
+func NewRpcExportProber(portmapperPort int, timeout time.Duration) ExportProber {
+	return &rpcExportProber{portmapperPort: portmapperPort, timeout: timeout}
+}
+
+func (p *rpcExportProber) Probe(env dockerdriver.Env, remote string, version string) error {
+	logger := env.Logger().Session("probe-export", lager.Data{"remote": remote, "version": version})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	if strings.HasPrefix(version, "4") {
+		// NFSv4 does not use the portmapper or the MOUNT protocol
+		logger.Info("skipped-for-nfsv4")
+		return nil
+	}
+
+	host, path, err := splitRemote(remote)
+	if err != nil {
+		return NewMountError(MountErrorNoSuchExport, err.Error())
+	}
+
+	deadline := time.Now().Add(p.timeout)
+
+	portmapper, err := sunrpc.Dial(net.JoinHostPort(host, strconv.Itoa(p.portmapperPort)), p.timeout)
+	if err != nil {
+		logger.Error("dial-portmapper-failed", err)
+		return probeError(err, "portmapper")
+	}
+	defer portmapper.Close()
+	_ = portmapper.SetDeadline(deadline)
+
+	nfsPort, err := portmapper.GetPort(sunrpc.NfsProgram, 3, sunrpc.ProtoTCP)
+	if err != nil {
+		logger.Error("getport-nfs-failed", err)
+		return probeError(err, "portmapper")
+	}
+	if nfsPort == 0 {
+		if version == "3" {
+			return NewMountError(MountErrorProtocolNotSupported, "the NFS server does not offer NFSv3 over TCP")
+		}
+		// without an explicit version the mount may still negotiate NFSv4, which the portmapper does not know about
+		logger.Info("nfsv3-not-registered-skipping-probe")
+		return nil
+	}
+
+	mountPort, err := portmapper.GetPort(sunrpc.MountProgram, sunrpc.MountVersion, sunrpc.ProtoTCP)
+	if err != nil {
+		logger.Error("getport-mount-failed", err)
+		return probeError(err, "portmapper")
+	}
+	if mountPort == 0 {
+		return NewMountError(MountErrorProtocolNotSupported, "the NFS server does not offer the MOUNT service over TCP")
+	}
+
+	mountd, err := sunrpc.DialPrivileged(net.JoinHostPort(host, strconv.Itoa(int(mountPort))), time.Until(deadline))
+	if err != nil {
+		logger.Error("dial-mountd-failed", err)
+		return probeError(err, "MOUNT service")
+	}
+	defer mountd.Close()
+	_ = mountd.SetDeadline(deadline)
+
+	machine, _ := os.Hostname()
+	mountd.Auth = sunrpc.AuthSys(machine, 0, 0)
+
+	_, err = mountd.Mnt(path)
+	if err != nil {
+		logger.Error("mnt-failed", err)
+		return probeError(err, "MOUNT service")
+	}
+
+	err = mountd.Umnt(path)
+	if err != nil {
+		logger.Error("umnt-failed", err)
+	}
+
+	return nil
+}
+
+func probeError(err error, service string) error {
+	var status sunrpc.MountStatus
+	if errors.As(err, &status) {
+		switch status {
+		case sunrpc.MountAccess, sunrpc.MountPerm:
+			return NewMountError(MountErrorAccessDenied, fmt.Sprintf("access denied by the NFS server; check that the export allows this client (%s)", err.Error()))
+		case sunrpc.MountNoEnt, sunrpc.MountNotDir:
+			return NewMountError(MountErrorNoSuchExport, fmt.Sprintf("the NFS server does not export the requested share (%s)", err.Error()))
+		default:
+			return NewMountError(MountErrorUnknown, err.Error())
+		}
+	}
+
+	var dnsError *net.DNSError
+	if errors.As(err, &dnsError) {
+		return NewMountError(MountErrorUnknownHost, fmt.Sprintf("the NFS server name could not be resolved (%s)", err.Error()))
+	}
+
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return NewMountError(MountErrorRPCTimeout, fmt.Sprintf("timed out waiting for the %s to respond", service))
+	}
+
+	if errors.Is(err, sunrpc.ErrProgramUnavailable) || errors.Is(err, sunrpc.ErrProgramMismatch) {
+		return NewMountError(MountErrorProtocolNotSupported, fmt.Sprintf("the NFS server does not support the requested protocol version (%s)", err.Error()))
+	}
+
+	var opError *net.OpError
+	if errors.As(err, &opError) {
+		return NewMountError(MountErrorServerUnreachable, fmt.Sprintf("the %s could not be reached (%s)", service, err.Error()))
+	}
+
+	return NewMountError(MountErrorUnknown, fmt.Sprintf("%s: %s", service, err.Error()))
+}
+
+// splitRemote splits an NFS share of the form host:/path or [ipv6]:/path.
+func splitRemote(remote string) (string, string, error) {
+	var host, path string
+	if strings.HasPrefix(remote, "[") {
+		end := strings.Index(remote, "]:")
+		if end < 0 {
+			return "", "", fmt.Errorf("invalid share %q", remote)
+		}
+		host, path = remote[1:end], remote[end+2:]
+	} else {
+		i := strings.Index(remote, ":")
+		if i < 0 {
+			return "", "", fmt.Errorf("invalid share %q", remote)
+		}
+		host, path = remote[:i], remote[i+1:]
+	}
+
+	if host == "" || !strings.HasPrefix(path, "/") {
+		return "", "", fmt.Errorf("invalid share %q", remote)
+	}
+	return host, path, nil
+}
@@ -0,0 +1,186 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/sunrpc"
+	"code.cloudfoundry.org/nfsv3driver/sunrpc/sunrpctest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RpcExportProber", func() {
+	var (
+		env      dockerdriver.Env
+		server   *sunrpctest.Server
+		port     int
+		mappings []sunrpc.Mapping
+		remote   string
+		version  string
+		timeout  time.Duration
+		err      error
+	)
+
+	BeforeEach(func() {
+		env = driverhttp.NewHttpDriverEnv(lagertest.NewTestLogger("export-prober"), context.TODO())
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		port = listener.Addr().(*net.TCPAddr).Port
+
+		// the fake server plays portmapper, NFS and MOUNT on a single port
+		server = sunrpctest.NewServer(listener)
+		mappings = []sunrpc.Mapping{
+			{Program: sunrpc.NfsProgram, Version: 3, Protocol: sunrpc.ProtoTCP, Port: uint32(port)},
+			{Program: sunrpc.MountProgram, Version: sunrpc.MountVersion, Protocol: sunrpc.ProtoTCP, Port: uint32(port)},
+		}
+		server.Register(sunrpc.MountProgram, sunrpc.MountVersion, sunrpctest.MountHandler(map[string]sunrpc.MountStatus{
+			"/export":  sunrpc.MountOK,
+			"/private": sunrpc.MountAccess,
+		}))
+		go func(server *sunrpctest.Server) { _ = server.Serve() }(server)
+
+		remote = "127.0.0.1:/export"
+		version = ""
+		timeout = time.Second
+	})
+
+	AfterEach(func() {
+		_ = server.Close()
+	})
+
+	JustBeforeEach(func() {
+		server.Register(sunrpc.PortmapperProgram, sunrpc.PortmapperVersion, sunrpctest.PortmapperHandler(mappings))
+		err = nfsv3driver.NewRpcExportProber(port, timeout).Probe(env, remote, version)
+	})
+
+	It("succeeds for an export the client may mount", func() {
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("when the client is not allowed to mount the export", func() {
+		BeforeEach(func() {
+			remote = "127.0.0.1:/private"
+		})
+
+		It("reports access denied", func() {
+			Expect(nfsv3driver.MountErrorCodeOf(err)).To(Equal(nfsv3driver.MountErrorAccessDenied))
+			var safeError dockerdriver.SafeError
+			Expect(errors.As(err, &safeError)).To(BeTrue())
+		})
+	})
+
+	Context("when the export does not exist", func() {
+		BeforeEach(func() {
+			remote = "127.0.0.1:/missing"
+		})
+
+		It("reports no such export", func() {
+			Expect(nfsv3driver.MountErrorCodeOf(err)).To(Equal(nfsv3driver.MountErrorNoSuchExport))
+		})
+	})
+
+	Context("when the server does not offer NFSv3", func() {
+		BeforeEach(func() {
+			mappings = mappings[1:]
+		})
+
+		Context("and version 3 was requested", func() {
+			BeforeEach(func() {
+				version = "3"
+			})
+
+			It("reports the protocol as not supported", func() {
+				Expect(nfsv3driver.MountErrorCodeOf(err)).To(Equal(nfsv3driver.MountErrorProtocolNotSupported))
+			})
+		})
+
+		Context("and no version was requested", func() {
+			It("leaves version negotiation to the mount", func() {
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+	})
+
+	Context("when the server does not offer the MOUNT service", func() {
+		BeforeEach(func() {
+			mappings = mappings[:1]
+		})
+
+		It("reports the protocol as not supported", func() {
+			Expect(nfsv3driver.MountErrorCodeOf(err)).To(Equal(nfsv3driver.MountErrorProtocolNotSupported))
+		})
+	})
+
+	Context("when NFSv4 is requested", func() {
+		BeforeEach(func() {
+			version = "4.1"
+			remote = "127.0.0.1:/missing"
+		})
+
+		It("does not probe", func() {
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when nothing listens on the portmapper port", func() {
+		BeforeEach(func() {
+			_ = server.Close()
+		})
+
+		It("reports the server as unreachable", func() {
+			Expect(nfsv3driver.MountErrorCodeOf(err)).To(Equal(nfsv3driver.MountErrorServerUnreachable))
+		})
+	})
+
+	Context("when the portmapper does not answer", func() {
+		var silent net.Listener
+
+		BeforeEach(func() {
+			silent, err = net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			timeout = 50 * time.Millisecond
+		})
+
+		JustBeforeEach(func() {
+			err = nfsv3driver.NewRpcExportProber(silent.Addr().(*net.TCPAddr).Port, timeout).Probe(env, remote, version)
+		})
+
+		AfterEach(func() {
+			_ = silent.Close()
+		})
+
+		It("reports a timeout", func() {
+			Expect(nfsv3driver.MountErrorCodeOf(err)).To(Equal(nfsv3driver.MountErrorRPCTimeout))
+		})
+	})
+
+	Context("when the share is not of the form host:/path", func() {
+		BeforeEach(func() {
+			remote = "127.0.0.1"
+		})
+
+		It("errors", func() {
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid share"))
+		})
+	})
+
+	Context("when the server is an IPv6 address", func() {
+		BeforeEach(func() {
+			remote = "[::1]:/export"
+		})
+
+		It("dials the address without brackets", func() {
+			// the fake server only listens on IPv4, so the dial is refused rather than failing to parse
+			Expect(nfsv3driver.MountErrorCodeOf(err)).To(Equal(nfsv3driver.MountErrorServerUnreachable))
+		})
+	})
+})
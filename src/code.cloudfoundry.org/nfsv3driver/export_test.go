@@ -0,0 +1,3 @@
+package nfsv3driver
+
+var ClassifyMountError = classifyMountError
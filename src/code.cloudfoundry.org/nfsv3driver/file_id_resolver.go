@@ -0,0 +1,146 @@
+package nfsv3driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/goshims/osshim"
+	"code.cloudfoundry.org/lager/v3"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// StaticUser maps a username and password to the ids of a volume mount.
+type StaticUser struct {
+	Username     string `json:"username"`
+	PasswordHash string `json:"password_hash"`
+	Uid          int    `json:"uid"`
+	Gid          int    `json:"gid"`
+	Groups       []int  `json:"groups,omitempty"`
+
+	gids []string
+}
+
+type staticUsersFile struct {
+	Users []StaticUser `json:"users"`
+}
+
+// fileIdResolver resolves usernames from a JSON file of StaticUsers with bcrypt password hashes, for
+// sites without a directory service. The file is read again whenever its modification time changes.
+type fileIdResolver struct {
+	os   osshim.Os
+	path string
+
+	// used to take as long to reject an unknown user as a wrong password
+	dummyHash []byte
+
+	lock    sync.Mutex
+	modTime time.Time
+	users   map[string]StaticUser
+}
+
+func NewFileIdResolver(os osshim.Os, path string) (IdResolver, error) {
+	r := &fileIdResolver{os: os, path: path}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	r.users = users
+	r.modTime = info.ModTime()
+
+	r.dummyHash, err = bcrypt.GenerateFromPassword([]byte("dummy"), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *fileIdResolver) Resolve(env dockerdriver.Env, username string, password string) (string, string, []string, error) {
+	logger := env.Logger().Session("file-resolve", lager.Data{"username": username})
+
+	user, ok := r.lookup(logger, username)
+	if !ok {
+		_ = bcrypt.CompareHashAndPassword(r.dummyHash, []byte(password))
+		return "", "", nil, dockerdriver.SafeError{SafeDescription: UserDoesNotExistErrorMessage}
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	if err != nil {
+		return "", "", nil, dockerdriver.SafeError{SafeDescription: InvalidCredentialsErrorMessage}
+	}
+
+	return strconv.Itoa(user.Uid), strconv.Itoa(user.Gid), user.gids, nil
+}
+
+// lookup returns the user named username, reloading the file first when it has changed. When the
+// changed file cannot be loaded, the users loaded before are kept.
+func (r *fileIdResolver) lookup(logger lager.Logger, username string) (StaticUser, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	info, err := r.os.Stat(r.path)
+	if err != nil {
+		logger.Error("stat-users-file-failed", err, lager.Data{"path": r.path})
+	} else if !info.ModTime().Equal(r.modTime) {
+		users, err := r.load()
+		if err != nil {
+			logger.Error("reload-users-file-failed", err, lager.Data{"path": r.path})
+		} else {
+			logger.Info("reloaded-users-file", lager.Data{"path": r.path, "users": len(users)})
+			r.users = users
+			r.modTime = info.ModTime()
+		}
+	}
+
+	user, ok := r.users[username]
+	return user, ok
+}
+
+func (r *fileIdResolver) load() (map[string]StaticUser, error) {
+	data, err := r.os.ReadFile(r.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file staticUsersFile
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid users file %s: %s", r.path, err.Error())
+	}
+
+	users := map[string]StaticUser{}
+	for _, user := range file.Users {
+		if user.Username == "" {
+			return nil, fmt.Errorf("invalid users file %s: a user has no username", r.path)
+		}
+		if _, ok := users[user.Username]; ok {
+			return nil, fmt.Errorf("invalid users file %s: user %q is listed more than once", r.path, user.Username)
+		}
+		if _, err := bcrypt.Cost([]byte(user.PasswordHash)); err != nil {
+			return nil, fmt.Errorf("invalid users file %s: password hash of user %q is not a bcrypt hash", r.path, user.Username)
+		}
+		if user.Uid <= 0 || user.Gid <= 0 {
+			return nil, fmt.Errorf("invalid users file %s: uid and gid of user %q must be positive", r.path, user.Username)
+		}
+
+		for _, group := range user.Groups {
+			if group <= 0 {
+				return nil, fmt.Errorf("invalid users file %s: groups of user %q must be positive", r.path, user.Username)
+			}
+			user.gids = append(user.gids, strconv.Itoa(group))
+		}
+		users[user.Username] = user
+	}
+
+	return users, nil
+}
@@ -0,0 +1,138 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/osshim/os_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var _ = Describe("FileIdResolver", func() {
+	var (
+		logger   *lagertest.TestLogger
+		env      dockerdriver.Env
+		fakeOs   *os_fake.FakeOs
+		contents string
+		modTime  time.Time
+		hash     string
+
+		subject nfsv3driver.IdResolver
+		err     error
+	)
+
+	usersFile := func(users string) string {
+		return fmt.Sprintf(`{"users": [%s]}`, users)
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("file-id-resolver")
+		env = driverhttp.NewHttpDriverEnv(logger, context.TODO())
+
+		password, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+		Expect(err).NotTo(HaveOccurred())
+		hash = string(password)
+
+		contents = usersFile(fmt.Sprintf(`{"username": "alice", "password_hash": %q, "uid": 1001, "gid": 1002, "groups": [2000, 2001]}`, hash))
+		modTime = time.Unix(1700000000, 0)
+
+		fakeOs = &os_fake.FakeOs{}
+		fakeOs.StatStub = func(string) (os.FileInfo, error) { return fakeFileInfo{modTime: modTime}, nil }
+		fakeOs.ReadFileStub = func(string) ([]byte, error) { return []byte(contents), nil }
+	})
+
+	JustBeforeEach(func() {
+		subject, err = nfsv3driver.NewFileIdResolver(fakeOs, "/var/vcap/jobs/nfsv3driver/config/static_users.json")
+	})
+
+	It("resolves a user with the right password", func() {
+		Expect(err).NotTo(HaveOccurred())
+
+		uid, gid, groups, err := subject.Resolve(env, "alice", "secret")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uid).To(Equal("1001"))
+		Expect(gid).To(Equal("1002"))
+		Expect(groups).To(Equal([]string{"2000", "2001"}))
+	})
+
+	It("rejects a wrong password", func() {
+		_, _, _, err := subject.Resolve(env, "alice", "wrong")
+		Expect(err).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+		Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+	})
+
+	It("reports a user that is not in the file as missing", func() {
+		_, _, _, err := subject.Resolve(env, "bob", "secret")
+		Expect(err).To(MatchError(nfsv3driver.UserDoesNotExistErrorMessage))
+		Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+	})
+
+	Context("when the file changes", func() {
+		JustBeforeEach(func() {
+			contents = usersFile(fmt.Sprintf(`{"username": "bob", "password_hash": %q, "uid": 1003, "gid": 1003}`, hash))
+		})
+
+		It("only reads it again once its modification time changes", func() {
+			_, _, _, err := subject.Resolve(env, "bob", "secret")
+			Expect(err).To(MatchError(nfsv3driver.UserDoesNotExistErrorMessage))
+
+			modTime = modTime.Add(time.Second)
+			uid, _, groups, err := subject.Resolve(env, "bob", "secret")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uid).To(Equal("1003"))
+			Expect(groups).To(BeEmpty())
+			Expect(fakeOs.ReadFileCallCount()).To(Equal(2))
+		})
+
+		It("keeps the users loaded before when the new file is invalid", func() {
+			contents = "{"
+			modTime = modTime.Add(time.Second)
+
+			_, _, _, err := subject.Resolve(env, "alice", "secret")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logger.LogMessages()).To(ContainElement("file-id-resolver.file-resolve.reload-users-file-failed"))
+		})
+	})
+
+	Context("when the file cannot be read", func() {
+		BeforeEach(func() {
+			fakeOs.ReadFileStub = func(string) ([]byte, error) { return nil, errors.New("permission denied") }
+		})
+
+		It("fails to create the resolver", func() {
+			Expect(err).To(MatchError("permission denied"))
+		})
+	})
+
+	DescribeTable("invalid files",
+		func(users string, message string) {
+			contents = usersFile(fmt.Sprintf(users, hash))
+			_, err := nfsv3driver.NewFileIdResolver(fakeOs, "/users.json")
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("without a username", `{"password_hash": %q, "uid": 1, "gid": 1}`, "has no username"),
+		Entry("with a duplicate user", `{"username": "a", "password_hash": %[1]q, "uid": 1, "gid": 1}, {"username": "a", "password_hash": %[1]q, "uid": 2, "gid": 2}`, "more than once"),
+		Entry("with a plaintext password", `{"username": "a", "password_hash": "secret%.0s", "uid": 1, "gid": 1}`, "not a bcrypt hash"),
+		Entry("with a zero uid", `{"username": "a", "password_hash": %q, "uid": 0, "gid": 1}`, "must be positive"),
+		Entry("with a negative group", `{"username": "a", "password_hash": %q, "uid": 1, "gid": 1, "groups": [-1]}`, "must be positive"),
+	)
+})
+
+type fakeFileInfo struct {
+	os.FileInfo
+	modTime time.Time
+	size    int64
+}
+
+func (f fakeFileInfo) ModTime() time.Time { return f.modTime }
+
+func (f fakeFileInfo) Size() int64 { return f.size }
@@ -0,0 +1,152 @@
+package nfsv3driver
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+const DefaultHealthCheckInterval = time.Second * 30
+
+// HealthCheckConcurrency bounds how many volumes are checked, or remounted, at the same time, so that a few
+// volumes whose server does not answer do not hold up the checks of the others.
+var HealthCheckConcurrency = 8
+
+//counterfeiter:generate -o nfsdriverfakes/fake_volume_lister.go . VolumeLister
+
+// VolumeLister lists the volumes known to the volume driver.
+type VolumeLister interface {
+	List(env dockerdriver.Env) dockerdriver.ListResponse
+}
+
+// HealthMonitor periodically checks every volume that is in use and remounts the ones that have gone
+// unhealthy, for example because their export was recreated on the server. It is an ifrit.Runner.
+type HealthMonitor struct {
+	logger   lager.Logger
+	volumes  VolumeLister
+	mounter  RecoverableMounter
+	interval time.Duration
+
+	lock       sync.Mutex
+	recoveries map[string]int
+	lastChecks map[string]driveradmin.HealthCheckResult
+}
+
+func NewHealthMonitor(logger lager.Logger, volumes VolumeLister, mounter RecoverableMounter, interval time.Duration) *HealthMonitor {
+	return &HealthMonitor{
+		logger:     logger.Session("health-monitor"),
+		volumes:    volumes,
+		mounter:    mounter,
+		interval:   interval,
+		recoveries: map[string]int{},
+		lastChecks: map[string]driveradmin.HealthCheckResult{},
+	}
+}
+
+func (h *HealthMonitor) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	close(ready)
+
+	for {
+		select {
+		case <-signals:
+			return nil
+		case <-ticker.C:
+			h.checkVolumes()
+		}
+	}
+}
+
+// Recoveries returns how many times each volume has been remounted.
+func (h *HealthMonitor) Recoveries() map[string]int {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	ret := make(map[string]int, len(h.recoveries))
+	for name, count := range h.recoveries {
+		ret[name] = count
+	}
+	return ret
+}
+
+// LastHealthCheck returns the result of the last check of the named volume, if it has been checked.
+func (h *HealthMonitor) LastHealthCheck(name string) (driveradmin.HealthCheckResult, bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	result, ok := h.lastChecks[name]
+	result.Recoveries = h.recoveries[name]
+	return result, ok
+}
+
+func (h *HealthMonitor) checkVolumes() {
+	env := driverhttp.NewHttpDriverEnv(h.logger, context.TODO())
+
+	checked := map[string]bool{}
+	defer h.forgetUnchecked(checked)
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, HealthCheckConcurrency)
+	for _, volume := range h.volumes.List(env).Volumes {
+		if volume.MountCount < 1 || volume.Mountpoint == "" {
+			continue
+		}
+		checked[volume.Name] = true
+
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(volume dockerdriver.VolumeInfo) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			h.checkVolume(env, volume)
+		}(volume)
+	}
+	wg.Wait()
+}
+
+func (h *HealthMonitor) checkVolume(env dockerdriver.Env, volume dockerdriver.VolumeInfo) {
+	healthy := h.mounter.Check(env, volume.Name, volume.Mountpoint)
+	h.lock.Lock()
+	h.lastChecks[volume.Name] = driveradmin.HealthCheckResult{Time: time.Now(), Healthy: healthy}
+	h.lock.Unlock()
+	if healthy {
+		return
+	}
+
+	logger := h.logger.Session("recover", lager.Data{"volume": volume.Name, "mountpoint": volume.Mountpoint})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	err := h.mounter.Remount(driverhttp.EnvWithLogger(logger, env), volume.Mountpoint)
+	if err != nil {
+		logger.Error("remount-failed", err)
+		return
+	}
+
+	h.lock.Lock()
+	h.recoveries[volume.Name]++
+	count := h.recoveries[volume.Name]
+	h.lock.Unlock()
+
+	logger.Info("remounted", lager.Data{"recoveries": count})
+}
+
+// forgetUnchecked drops the results of the volumes that are no longer mounted.
+func (h *HealthMonitor) forgetUnchecked(checked map[string]bool) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for name := range h.lastChecks {
+		if !checked[name] {
+			delete(h.lastChecks, name)
+		}
+	}
+}
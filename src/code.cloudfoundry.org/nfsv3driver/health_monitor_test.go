@@ -0,0 +1,142 @@
+package nfsv3driver_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/tedsuo/ifrit"
+)
+
+var _ = Describe("HealthMonitor", func() {
+	var (
+		logger      *lagertest.TestLogger
+		fakeVolumes *nfsdriverfakes.FakeVolumeLister
+		fakeMounter *nfsdriverfakes.FakeRecoverableMounter
+		monitor     *nfsv3driver.HealthMonitor
+		process     ifrit.Process
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("health-monitor")
+		fakeVolumes = &nfsdriverfakes.FakeVolumeLister{}
+		fakeVolumes.ListReturns(dockerdriver.ListResponse{Volumes: []dockerdriver.VolumeInfo{
+			{Name: "in-use", Mountpoint: "/mounts/in-use", MountCount: 2},
+			{Name: "unused", Mountpoint: "/mounts/unused", MountCount: 0},
+		}})
+		fakeMounter = &nfsdriverfakes.FakeRecoverableMounter{}
+		fakeMounter.CheckReturns(true)
+
+		monitor = nfsv3driver.NewHealthMonitor(logger, fakeVolumes, fakeMounter, 10*time.Millisecond)
+	})
+
+	JustBeforeEach(func() {
+		process = ifrit.Invoke(monitor)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive(BeNil()))
+	})
+
+	It("only checks volumes that are in use", func() {
+		Eventually(fakeMounter.CheckCallCount).Should(BeNumerically(">=", 2))
+		for i := 0; i < fakeMounter.CheckCallCount(); i++ {
+			_, name, mountpoint := fakeMounter.CheckArgsForCall(i)
+			Expect(name).To(Equal("in-use"))
+			Expect(mountpoint).To(Equal("/mounts/in-use"))
+		}
+	})
+
+	It("leaves healthy volumes alone", func() {
+		Eventually(fakeMounter.CheckCallCount).Should(BeNumerically(">=", 2))
+		Expect(fakeMounter.RemountCallCount()).To(BeZero())
+		Expect(monitor.Recoveries()).To(BeEmpty())
+	})
+
+	It("reports the last check of each volume that is in use", func() {
+		Eventually(func() bool {
+			_, ok := monitor.LastHealthCheck("in-use")
+			return ok
+		}).Should(BeTrue())
+
+		result, _ := monitor.LastHealthCheck("in-use")
+		Expect(result.Healthy).To(BeTrue())
+		Expect(result.Time).NotTo(BeZero())
+
+		_, ok := monitor.LastHealthCheck("unused")
+		Expect(ok).To(BeFalse())
+	})
+
+	Context("when a volume is unhealthy", func() {
+		BeforeEach(func() {
+			fakeMounter.CheckReturnsOnCall(0, false)
+		})
+
+		It("remounts it and counts the recovery", func() {
+			Eventually(fakeMounter.RemountCallCount).Should(Equal(1))
+			_, target := fakeMounter.RemountArgsForCall(0)
+			Expect(target).To(Equal("/mounts/in-use"))
+
+			Eventually(monitor.Recoveries).Should(Equal(map[string]int{"in-use": 1}))
+			Eventually(logger.Buffer()).Should(gbytes.Say("remounted"))
+
+			result, _ := monitor.LastHealthCheck("in-use")
+			Expect(result.Recoveries).To(Equal(1))
+		})
+
+		Context("when the remount fails", func() {
+			BeforeEach(func() {
+				fakeMounter.CheckReturns(false)
+				fakeMounter.RemountReturns(errors.New("server unreachable"))
+			})
+
+			It("keeps trying without counting a recovery", func() {
+				Eventually(fakeMounter.RemountCallCount).Should(BeNumerically(">=", 2))
+				Expect(monitor.Recoveries()).To(BeEmpty())
+				Expect(logger.Buffer()).To(gbytes.Say("remount-failed"))
+			})
+		})
+	})
+
+	Context("when the check of a volume hangs", func() {
+		var unblock chan struct{}
+
+		BeforeEach(func() {
+			fakeVolumes.ListReturns(dockerdriver.ListResponse{Volumes: []dockerdriver.VolumeInfo{
+				{Name: "hung", Mountpoint: "/mounts/hung", MountCount: 1},
+				{Name: "in-use", Mountpoint: "/mounts/in-use", MountCount: 1},
+			}})
+
+			unblock = make(chan struct{})
+			blocked := unblock
+			fakeMounter.CheckStub = func(_ dockerdriver.Env, name string, _ string) bool {
+				if name == "hung" {
+					<-blocked
+				}
+				return true
+			}
+		})
+
+		AfterEach(func() {
+			close(unblock)
+		})
+
+		It("checks the other volumes", func() {
+			Eventually(func() bool {
+				_, ok := monitor.LastHealthCheck("in-use")
+				return ok
+			}).Should(BeTrue())
+
+			_, ok := monitor.LastHealthCheck("hung")
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
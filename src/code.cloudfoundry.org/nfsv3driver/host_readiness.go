@@ -0,0 +1,145 @@
+package nfsv3driver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/goshims/osshim"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+	"code.cloudfoundry.org/nfsv3driver/sunrpc"
+)
+
+const DefaultReadinessTimeout = 2 * time.Second
+const MountNfsPath = "/sbin/mount.nfs"
+
+const (
+	ReadinessCheckRpcbind   = "rpcbind"
+	ReadinessCheckStatd     = "status"
+	ReadinessCheckNlockmgr  = "nlockmgr"
+	ReadinessCheckMountNfs  = "mount.nfs"
+	ReadinessCheckMapfs     = "mapfs"
+	ReadinessCheckMountRoot = "mount-root"
+)
+
+type hostReadinessChecker struct {
+	os             osshim.Os
+	portmapperAddr string
+	timeout        time.Duration
+	mountNfsPath   string
+	mapfsPath      string
+	mountRoot      string
+}
+
+// NewHostReadinessChecker returns a ReadinessChecker for the host services NFSv3 mounts depend on: the local
+// rpcbind at portmapperAddr, with rpc.statd and the kernel lock manager registered with it, so that files on the
+// shares can be locked, the mount.nfs and mapfs binaries, and a writable directory to mount the shares in.
+func NewHostReadinessChecker(os osshim.Os, portmapperAddr string, timeout time.Duration, mountNfsPath string, mapfsPath string, mountRoot string) driveradmin.ReadinessChecker {
+	return &hostReadinessChecker{
+		os:             os,
+		portmapperAddr: portmapperAddr,
+		timeout:        timeout,
+		mountNfsPath:   mountNfsPath,
+		mapfsPath:      mapfsPath,
+		mountRoot:      mountRoot,
+	}
+}
+
+func (c *hostReadinessChecker) CheckReadiness(env dockerdriver.Env) []driveradmin.ReadinessCheck {
+	logger := env.Logger().Session("check-readiness")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	registered, err := c.rpcRegistrations()
+	checks := []driveradmin.ReadinessCheck{
+		readinessCheck(ReadinessCheckRpcbind, err),
+	}
+	for _, program := range []struct {
+		name   string
+		number uint32
+	}{
+		{ReadinessCheckStatd, sunrpc.StatusProgram},
+		{ReadinessCheckNlockmgr, sunrpc.NlockmgrProgram},
+	} {
+		programErr := err
+		if programErr == nil && !registered[program.number] {
+			programErr = fmt.Errorf("program %d is not registered with rpcbind", program.number)
+		}
+		checks = append(checks, readinessCheck(program.name, programErr))
+	}
+
+	checks = append(checks, readinessCheck(ReadinessCheckMountNfs, c.checkExecutable(c.mountNfsPath)))
+	if c.mapfsPath != "" {
+		checks = append(checks, readinessCheck(ReadinessCheckMapfs, c.checkExecutable(c.mapfsPath)))
+	}
+	checks = append(checks, readinessCheck(ReadinessCheckMountRoot, c.checkWritable(c.mountRoot)))
+
+	for _, check := range checks {
+		if !check.Ready {
+			logger.Info("not-ready", lager.Data{"check": check.Name, "error": check.Err})
+		}
+	}
+	return checks
+}
+
+// rpcRegistrations pings rpcbind with a NULL call and returns the programs registered with it.
+func (c *hostReadinessChecker) rpcRegistrations() (map[uint32]bool, error) {
+	portmapper, err := sunrpc.Dial(c.portmapperAddr, c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer portmapper.Close()
+	_ = portmapper.SetDeadline(time.Now().Add(c.timeout))
+
+	err = portmapper.Null(sunrpc.PortmapperProgram, sunrpc.PortmapperVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	mappings, err := portmapper.Dump()
+	if err != nil {
+		return nil, err
+	}
+
+	registered := map[uint32]bool{}
+	for _, mapping := range mappings {
+		registered[mapping.Program] = true
+	}
+	return registered, nil
+}
+
+func (c *hostReadinessChecker) checkExecutable(path string) error {
+	info, err := c.os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", path)
+	}
+	if info.Mode().Perm()&0111 == 0 {
+		return fmt.Errorf("%s is not executable", path)
+	}
+	return nil
+}
+
+// checkWritable creates and removes a file in dir, the way mounts create their mountpoints in it.
+func (c *hostReadinessChecker) checkWritable(dir string) error {
+	path := filepath.Join(dir, fmt.Sprintf(".ready-%d", c.os.Getpid()))
+	file, err := c.os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	_ = file.Close()
+	return c.os.Remove(path)
+}
+
+func readinessCheck(name string, err error) driveradmin.ReadinessCheck {
+	if err != nil {
+		return driveradmin.ReadinessCheck{Name: name, Err: err.Error()}
+	}
+	return driveradmin.ReadinessCheck{Name: name, Ready: true}
+}
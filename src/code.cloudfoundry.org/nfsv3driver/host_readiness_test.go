@@ -0,0 +1,164 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/osshim"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+	"code.cloudfoundry.org/nfsv3driver/sunrpc"
+	"code.cloudfoundry.org/nfsv3driver/sunrpc/sunrpctest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HostReadinessChecker", func() {
+	var (
+		env            dockerdriver.Env
+		server         *sunrpctest.Server
+		portmapperAddr string
+		mappings       []sunrpc.Mapping
+		dir            string
+		mountNfsPath   string
+		mapfsPath      string
+		mountRoot      string
+		checks         []driveradmin.ReadinessCheck
+	)
+
+	check := func(name string) driveradmin.ReadinessCheck {
+		for _, check := range checks {
+			if check.Name == name {
+				return check
+			}
+		}
+		Fail("no " + name + " check")
+		return driveradmin.ReadinessCheck{}
+	}
+
+	BeforeEach(func() {
+		env = driverhttp.NewHttpDriverEnv(lagertest.NewTestLogger("host-readiness"), context.TODO())
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		portmapperAddr = listener.Addr().String()
+		server = sunrpctest.NewServer(listener)
+		go func(server *sunrpctest.Server) { _ = server.Serve() }(server)
+
+		mappings = []sunrpc.Mapping{
+			{Program: sunrpc.PortmapperProgram, Version: sunrpc.PortmapperVersion, Protocol: sunrpc.ProtoTCP, Port: 111},
+			{Program: sunrpc.StatusProgram, Version: 1, Protocol: sunrpc.ProtoUDP, Port: 41793},
+			{Program: sunrpc.NlockmgrProgram, Version: 4, Protocol: sunrpc.ProtoTCP, Port: 40001},
+		}
+
+		dir, err = os.MkdirTemp("", "host-readiness")
+		Expect(err).NotTo(HaveOccurred())
+		mountNfsPath = filepath.Join(dir, "mount.nfs")
+		Expect(os.WriteFile(mountNfsPath, []byte("#!/bin/sh\n"), 0755)).To(Succeed())
+		mapfsPath = filepath.Join(dir, "mapfs")
+		Expect(os.WriteFile(mapfsPath, []byte("#!/bin/sh\n"), 0755)).To(Succeed())
+		mountRoot = filepath.Join(dir, "volumes")
+		Expect(os.Mkdir(mountRoot, 0755)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		_ = server.Close()
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	JustBeforeEach(func() {
+		server.Register(sunrpc.PortmapperProgram, sunrpc.PortmapperVersion, sunrpctest.PortmapperHandler(mappings))
+		checks = nfsv3driver.NewHostReadinessChecker(&osshim.OsShim{}, portmapperAddr, time.Second, mountNfsPath, mapfsPath, mountRoot).CheckReadiness(env)
+	})
+
+	It("reports every check ready on a healthy host", func() {
+		Expect(checks).To(Equal([]driveradmin.ReadinessCheck{
+			{Name: nfsv3driver.ReadinessCheckRpcbind, Ready: true},
+			{Name: nfsv3driver.ReadinessCheckStatd, Ready: true},
+			{Name: nfsv3driver.ReadinessCheckNlockmgr, Ready: true},
+			{Name: nfsv3driver.ReadinessCheckMountNfs, Ready: true},
+			{Name: nfsv3driver.ReadinessCheckMapfs, Ready: true},
+			{Name: nfsv3driver.ReadinessCheckMountRoot, Ready: true},
+		}))
+	})
+
+	It("leaves nothing behind in the mount root", func() {
+		entries, err := os.ReadDir(mountRoot)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	Context("when rpc.statd is not registered", func() {
+		BeforeEach(func() {
+			mappings = mappings[:1]
+		})
+
+		It("reports statd and the lock manager as not ready", func() {
+			Expect(check(nfsv3driver.ReadinessCheckRpcbind).Ready).To(BeTrue())
+			Expect(check(nfsv3driver.ReadinessCheckStatd).Ready).To(BeFalse())
+			Expect(check(nfsv3driver.ReadinessCheckStatd).Err).To(Equal("program 100024 is not registered with rpcbind"))
+			Expect(check(nfsv3driver.ReadinessCheckNlockmgr).Ready).To(BeFalse())
+		})
+	})
+
+	Context("when rpcbind is not running", func() {
+		BeforeEach(func() {
+			Expect(server.Close()).To(Succeed())
+		})
+
+		It("reports every rpc check as not ready", func() {
+			Expect(check(nfsv3driver.ReadinessCheckRpcbind).Ready).To(BeFalse())
+			Expect(check(nfsv3driver.ReadinessCheckRpcbind).Err).To(ContainSubstring("connection refused"))
+			Expect(check(nfsv3driver.ReadinessCheckStatd).Ready).To(BeFalse())
+			Expect(check(nfsv3driver.ReadinessCheckNlockmgr).Ready).To(BeFalse())
+			Expect(check(nfsv3driver.ReadinessCheckMountNfs).Ready).To(BeTrue())
+		})
+	})
+
+	Context("when mount.nfs is missing", func() {
+		BeforeEach(func() {
+			Expect(os.Remove(mountNfsPath)).To(Succeed())
+		})
+
+		It("reports it as not ready", func() {
+			Expect(check(nfsv3driver.ReadinessCheckMountNfs).Ready).To(BeFalse())
+			Expect(check(nfsv3driver.ReadinessCheckMountNfs).Err).To(ContainSubstring("no such file or directory"))
+		})
+	})
+
+	Context("when mapfs is not executable", func() {
+		BeforeEach(func() {
+			Expect(os.Chmod(mapfsPath, 0644)).To(Succeed())
+		})
+
+		It("reports it as not ready", func() {
+			Expect(check(nfsv3driver.ReadinessCheckMapfs)).To(Equal(driveradmin.ReadinessCheck{Name: nfsv3driver.ReadinessCheckMapfs, Err: mapfsPath + " is not executable"}))
+		})
+	})
+
+	Context("when no mapfs is configured", func() {
+		BeforeEach(func() {
+			mapfsPath = ""
+		})
+
+		It("does not check it", func() {
+			Expect(checks).NotTo(ContainElement(HaveField("Name", nfsv3driver.ReadinessCheckMapfs)))
+		})
+	})
+
+	Context("when the mount root does not exist", func() {
+		BeforeEach(func() {
+			Expect(os.Remove(mountRoot)).To(Succeed())
+		})
+
+		It("reports it as not ready", func() {
+			Expect(check(nfsv3driver.ReadinessCheckMountRoot).Ready).To(BeFalse())
+		})
+	})
+})
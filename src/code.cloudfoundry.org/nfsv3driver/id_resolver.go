@@ -4,20 +4,28 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
-	"fmt"
+	"strconv"
 	"time"
 
 	"code.cloudfoundry.org/dockerdriver"
 	"code.cloudfoundry.org/goshims/ldapshim"
+	"code.cloudfoundry.org/lager/v3"
 	"gopkg.in/ldap.v2"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
 //counterfeiter:generate -o nfsdriverfakes/fake_id_resolver.go . IdResolver
 type IdResolver interface {
-	Resolve(env dockerdriver.Env, username string, password string) (uid string, gid string, err error)
+	// Resolve returns the uid and primary gid of username, and the gids of the supplementary groups they
+	// belong to when the resolver looks those up.
+	Resolve(env dockerdriver.Env, username string, password string) (uid string, gid string, groups []string, err error)
 }
 
+const (
+	UserDoesNotExistErrorMessage   = "User does not exist"
+	InvalidCredentialsErrorMessage = "Invalid credentials"
+)
+
 type ldapIdResolver struct {
 	svcUser     string
 	svcPass     string
@@ -28,6 +36,29 @@ type ldapIdResolver struct {
 	ldapCACert  string
 	ldap        ldapshim.Ldap
 	ldapTimeout time.Duration
+	pool        *ldapConnectionPool
+	servers     *LdapServers
+	ldapSearch  LdapSearch
+	tlsMode     LdapTLSMode
+	audit       AuditLog
+}
+
+type LdapIdResolverOption func(*ldapIdResolver)
+
+// WithLdapConnectionPool keeps up to size connections bound as the service account open between
+// resolutions, closing the ones left idle for longer than idleTimeout. Users' passwords are then
+// verified on connections of their own, so that a failed user bind never affects a pooled connection.
+func WithLdapConnectionPool(size int, idleTimeout time.Duration) LdapIdResolverOption {
+	return func(d *ldapIdResolver) {
+		d.pool = newLdapConnectionPool(size, idleTimeout, d.dialService, d.bindService)
+	}
+}
+
+// WithLdapAuditLog records every resolution, successful or not, in audit.
+func WithLdapAuditLog(audit AuditLog) LdapIdResolverOption {
+	return func(d *ldapIdResolver) {
+		d.audit = audit
+	}
 }
 
 func NewLdapIdResolver(
@@ -40,8 +71,9 @@ func NewLdapIdResolver(
 	ldapCACert string,
 	ldap ldapshim.Ldap,
 	ldapTimeout time.Duration,
+	options ...LdapIdResolverOption,
 ) IdResolver {
-	return &ldapIdResolver{
+	d := &ldapIdResolver{
 		svcUser:     svcUser,
 		svcPass:     svcPass,
 		ldapHost:    ldapHost,
@@ -51,39 +83,199 @@ func NewLdapIdResolver(
 		ldapCACert:  ldapCACert,
 		ldap:        ldap,
 		ldapTimeout: ldapTimeout,
+		ldapSearch:  LdapSearch{}.withDefaults(),
+	}
+	d.tlsMode, _ = ParseLdapTLSMode("", ldapCACert)
+	for _, option := range options {
+		option(d)
 	}
+	if d.servers == nil {
+		d.servers = NewStaticLdapServers([]LdapEndpoint{{Host: ldapHost, Port: ldapPort}}, 0)
+	}
+	return d
 }
 
-func (d *ldapIdResolver) Resolve(env dockerdriver.Env, username string, password string) (uid string, gid string, err error) {
-	addr := fmt.Sprintf("%s:%d", d.ldapHost, d.ldapPort)
+func (d *ldapIdResolver) Resolve(env dockerdriver.Env, username string, password string) (string, string, []string, error) {
+	start := time.Now()
+	uid, gid, groups, err := d.resolve(env, username, password)
+	if d.audit != nil {
+		d.audit.Record(env, AuditRecord{Event: AuditEventResolve, Username: username, Uid: uid, Gid: gid}.finished(start, err))
+	}
+	return uid, gid, groups, err
+}
+
+func (d *ldapIdResolver) resolve(env dockerdriver.Env, username string, password string) (uid string, gid string, groups []string, err error) {
+	if d.pool == nil {
+		l, err := d.dialService(env)
+		if err != nil {
+			return "", "", nil, err
+		}
+		defer l.Close()
+
+		user, err := d.search(l, username)
+		if err != nil {
+			return "", "", nil, err
+		}
+
+		// Bind as the user to verify their password
+		err = l.Bind(user.dn, password)
+		if err != nil {
+			return "", "", nil, userBindError(err)
+		}
+
+		return user.uid, user.gid, user.groups, nil
+	}
+
+	user, err := d.pooledSearch(env, username)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	err = d.verifyPassword(env, user.dn, password)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	return user.uid, user.gid, user.groups, nil
+}
 
-	var l ldapshim.LdapConnection
+// pooledSearch searches for username on a pooled connection. A connection the server has dropped
+// since it was last used fails with a network error; the search is then retried once on a new one.
+func (d *ldapIdResolver) pooledSearch(env dockerdriver.Env, username string) (ldapUser, error) {
+	logger := env.Logger().Session("ldap-search")
+
+	for attempt := 0; ; attempt++ {
+		l, err := d.pool.get(env)
+		if err != nil {
+			return ldapUser{}, err
+		}
+
+		user, err := d.search(l, username)
+		if ldap.IsErrorWithCode(err, ldap.ErrorNetwork) {
+			logger.Info("pooled-connection-failed", lager.Data{"err": err.Error()})
+			d.pool.put(l, false)
+			d.pool.drain()
+			if attempt == 0 {
+				continue
+			}
+			return ldapUser{}, err
+		}
+
+		d.pool.put(l, true)
+		return user, err
+	}
+}
+
+// verifyPassword binds as userdn on a connection of its own.
+func (d *ldapIdResolver) verifyPassword(env dockerdriver.Env, userdn string, password string) error {
+	l, err := d.dial(env)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	err = l.Bind(userdn, password)
+	if err != nil {
+		return userBindError(err)
+	}
+	return nil
+}
+
+// userBindError returns the SafeError for a failed bind as a user, which is InvalidCredentialsErrorMessage
+// when the password is wrong.
+func userBindError(err error) error {
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+		return dockerdriver.SafeError{SafeDescription: InvalidCredentialsErrorMessage}
+	}
+	return dockerdriver.SafeError{SafeDescription: err.Error()}
+}
+
+// dial connects to the first of the LDAP servers that can be reached. Servers that cannot be reached
+// are marked down so that they are tried last until their cooldown has passed.
+func (d *ldapIdResolver) dial(env dockerdriver.Env) (ldapshim.LdapConnection, error) {
+	logger := env.Logger().Session("ldap-dial")
+
+	var roots *x509.CertPool
 	if d.ldapCACert != "" {
-		roots := x509.NewCertPool()
+		roots = x509.NewCertPool()
 		ok := roots.AppendCertsFromPEM([]byte(d.ldapCACert))
 		if !ok {
-			return "", "", errors.New("failed to load CA certificate")
+			return nil, errors.New("failed to load CA certificate")
 		}
+	}
 
+	for _, endpoint := range d.servers.candidates(env) {
 		// #nosec G402
-		l, err = d.ldap.DialTLS(d.ldapProto, addr, &tls.Config{
-			ServerName: d.ldapHost,
+		config := &tls.Config{
+			ServerName: endpoint.Host,
 			RootCAs:    roots,
-		})
-	} else {
-		l, err = d.ldap.Dial(d.ldapProto, addr)
+		}
+
+		var l ldapshim.LdapConnection
+		var err error
+		if d.tlsMode == LdapTLSModeLdaps {
+			l, err = d.ldap.DialTLS(d.ldapProto, endpoint.String(), config)
+		} else {
+			l, err = d.ldap.Dial(d.ldapProto, endpoint.String())
+		}
+		if err != nil {
+			logger.Error("ldap-server-unreachable", err, lager.Data{"server": endpoint.String()})
+			d.servers.markDown(endpoint)
+			continue
+		}
+
+		// Never bind on a connection that StartTLS failed to secure
+		if d.tlsMode == LdapTLSModeStartTLS {
+			err = startTLS(l, config)
+			if err != nil {
+				logger.Error("ldap-starttls-failed", err, lager.Data{"server": endpoint.String()})
+				l.Close()
+				d.servers.markDown(endpoint)
+				continue
+			}
+		}
+
+		logger.Info("ldap-server-answered", lager.Data{"server": endpoint.String()})
+		d.servers.markUp(endpoint)
+		l.SetTimeout(d.ldapTimeout)
+		return l, nil
 	}
+
+	return nil, dockerdriver.SafeError{SafeDescription: "LDAP server could not be reached, please contact your system administrator"}
+}
+
+// dialService returns a connection bound as the service account.
+func (d *ldapIdResolver) dialService(env dockerdriver.Env) (ldapshim.LdapConnection, error) {
+	l, err := d.dial(env)
 	if err != nil {
-		return "", "", dockerdriver.SafeError{SafeDescription: "LDAP server could not be reached, please contact your system administrator"}
+		return nil, err
 	}
 
-	l.SetTimeout(d.ldapTimeout)
-	defer l.Close()
-
 	// First bind with a read only user
-	err = l.Bind(d.svcUser, d.svcPass)
+	err = d.bindService(l)
 	if err != nil {
-		return "", "", err
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func (d *ldapIdResolver) bindService(l ldapshim.LdapConnection) error {
+	return l.Bind(d.svcUser, d.svcPass)
+}
+
+// ldapUser is the entry found for a username.
+type ldapUser struct {
+	dn     string
+	uid    string
+	gid    string
+	groups []string
+}
+
+func (d *ldapIdResolver) search(l ldapshim.LdapConnection, username string) (ldapUser, error) {
+	attributes := []string{"dn", d.ldapSearch.UidAttribute, d.ldapSearch.GidAttribute}
+	if d.ldapSearch.MemberOfAttribute != "" {
+		attributes = append(attributes, d.ldapSearch.MemberOfAttribute)
 	}
 
 	// Search for the given username
@@ -94,36 +286,151 @@ func (d *ldapIdResolver) Resolve(env dockerdriver.Env, username string, password
 		0,
 		0,
 		false,
-		fmt.Sprintf("(&(objectClass=User)(cn=%s))", ldap.EscapeFilter(username)),
-		[]string{"dn", "uidNumber", "gidNumber"},
+		d.ldapSearch.userFilter(username),
+		attributes,
 		nil,
 	)
 
 	sr, err := l.Search(searchRequest)
 	if err != nil {
-		return "", "", err
+		return ldapUser{}, err
 	}
 
 	if len(sr.Entries) == 0 {
-		return "", "", dockerdriver.SafeError{SafeDescription: "User does not exist"}
+		return ldapUser{}, dockerdriver.SafeError{SafeDescription: UserDoesNotExistErrorMessage}
 	}
 	if len(sr.Entries) > 1 {
-		return "", "", dockerdriver.SafeError{SafeDescription: "Ambiguous search--too many results"}
+		return ldapUser{}, dockerdriver.SafeError{SafeDescription: "Ambiguous search--too many results"}
+	}
+
+	user := ldapUser{
+		dn:  sr.Entries[0].DN,
+		uid: sr.Entries[0].GetAttributeValue(d.ldapSearch.UidAttribute),
+		gid: sr.Entries[0].GetAttributeValue(d.ldapSearch.GidAttribute),
 	}
 
-	userdn := sr.Entries[0].DN
+	if d.ldapSearch.GroupFilter != "" {
+		groupGid, err := d.searchGroup(l, username, user.dn)
+		if err != nil {
+			return ldapUser{}, err
+		}
+		if groupGid != "" {
+			user.gid = groupGid
+		}
+	}
 
-	uid = sr.Entries[0].GetAttributeValue("uidNumber")
-	gid = sr.Entries[0].GetAttributeValue("gidNumber")
-	if gid == "" {
-		gid = uid
+	if user.gid == "" {
+		user.gid = user.uid
 	}
 
-	// Bind as the user to verify their password
-	err = l.Bind(userdn, password)
+	if d.ldapSearch.resolvesSupplementaryGroups() {
+		var memberOf []string
+		if d.ldapSearch.MemberOfAttribute != "" {
+			memberOf = sr.Entries[0].GetAttributeValues(d.ldapSearch.MemberOfAttribute)
+		}
+		user.groups, err = d.searchSupplementaryGroups(l, username, user.dn, user.gid, memberOf)
+		if err != nil {
+			return ldapUser{}, err
+		}
+	}
+
+	return user, nil
+}
+
+// searchGroup returns the gid of the primary group of the user, or "" when no group matches.
+func (d *ldapIdResolver) searchGroup(l ldapshim.LdapConnection, username string, userdn string) (string, error) {
+	baseDN := d.ldapSearch.GroupBaseDN
+	if baseDN == "" {
+		baseDN = d.ldapFqdn
+	}
+
+	searchRequest := d.ldap.NewSearchRequest(
+		baseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		0,
+		0,
+		false,
+		d.ldapSearch.groupFilter(username, userdn),
+		[]string{"dn", d.ldapSearch.GroupGidAttribute},
+		nil,
+	)
+
+	sr, err := l.Search(searchRequest)
 	if err != nil {
-		return "", "", dockerdriver.SafeError{SafeDescription: err.Error()}
+		return "", err
+	}
+
+	if len(sr.Entries) == 0 {
+		return "", nil
+	}
+	if len(sr.Entries) > 1 {
+		return "", dockerdriver.SafeError{SafeDescription: "Ambiguous group search--too many results"}
+	}
+
+	return sr.Entries[0].GetAttributeValue(d.ldapSearch.GroupGidAttribute), nil
+}
+
+// searchSupplementaryGroups returns the numeric gids, other than gid, of the groups matching the
+// supplementary group filter and of the groups whose DNs are in memberOf.
+func (d *ldapIdResolver) searchSupplementaryGroups(l ldapshim.LdapConnection, username string, userdn string, gid string, memberOf []string) ([]string, error) {
+	attribute := d.ldapSearch.GroupGidAttribute
+	seen := map[string]bool{gid: true}
+	var groups []string
+	collect := func(entries []*ldap.Entry) {
+		for _, entry := range entries {
+			groupGid := entry.GetAttributeValue(attribute)
+			if _, err := strconv.ParseUint(groupGid, 10, 32); err != nil || seen[groupGid] {
+				continue
+			}
+			seen[groupGid] = true
+			groups = append(groups, groupGid)
+		}
+	}
+
+	if d.ldapSearch.SupplementaryGroupFilter != "" {
+		baseDN := d.ldapSearch.GroupBaseDN
+		if baseDN == "" {
+			baseDN = d.ldapFqdn
+		}
+
+		sr, err := l.Search(d.ldap.NewSearchRequest(
+			baseDN,
+			ldap.ScopeWholeSubtree,
+			ldap.NeverDerefAliases,
+			0,
+			0,
+			false,
+			d.ldapSearch.supplementaryGroupFilter(username, userdn),
+			[]string{"dn", attribute},
+			nil,
+		))
+		if err != nil {
+			return nil, err
+		}
+		collect(sr.Entries)
+	}
+
+	for _, groupdn := range memberOf {
+		sr, err := l.Search(d.ldap.NewSearchRequest(
+			groupdn,
+			ldap.ScopeBaseObject,
+			ldap.NeverDerefAliases,
+			0,
+			0,
+			false,
+			"(objectClass=*)",
+			[]string{"dn", attribute},
+			nil,
+		))
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		collect(sr.Entries)
 	}
 
-	return uid, gid, nil
+	return groups, nil
 }
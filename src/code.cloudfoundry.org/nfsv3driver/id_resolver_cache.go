@@ -0,0 +1,115 @@
+package nfsv3driver
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/goshims/timeshim"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+const DefaultIdCacheTTL = time.Minute * 5
+const DefaultIdCacheNegativeTTL = time.Second * 30
+
+// cachingIdResolver remembers the ids resolved for a username and password for ttl, and that a username
+// does not exist for negativeTTL. Passwords are only kept as a salted hash, and a password that has not
+// been resolved successfully before is always verified by the wrapped resolver.
+type cachingIdResolver struct {
+	resolver    IdResolver
+	clock       timeshim.Time
+	ttl         time.Duration
+	negativeTTL time.Duration
+	salt        []byte
+
+	lock    sync.Mutex
+	ids     map[string]cachedIds
+	missing map[string]time.Time
+}
+
+type cachedIds struct {
+	uid     string
+	gid     string
+	groups  []string
+	expires time.Time
+}
+
+func NewCachingIdResolver(resolver IdResolver, clock timeshim.Time, ttl time.Duration, negativeTTL time.Duration) (IdResolver, error) {
+	salt := make([]byte, 32)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cachingIdResolver{
+		resolver:    resolver,
+		clock:       clock,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		salt:        salt,
+		ids:         map[string]cachedIds{},
+		missing:     map[string]time.Time{},
+	}, nil
+}
+
+func (c *cachingIdResolver) Resolve(env dockerdriver.Env, username string, password string) (string, string, []string, error) {
+	logger := env.Logger().Session("cached-resolve", lager.Data{"username": username})
+
+	key := c.key(username, password)
+	now := c.clock.Now()
+
+	c.lock.Lock()
+	if expires, ok := c.missing[username]; ok && now.Before(expires) {
+		c.lock.Unlock()
+		logger.Info("cached-missing-user")
+		return "", "", nil, dockerdriver.SafeError{SafeDescription: UserDoesNotExistErrorMessage}
+	}
+	if ids, ok := c.ids[key]; ok && now.Before(ids.expires) {
+		c.lock.Unlock()
+		logger.Info("cached-ids")
+		return ids.uid, ids.gid, ids.groups, nil
+	}
+	c.lock.Unlock()
+
+	uid, gid, groups, err := c.resolver.Resolve(env, username, password)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.prune(now)
+
+	if err != nil {
+		if isUserDoesNotExist(err) {
+			c.missing[username] = now.Add(c.negativeTTL)
+		}
+		return "", "", nil, err
+	}
+
+	delete(c.missing, username)
+	c.ids[key] = cachedIds{uid: uid, gid: gid, groups: groups, expires: now.Add(c.ttl)}
+	return uid, gid, groups, nil
+}
+
+func (c *cachingIdResolver) key(username string, password string) string {
+	mac := hmac.New(sha256.New, c.salt)
+	mac.Write([]byte(username))
+	mac.Write([]byte{0})
+	mac.Write([]byte(password))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (c *cachingIdResolver) prune(now time.Time) {
+	for key, ids := range c.ids {
+		if !now.Before(ids.expires) {
+			delete(c.ids, key)
+		}
+	}
+	for username, expires := range c.missing {
+		if !now.Before(expires) {
+			delete(c.missing, username)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/timeshim/time_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CachingIdResolver", func() {
+	var (
+		env          dockerdriver.Env
+		fakeResolver *nfsdriverfakes.FakeIdResolver
+		fakeTime     *time_fake.FakeTime
+		now          time.Time
+		subject      nfsv3driver.IdResolver
+	)
+
+	BeforeEach(func() {
+		env = driverhttp.NewHttpDriverEnv(lagertest.NewTestLogger("caching-id-resolver"), context.TODO())
+
+		fakeResolver = &nfsdriverfakes.FakeIdResolver{}
+		fakeResolver.ResolveReturns("100", "200", nil, nil)
+
+		now = time.Unix(1700000000, 0)
+		fakeTime = &time_fake.FakeTime{}
+		fakeTime.NowStub = func() time.Time { return now }
+
+		var err error
+		subject, err = nfsv3driver.NewCachingIdResolver(fakeResolver, fakeTime, time.Minute, 10*time.Second)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	resolve := func(username string, password string) (string, string, error) {
+		uid, gid, _, err := subject.Resolve(env, username, password)
+		return uid, gid, err
+	}
+
+	It("resolves through the wrapped resolver the first time", func() {
+		uid, gid, err := resolve("user", "pw")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uid).To(Equal("100"))
+		Expect(gid).To(Equal("200"))
+
+		Expect(fakeResolver.ResolveCallCount()).To(Equal(1))
+		_, username, password := fakeResolver.ResolveArgsForCall(0)
+		Expect(username).To(Equal("user"))
+		Expect(password).To(Equal("pw"))
+	})
+
+	Context("when the same credentials have been resolved before", func() {
+		BeforeEach(func() {
+			_, _, err := resolve("user", "pw")
+			Expect(err).NotTo(HaveOccurred())
+			fakeResolver.ResolveReturns("", "", nil, dockerdriver.SafeError{SafeDescription: "LDAP server could not be reached, please contact your system administrator"})
+		})
+
+		It("answers from the cache, even while the directory is unreachable", func() {
+			uid, gid, err := resolve("user", "pw")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uid).To(Equal("100"))
+			Expect(gid).To(Equal("200"))
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(1))
+		})
+
+		It("answers with the supplementary groups resolved before", func() {
+			fakeResolver.ResolveReturns("100", "200", []string{"300", "400"}, nil)
+			_, _, groups, err := subject.Resolve(env, "other-user", "pw")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(groups).To(Equal([]string{"300", "400"}))
+
+			_, _, groups, err = subject.Resolve(env, "other-user", "pw")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(groups).To(Equal([]string{"300", "400"}))
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(2))
+		})
+
+		It("verifies a password it has not seen before", func() {
+			_, _, err := resolve("user", "other-pw")
+			Expect(err).To(MatchError(ContainSubstring("could not be reached")))
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(2))
+		})
+
+		It("does not answer for another user with the same password", func() {
+			_, _, err := resolve("other-user", "pw")
+			Expect(err).To(HaveOccurred())
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(2))
+		})
+
+		It("resolves again once the entry has expired", func() {
+			now = now.Add(time.Minute)
+			_, _, err := resolve("user", "pw")
+			Expect(err).To(HaveOccurred())
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(2))
+		})
+	})
+
+	Context("when the resolution fails", func() {
+		BeforeEach(func() {
+			fakeResolver.ResolveReturns("", "", nil, dockerdriver.SafeError{SafeDescription: "Invalid Credentials"})
+		})
+
+		It("does not cache the failure", func() {
+			_, _, err := resolve("user", "bad-pw")
+			Expect(err).To(MatchError("Invalid Credentials"))
+
+			fakeResolver.ResolveReturns("100", "200", nil, nil)
+			_, _, err = resolve("user", "bad-pw")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(2))
+		})
+	})
+
+	Context("when the user does not exist", func() {
+		BeforeEach(func() {
+			fakeResolver.ResolveReturns("", "", nil, dockerdriver.SafeError{SafeDescription: nfsv3driver.UserDoesNotExistErrorMessage})
+			_, _, err := resolve("missing", "pw")
+			Expect(err).To(MatchError(nfsv3driver.UserDoesNotExistErrorMessage))
+		})
+
+		It("remembers it for whatever password is used", func() {
+			_, _, err := resolve("missing", "other-pw")
+			Expect(err).To(MatchError(nfsv3driver.UserDoesNotExistErrorMessage))
+			Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(1))
+		})
+
+		It("looks the user up again after the negative ttl", func() {
+			now = now.Add(10 * time.Second)
+			fakeResolver.ResolveReturns("100", "200", nil, nil)
+
+			uid, _, err := resolve("missing", "pw")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uid).To(Equal("100"))
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(2))
+		})
+	})
+
+	It("does not remember other errors as a missing user", func() {
+		fakeResolver.ResolveReturns("", "", nil, errors.New("User does not exist"))
+		_, _, _ = resolve("user", "pw")
+		_, _, _ = resolve("user", "pw")
+		Expect(fakeResolver.ResolveCallCount()).To(Equal(2))
+	})
+})
@@ -0,0 +1,52 @@
+package nfsv3driver
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+// ChainedIdResolver is a step of a chain of IdResolvers, named in the logs.
+type ChainedIdResolver struct {
+	Name     string
+	Resolver IdResolver
+}
+
+type chainIdResolver struct {
+	chain []ChainedIdResolver
+}
+
+// NewChainIdResolver returns an IdResolver that asks the resolvers of chain in turn. It only moves on to
+// the next resolver when a resolver does not know the user: any other error, and in particular a wrong
+// password, is returned straight away so that a user can never be resolved by a later resolver with
+// another password.
+func NewChainIdResolver(chain ...ChainedIdResolver) IdResolver {
+	return &chainIdResolver{chain: chain}
+}
+
+func (c *chainIdResolver) Resolve(env dockerdriver.Env, username string, password string) (string, string, []string, error) {
+	logger := env.Logger().Session("chain-resolve", lager.Data{"username": username})
+
+	for _, step := range c.chain {
+		uid, gid, groups, err := step.Resolver.Resolve(env, username, password)
+		if isUserDoesNotExist(err) {
+			logger.Debug("user-not-found", lager.Data{"resolver": step.Name})
+			continue
+		}
+		if err != nil {
+			logger.Info("resolve-failed", lager.Data{"resolver": step.Name})
+			return "", "", nil, err
+		}
+
+		logger.Info("resolved", lager.Data{"resolver": step.Name})
+		return uid, gid, groups, nil
+	}
+
+	return "", "", nil, dockerdriver.SafeError{SafeDescription: UserDoesNotExistErrorMessage}
+}
+
+func isUserDoesNotExist(err error) bool {
+	var safeErr dockerdriver.SafeError
+	return errors.As(err, &safeErr) && safeErr.SafeDescription == UserDoesNotExistErrorMessage
+}
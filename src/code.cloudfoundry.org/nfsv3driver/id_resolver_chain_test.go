@@ -0,0 +1,114 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ChainIdResolver", func() {
+	var (
+		logger *lagertest.TestLogger
+		env    dockerdriver.Env
+		first  *nfsdriverfakes.FakeIdResolver
+		second *nfsdriverfakes.FakeIdResolver
+
+		uid, gid string
+		groups   []string
+		err      error
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("chain-id-resolver")
+		env = driverhttp.NewHttpDriverEnv(logger, context.TODO())
+
+		first = &nfsdriverfakes.FakeIdResolver{}
+		first.ResolveReturns("100", "200", []string{"300"}, nil)
+		second = &nfsdriverfakes.FakeIdResolver{}
+		second.ResolveReturns("101", "201", nil, nil)
+	})
+
+	JustBeforeEach(func() {
+		subject := nfsv3driver.NewChainIdResolver(
+			nfsv3driver.ChainedIdResolver{Name: "static", Resolver: first},
+			nfsv3driver.ChainedIdResolver{Name: "ldap", Resolver: second},
+		)
+		uid, gid, groups, err = subject.Resolve(env, "user", "pw")
+	})
+
+	It("answers with the first resolver that knows the user", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uid).To(Equal("100"))
+		Expect(gid).To(Equal("200"))
+		Expect(groups).To(Equal([]string{"300"}))
+
+		_, username, password := first.ResolveArgsForCall(0)
+		Expect(username).To(Equal("user"))
+		Expect(password).To(Equal("pw"))
+		Expect(second.ResolveCallCount()).To(Equal(0))
+	})
+
+	It("logs which resolver answered", func() {
+		Expect(logger.Logs()).To(ContainElement(SatisfyAll(
+			HaveField("Message", "chain-id-resolver.chain-resolve.resolved"),
+			HaveField("Data", HaveKeyWithValue("resolver", "static")),
+		)))
+	})
+
+	Context("when the first resolver does not know the user", func() {
+		BeforeEach(func() {
+			first.ResolveReturns("", "", nil, dockerdriver.SafeError{SafeDescription: nfsv3driver.UserDoesNotExistErrorMessage})
+		})
+
+		It("asks the next one", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uid).To(Equal("101"))
+			Expect(second.ResolveCallCount()).To(Equal(1))
+			Expect(logger.Logs()).To(ContainElement(SatisfyAll(
+				HaveField("Message", "chain-id-resolver.chain-resolve.resolved"),
+				HaveField("Data", HaveKeyWithValue("resolver", "ldap")),
+			)))
+		})
+
+		Context("when no resolver knows the user", func() {
+			BeforeEach(func() {
+				second.ResolveReturns("", "", nil, dockerdriver.SafeError{SafeDescription: nfsv3driver.UserDoesNotExistErrorMessage})
+			})
+
+			It("reports the user as missing", func() {
+				Expect(err).To(MatchError(nfsv3driver.UserDoesNotExistErrorMessage))
+				Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+			})
+		})
+	})
+
+	Context("when the first resolver rejects the password", func() {
+		BeforeEach(func() {
+			first.ResolveReturns("", "", nil, dockerdriver.SafeError{SafeDescription: nfsv3driver.InvalidCredentialsErrorMessage})
+		})
+
+		It("never falls through to the next resolver", func() {
+			Expect(err).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+			Expect(second.ResolveCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the first resolver fails", func() {
+		BeforeEach(func() {
+			first.ResolveReturns("", "", nil, errors.New("LDAP Result Code 200 \"Network Error\""))
+		})
+
+		It("returns the error without asking the next resolver", func() {
+			Expect(err).To(MatchError(ContainSubstring("Network Error")))
+			Expect(second.ResolveCallCount()).To(Equal(0))
+			Expect(logger.LogMessages()).To(ContainElement("chain-id-resolver.chain-resolve.resolve-failed"))
+		})
+	})
+})
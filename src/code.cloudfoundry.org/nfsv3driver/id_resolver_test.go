@@ -3,13 +3,16 @@ package nfsv3driver_test
 import (
 	"context"
 	"errors"
+	"net"
 	"time"
 
 	"code.cloudfoundry.org/dockerdriver"
 	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/ldapshim"
 	"code.cloudfoundry.org/goshims/ldapshim/ldap_fake"
 	"code.cloudfoundry.org/lager/v3/lagertest"
 	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"gopkg.in/ldap.v2"
@@ -22,10 +25,12 @@ var _ = Describe("IdResolverTest", func() {
 	var env dockerdriver.Env
 	var uid string
 	var gid string
+	var groups []string
 	var err error
 	var ldapCACert string
 	var ldapTimeout time.Duration
 	var user string
+	var options []nfsv3driver.LdapIdResolverOption
 
 	BeforeEach(func() {
 		logger := lagertest.NewTestLogger("nfs-mounter")
@@ -33,6 +38,7 @@ var _ = Describe("IdResolverTest", func() {
 		env = driverhttp.NewHttpDriverEnv(logger, testContext)
 
 		user = "user"
+		options = nil
 	})
 
 	JustBeforeEach(func() {
@@ -46,8 +52,9 @@ var _ = Describe("IdResolverTest", func() {
 			ldapCACert,
 			ldapFake,
 			ldapTimeout,
+			options...,
 		)
-		uid, gid, err = ldapIdResolver.Resolve(env, user, "pw")
+		uid, gid, groups, err = ldapIdResolver.Resolve(env, user, "pw")
 	})
 
 	Context("when the connection is successful", func() {
@@ -62,25 +69,7 @@ var _ = Describe("IdResolverTest", func() {
 
 		Context("when CA cert is provided", func() {
 			BeforeEach(func() {
-				ldapCACert = `-----BEGIN CERTIFICATE-----
-MIIDGTCCAgGgAwIBAgIRAIlVvSGFPY1EvNayuTpPAScwDQYJKoZIhvcNAQELBQAw
-EjEQMA4GA1UEChMHQWNtZSBDbzAeFw0xODA1MzExNzU5MTBaFw0xOTA1MzExNzU5
-MTBaMBIxEDAOBgNVBAoTB0FjbWUgQ28wggEiMA0GCSqGSIb3DQEBAQUAA4IBDwAw
-ggEKAoIBAQCSf8J68FYrRuE8+NumcleeI10+O5QGibQ3+axX79eFS3RGcQKn5UOr
-OFE/RM/ghc7sUD8urLhlA2QAua+0dZEr+QtNswDxLfWljw08azR4xkPnBejdwYKU
-jHHU9UoJrxEgWqNFwTWWCyHYERUK/RFSrSUJaZLv1fRa9C+wbkD2Wd+aesPU6TZr
-5f6DT1UdL5umykwVoKy9ymA1CUi3iRSPuIxF0iuwwNtgtS0Dswi9+gqICOYp+lGJ
-RM2zRZFas8clubvkIRYlO2YG8hb181uxW9nLAfUfJjjtDt7lp5z/eZqliFwzrl0i
-DG8xWUppHV9654hGRDOL2ow3u8kwNv9/AgMBAAGjajBoMA4GA1UdDwEB/wQEAwIC
-pDATBgNVHSUEDDAKBggrBgEFBQcDATAPBgNVHRMBAf8EBTADAQH/MDAGA1UdEQQp
-MCeCJW5mc3Rlc3RsZGFwc2VydmVyLnNlcnZpY2UuY2YuaW50ZXJuYWwwDQYJKoZI
-hvcNAQELBQADggEBAB/4KT3+G5YqrnCCF+GmYlxZO9ScRA6yPBtwXTQe7WH8Yfz2
-bnUs4jKhK2wh3+RSTsBwV9afF+xm/uVrD9iZveixC1E3NqJwlchHc2bv9NCvC8OY
-VShIx+8Joqpud6VIrzclhus2lo9Dvn55at3Z/5SYDf07fDmSJ5pZuLUVryiJk9AT
-G0GELNbBftMakAJaH6eqGvcNbDRMeFqq7VyjthQJRPWSaWKA6TsfzgiO9lwx1wd1
-1ZtN1nl1NexFqcan26vg0f1SwLM9r9mVXrKII/T60RXKvtcAkMS3XfaebG3ulout
-z6sbK6WkL0AwPEcI/HzUOrsAUBtyY8cfy6yVcuQ=
------END CERTIFICATE-----`
+				ldapCACert = testCACert
 				ldapFake.DialTLSReturns(ldapConnectionFake, nil)
 			})
 
@@ -151,6 +140,43 @@ z6sbK6WkL0AwPEcI/HzUOrsAUBtyY8cfy6yVcuQ=
 				Expect(gid).To(Equal("100"))
 			})
 
+			Context("when an audit log is configured", func() {
+				var fakeAuditLog *nfsdriverfakes.FakeAuditLog
+
+				BeforeEach(func() {
+					fakeAuditLog = &nfsdriverfakes.FakeAuditLog{}
+					options = append(options, nfsv3driver.WithLdapAuditLog(fakeAuditLog))
+				})
+
+				It("records the resolution", func() {
+					Expect(fakeAuditLog.RecordCallCount()).To(Equal(1))
+					_, record := fakeAuditLog.RecordArgsForCall(0)
+					Expect(record.Event).To(Equal(nfsv3driver.AuditEventResolve))
+					Expect(record.Username).To(Equal("user"))
+					Expect(record.Uid).To(Equal("100"))
+					Expect(record.Gid).To(Equal("100"))
+					Expect(record.Outcome).To(Equal(nfsv3driver.AuditOutcomeSuccess))
+				})
+
+				Context("when the password is wrong", func() {
+					BeforeEach(func() {
+						ldapConnectionFake.BindStub = func(u, p string) error {
+							if u == "svcuser" {
+								return nil
+							}
+							return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+						}
+					})
+
+					It("records the failure", func() {
+						_, record := fakeAuditLog.RecordArgsForCall(0)
+						Expect(record.Outcome).To(Equal(nfsv3driver.AuditOutcomeFailure))
+						Expect(record.ErrorCode).To(Equal(nfsv3driver.AuditErrorInvalidCredentials))
+						Expect(record.Uid).To(BeEmpty())
+					})
+				})
+			})
+
 			Context("when the credentials are not good", func() {
 				BeforeEach(func() {
 					ldapConnectionFake.BindStub = func(u, p string) error {
@@ -168,6 +194,22 @@ z6sbK6WkL0AwPEcI/HzUOrsAUBtyY8cfy6yVcuQ=
 					Expect(ldapConnectionFake.SearchCallCount()).To(Equal(1))
 					Expect(uid).To(BeEmpty())
 				})
+
+				Context("because the password is wrong", func() {
+					BeforeEach(func() {
+						ldapConnectionFake.BindStub = func(u, p string) error {
+							if u == "svcuser" {
+								return nil
+							}
+							return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("80090308: LdapErr: DSID-0C09044E, data 52e"))
+						}
+					})
+
+					It("reports invalid credentials", func() {
+						Expect(err).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+						Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+					})
+				})
 			})
 		})
 
@@ -273,6 +315,526 @@ z6sbK6WkL0AwPEcI/HzUOrsAUBtyY8cfy6yVcuQ=
 		})
 	})
 
+	Context("when a connection pool is configured", func() {
+		var connections []*ldap_fake.FakeLdapConnection
+		var userBindErr error
+
+		BeforeEach(func() {
+			connections = nil
+			userBindErr = nil
+			ldapFake = &ldap_fake.FakeLdap{}
+			ldapFake.DialStub = func(string, string) (ldapshim.LdapConnection, error) {
+				conn := &ldap_fake.FakeLdapConnection{}
+				conn.SearchReturns(&ldap.SearchResult{Entries: []*ldap.Entry{{
+					DN: "cn=user,cn=Users,dc=test,dc=com",
+					Attributes: []*ldap.EntryAttribute{
+						{Name: "uidNumber", Values: []string{"100"}},
+						{Name: "gidNumber", Values: []string{"200"}},
+					},
+				}}}, nil)
+				conn.BindStub = func(u, _ string) error {
+					if u == "svcuser" {
+						return nil
+					}
+					return userBindErr
+				}
+				connections = append(connections, conn)
+				return conn, nil
+			}
+			ldapCACert = ""
+			ldapTimeout = 120 * time.Second
+			options = []nfsv3driver.LdapIdResolverOption{nfsv3driver.WithLdapConnectionPool(2, time.Minute)}
+		})
+
+		It("verifies the password on a connection of its own", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uid).To(Equal("100"))
+			Expect(gid).To(Equal("200"))
+
+			Expect(connections).To(HaveLen(2))
+			Expect(connections[0].BindCallCount()).To(Equal(1))
+			u, p := connections[0].BindArgsForCall(0)
+			Expect([]string{u, p}).To(Equal([]string{"svcuser", "svcpw"}))
+			Expect(connections[0].SearchCallCount()).To(Equal(1))
+			Expect(connections[0].CloseCallCount()).To(Equal(0))
+
+			Expect(connections[1].BindCallCount()).To(Equal(1))
+			u, p = connections[1].BindArgsForCall(0)
+			Expect([]string{u, p}).To(Equal([]string{"cn=user,cn=Users,dc=test,dc=com", "pw"}))
+			Expect(connections[1].SearchCallCount()).To(Equal(0))
+			Expect(connections[1].CloseCallCount()).To(Equal(1))
+		})
+
+		It("reuses the service connection for later resolutions", func() {
+			_, _, _, err = ldapIdResolver.Resolve(env, "other-user", "other-pw")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(connections).To(HaveLen(3))
+			Expect(connections[0].BindCallCount()).To(Equal(1))
+			Expect(connections[0].SearchCallCount()).To(Equal(2))
+		})
+
+		Context("when the user's password is wrong", func() {
+			BeforeEach(func() {
+				userBindErr = errors.New("invalid credentials")
+			})
+
+			It("fails without affecting the pooled connection", func() {
+				Expect(err).To(MatchError("invalid credentials"))
+				Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+
+				_, _, _, err = ldapIdResolver.Resolve(env, user, "pw")
+				Expect(err).To(HaveOccurred())
+				Expect(connections).To(HaveLen(3))
+				Expect(connections[0].SearchCallCount()).To(Equal(2))
+				Expect(connections[0].CloseCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the pooled connection has been idle for too long", func() {
+			BeforeEach(func() {
+				options = []nfsv3driver.LdapIdResolverOption{nfsv3driver.WithLdapConnectionPool(2, time.Nanosecond)}
+			})
+
+			It("closes it and binds a new one", func() {
+				time.Sleep(time.Millisecond)
+				_, _, _, err = ldapIdResolver.Resolve(env, user, "pw")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(connections).To(HaveLen(4))
+				Expect(connections[0].CloseCallCount()).To(Equal(1))
+				Expect(connections[2].SearchCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when the pooled connection is due a health check", func() {
+			BeforeEach(func() {
+				nfsv3driver.LdapPoolHealthCheckInterval = 0
+			})
+
+			AfterEach(func() {
+				nfsv3driver.LdapPoolHealthCheckInterval = 30 * time.Second
+			})
+
+			It("binds as the service account again before reusing it", func() {
+				_, _, _, err = ldapIdResolver.Resolve(env, user, "pw")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(connections).To(HaveLen(3))
+				Expect(connections[0].BindCallCount()).To(Equal(2))
+				Expect(connections[0].SearchCallCount()).To(Equal(2))
+			})
+
+			It("replaces it when the check fails", func() {
+				connections[0].BindStub = nil
+				connections[0].BindReturns(errors.New("connection reset"))
+
+				_, _, _, err = ldapIdResolver.Resolve(env, user, "pw")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(connections).To(HaveLen(4))
+				Expect(connections[0].CloseCallCount()).To(Equal(1))
+				Expect(connections[2].SearchCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when every pooled connection is in use", func() {
+			BeforeEach(func() {
+				options = []nfsv3driver.LdapIdResolverOption{nfsv3driver.WithLdapConnectionPool(1, time.Minute)}
+			})
+
+			It("waits for one to be put back", func() {
+				release := make(chan struct{})
+				connections[0].SearchStub = func(*ldap.SearchRequest) (*ldap.SearchResult, error) {
+					<-release
+					return &ldap.SearchResult{Entries: []*ldap.Entry{{DN: "cn=user"}}}, nil
+				}
+				done := make(chan struct{})
+				go func() {
+					defer GinkgoRecover()
+					defer close(done)
+					_, _, _, _ = ldapIdResolver.Resolve(env, user, "pw")
+				}()
+				Eventually(connections[0].SearchCallCount).Should(Equal(2))
+
+				ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+				defer cancel()
+				_, _, _, err = ldapIdResolver.Resolve(driverhttp.EnvWithContext(ctx, env), user, "pw")
+				Expect(err).To(MatchError(context.DeadlineExceeded))
+				Expect(ldapFake.DialCallCount()).To(Equal(2))
+
+				close(release)
+				Eventually(done).Should(BeClosed())
+			})
+		})
+
+		Context("when the server has dropped the pooled connection", func() {
+			It("retries the search on a new connection", func() {
+				connections[0].SearchReturns(nil, ldap.NewError(ldap.ErrorNetwork, errors.New("connection closed")))
+
+				uid, gid, groups, err = ldapIdResolver.Resolve(env, user, "pw")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(uid).To(Equal("100"))
+				Expect(gid).To(Equal("200"))
+
+				Expect(connections).To(HaveLen(4))
+				Expect(connections[0].CloseCallCount()).To(Equal(1))
+				Expect(connections[2].SearchCallCount()).To(Equal(1))
+			})
+		})
+	})
+
+	Context("when the TLS mode is starttls", func() {
+		var startTLSConnection *nfsdriverfakes.FakeStartTLSLdapConnection
+
+		BeforeEach(func() {
+			ldapFake = &ldap_fake.FakeLdap{}
+			startTLSConnection = &nfsdriverfakes.FakeStartTLSLdapConnection{}
+			ldapFake.DialReturns(startTLSConnection, nil)
+			ldapCACert = ""
+			ldapTimeout = 120 * time.Second
+			startTLSConnection.SearchReturns(&ldap.SearchResult{Entries: []*ldap.Entry{{
+				DN:         "foo",
+				Attributes: []*ldap.EntryAttribute{{Name: "uidNumber", Values: []string{"100"}}},
+			}}}, nil)
+
+			options = []nfsv3driver.LdapIdResolverOption{nfsv3driver.WithLdapTLSMode(nfsv3driver.LdapTLSModeStartTLS)}
+		})
+
+		It("upgrades the plaintext connection before binding", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ldapFake.DialTLSCallCount()).To(Equal(0))
+			Expect(ldapFake.DialCallCount()).To(Equal(1))
+
+			Expect(startTLSConnection.StartTLSCallCount()).To(Equal(1))
+			Expect(startTLSConnection.BindCallCount()).To(Equal(2))
+		})
+
+		It("verifies the certificate of the server", func() {
+			config := startTLSConnection.StartTLSArgsForCall(0)
+			Expect(config.ServerName).To(Equal("host"))
+			Expect(config.InsecureSkipVerify).To(BeFalse())
+		})
+
+		Context("when a CA cert is provided", func() {
+			BeforeEach(func() {
+				ldapCACert = testCACert
+			})
+
+			It("verifies the certificate against it", func() {
+				config := startTLSConnection.StartTLSArgsForCall(0)
+				Expect(config.RootCAs.Subjects()).To(HaveLen(1)) //lint:ignore SA1019 "not systemcert"
+			})
+		})
+
+		Context("when the upgrade fails", func() {
+			BeforeEach(func() {
+				startTLSConnection.StartTLSReturns(errors.New("x509: certificate signed by unknown authority"))
+			})
+
+			It("refuses to bind", func() {
+				Expect(err).To(MatchError("LDAP server could not be reached, please contact your system administrator"))
+				Expect(startTLSConnection.BindCallCount()).To(Equal(0))
+				Expect(startTLSConnection.CloseCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when the connection cannot be upgraded", func() {
+			BeforeEach(func() {
+				ldapFake.DialReturns(&ldap_fake.FakeLdapConnection{}, nil)
+			})
+
+			It("refuses to bind", func() {
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+
+	Context("when the TLS mode is none", func() {
+		BeforeEach(func() {
+			ldapFake = &ldap_fake.FakeLdap{}
+			ldapConnectionFake = &ldap_fake.FakeLdapConnection{}
+			ldapFake.DialReturns(ldapConnectionFake, nil)
+			ldapCACert = testCACert
+			ldapTimeout = 120 * time.Second
+			ldapConnectionFake.SearchReturns(&ldap.SearchResult{}, nil)
+
+			options = []nfsv3driver.LdapIdResolverOption{nfsv3driver.WithLdapTLSMode(nfsv3driver.LdapTLSModeNone)}
+		})
+
+		It("connects without TLS even though a CA cert is provided", func() {
+			Expect(ldapFake.DialCallCount()).To(Equal(1))
+			Expect(ldapFake.DialTLSCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when a custom search is configured", func() {
+		var search nfsv3driver.LdapSearch
+
+		BeforeEach(func() {
+			ldapFake = &ldap_fake.FakeLdap{}
+			ldapConnectionFake = &ldap_fake.FakeLdapConnection{}
+			ldapFake.DialReturns(ldapConnectionFake, nil)
+			ldapCACert = ""
+			ldapTimeout = 120 * time.Second
+			user = "jdoe*)(uid=*"
+
+			search = nfsv3driver.LdapSearch{
+				UserFilter:   "(&(objectClass=posixAccount)(uid={username}))",
+				UidAttribute: "uidNum",
+				GidAttribute: "gidNum",
+			}
+			ldapConnectionFake.SearchReturns(&ldap.SearchResult{Entries: []*ldap.Entry{{
+				DN: "uid=jdoe,ou=People,dc=test,dc=com",
+				Attributes: []*ldap.EntryAttribute{
+					{Name: "uidNum", Values: []string{"1001"}},
+					{Name: "gidNum", Values: []string{"1002"}},
+				},
+			}}}, nil)
+		})
+
+		Context("without a group lookup", func() {
+			BeforeEach(func() {
+				options = []nfsv3driver.LdapIdResolverOption{nfsv3driver.WithLdapSearch(search)}
+			})
+
+			It("searches with the escaped filter and attribute names", func() {
+				Expect(ldapFake.NewSearchRequestCallCount()).To(Equal(1))
+				baseDN, _, _, _, _, _, filter, attributes, _ := ldapFake.NewSearchRequestArgsForCall(0)
+				Expect(baseDN).To(Equal("cn=Users,dc=test,dc=com"))
+				Expect(filter).To(Equal(`(&(objectClass=posixAccount)(uid=jdoe\2a\29\28uid=\2a))`))
+				Expect(attributes).To(ConsistOf("dn", "uidNum", "gidNum"))
+			})
+
+			It("returns the ids from the configured attributes", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(uid).To(Equal("1001"))
+				Expect(gid).To(Equal("1002"))
+			})
+		})
+
+		Context("with supplementary groups", func() {
+			BeforeEach(func() {
+				search.SupplementaryGroupFilter = "(&(objectClass=posixGroup)(memberUid={username}))"
+				search.MemberOfAttribute = "memberOf"
+				options = []nfsv3driver.LdapIdResolverOption{nfsv3driver.WithLdapSearch(search)}
+
+				ldapConnectionFake.SearchReturnsOnCall(0, &ldap.SearchResult{Entries: []*ldap.Entry{{
+					DN: "uid=jdoe,ou=People,dc=test,dc=com",
+					Attributes: []*ldap.EntryAttribute{
+						{Name: "uidNum", Values: []string{"1001"}},
+						{Name: "gidNum", Values: []string{"1002"}},
+						{Name: "memberOf", Values: []string{"cn=devs,ou=Groups,dc=test,dc=com", "cn=gone,ou=Groups,dc=test,dc=com"}},
+					},
+				}}}, nil)
+				ldapConnectionFake.SearchReturnsOnCall(1, &ldap.SearchResult{Entries: []*ldap.Entry{
+					{DN: "cn=jdoe", Attributes: []*ldap.EntryAttribute{{Name: "gidNumber", Values: []string{"1002"}}}},
+					{DN: "cn=staff", Attributes: []*ldap.EntryAttribute{{Name: "gidNumber", Values: []string{"3000"}}}},
+					{DN: "cn=nogid"},
+				}}, nil)
+				ldapConnectionFake.SearchReturnsOnCall(2, &ldap.SearchResult{Entries: []*ldap.Entry{
+					{DN: "cn=devs", Attributes: []*ldap.EntryAttribute{{Name: "gidNumber", Values: []string{"4000"}}}},
+				}}, nil)
+				ldapConnectionFake.SearchReturnsOnCall(3, nil, ldap.NewError(ldap.LDAPResultNoSuchObject, errors.New("no such object")))
+			})
+
+			It("requests the member of attribute of the user", func() {
+				_, _, _, _, _, _, _, attributes, _ := ldapFake.NewSearchRequestArgsForCall(0)
+				Expect(attributes).To(ContainElement("memberOf"))
+			})
+
+			It("searches for the groups of the user and looks up the ones they are a member of", func() {
+				Expect(ldapFake.NewSearchRequestCallCount()).To(Equal(4))
+
+				baseDN, scope, _, _, _, _, filter, _, _ := ldapFake.NewSearchRequestArgsForCall(1)
+				Expect(baseDN).To(Equal("cn=Users,dc=test,dc=com"))
+				Expect(scope).To(Equal(ldap.ScopeWholeSubtree))
+				Expect(filter).To(Equal(`(&(objectClass=posixGroup)(memberUid=jdoe\2a\29\28uid=\2a))`))
+
+				baseDN, scope, _, _, _, _, _, _, _ = ldapFake.NewSearchRequestArgsForCall(2)
+				Expect(baseDN).To(Equal("cn=devs,ou=Groups,dc=test,dc=com"))
+				Expect(scope).To(Equal(ldap.ScopeBaseObject))
+			})
+
+			It("returns the numeric gids other than the primary gid", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(gid).To(Equal("1002"))
+				Expect(groups).To(Equal([]string{"3000", "4000"}))
+			})
+		})
+
+		Context("with a group lookup", func() {
+			BeforeEach(func() {
+				search.GroupFilter = "(&(objectClass=posixGroup)(member={userdn}))"
+				search.GroupBaseDN = "ou=Groups,dc=test,dc=com"
+				options = []nfsv3driver.LdapIdResolverOption{nfsv3driver.WithLdapSearch(search)}
+
+				ldapConnectionFake.SearchReturnsOnCall(1, &ldap.SearchResult{Entries: []*ldap.Entry{{
+					DN:         "cn=staff,ou=Groups,dc=test,dc=com",
+					Attributes: []*ldap.EntryAttribute{{Name: "gidNumber", Values: []string{"2000"}}},
+				}}}, nil)
+			})
+
+			It("looks the group up with the user's DN", func() {
+				Expect(ldapFake.NewSearchRequestCallCount()).To(Equal(2))
+				baseDN, _, _, _, _, _, filter, attributes, _ := ldapFake.NewSearchRequestArgsForCall(1)
+				Expect(baseDN).To(Equal("ou=Groups,dc=test,dc=com"))
+				Expect(filter).To(Equal("(&(objectClass=posixGroup)(member=uid=jdoe,ou=People,dc=test,dc=com))"))
+				Expect(attributes).To(ConsistOf("dn", "gidNumber"))
+			})
+
+			It("uses the gid of the group", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(uid).To(Equal("1001"))
+				Expect(gid).To(Equal("2000"))
+			})
+
+			Context("when no group matches", func() {
+				BeforeEach(func() {
+					ldapConnectionFake.SearchReturnsOnCall(1, &ldap.SearchResult{}, nil)
+				})
+
+				It("keeps the gid of the user entry", func() {
+					Expect(err).NotTo(HaveOccurred())
+					Expect(gid).To(Equal("1002"))
+				})
+			})
+
+			Context("when several groups match", func() {
+				BeforeEach(func() {
+					ldapConnectionFake.SearchReturnsOnCall(1, &ldap.SearchResult{Entries: []*ldap.Entry{{DN: "cn=a"}, {DN: "cn=b"}}}, nil)
+				})
+
+				It("reports an error for the ambiguous search", func() {
+					Expect(err).To(MatchError("Ambiguous group search--too many results"))
+					Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+				})
+			})
+		})
+	})
+
+	Context("when several LDAP servers are configured", func() {
+		var servers *nfsv3driver.LdapServers
+
+		BeforeEach(func() {
+			ldapFake = &ldap_fake.FakeLdap{}
+			ldapConnectionFake = &ldap_fake.FakeLdapConnection{}
+			ldapCACert = ""
+			ldapTimeout = 120 * time.Second
+			ldapConnectionFake.SearchReturns(&ldap.SearchResult{Entries: []*ldap.Entry{{
+				DN:         "foo",
+				Attributes: []*ldap.EntryAttribute{{Name: "uidNumber", Values: []string{"100"}}},
+			}}}, nil)
+			ldapFake.DialStub = func(proto, addr string) (ldapshim.LdapConnection, error) {
+				if addr == "dc1:389" {
+					return nil, errors.New("connection refused")
+				}
+				return ldapConnectionFake, nil
+			}
+
+			servers = nfsv3driver.NewStaticLdapServers([]nfsv3driver.LdapEndpoint{{Host: "dc1", Port: 389}, {Host: "dc2", Port: 636}}, time.Minute)
+			options = []nfsv3driver.LdapIdResolverOption{nfsv3driver.WithLdapServers(servers)}
+		})
+
+		It("fails over to the next server", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uid).To(Equal("100"))
+
+			Expect(ldapFake.DialCallCount()).To(Equal(2))
+			_, addr := ldapFake.DialArgsForCall(0)
+			Expect(addr).To(Equal("dc1:389"))
+			_, addr = ldapFake.DialArgsForCall(1)
+			Expect(addr).To(Equal("dc2:636"))
+		})
+
+		It("logs which server answered", func() {
+			Expect(env.Logger().(*lagertest.TestLogger).LogMessages()).To(ContainElement("nfs-mounter.ldap-dial.ldap-server-answered"))
+			Expect(env.Logger().(*lagertest.TestLogger).Logs()).To(ContainElement(HaveField("Data", HaveKeyWithValue("server", "dc2:636"))))
+		})
+
+		It("tries the server that failed last until its cooldown has passed", func() {
+			_, _, _, err = ldapIdResolver.Resolve(env, user, "pw")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ldapFake.DialCallCount()).To(Equal(3))
+			_, addr := ldapFake.DialArgsForCall(2)
+			Expect(addr).To(Equal("dc2:636"))
+		})
+
+		Context("when no server can be reached", func() {
+			BeforeEach(func() {
+				ldapFake.DialStub = nil
+				ldapFake.DialReturns(nil, errors.New("connection refused"))
+			})
+
+			It("returns an error after trying them all", func() {
+				Expect(err).To(MatchError("LDAP server could not be reached, please contact your system administrator"))
+				Expect(ldapFake.DialCallCount()).To(Equal(2))
+			})
+		})
+
+		Context("when the servers are discovered from SRV records", func() {
+			var srvResolver *nfsdriverfakes.FakeSrvResolver
+
+			BeforeEach(func() {
+				srvResolver = &nfsdriverfakes.FakeSrvResolver{}
+				srvResolver.LookupSRVReturns("_ldap._tcp.corp.test.com.", []*net.SRV{
+					{Target: "dc2.corp.test.com.", Port: 389, Priority: 10, Weight: 100},
+					{Target: "dc1.corp.test.com.", Port: 389, Priority: 0, Weight: 0},
+				}, nil)
+				ldapFake.DialStub = func(proto, addr string) (ldapshim.LdapConnection, error) {
+					if addr == "dc1.corp.test.com:389" {
+						return nil, errors.New("connection refused")
+					}
+					return ldapConnectionFake, nil
+				}
+
+				servers = nfsv3driver.NewSrvLdapServers(srvResolver, "corp.test.com", time.Minute)
+				options = []nfsv3driver.LdapIdResolverOption{nfsv3driver.WithLdapServers(servers)}
+			})
+
+			It("looks up the _ldap._tcp records of the domain", func() {
+				Expect(srvResolver.LookupSRVCallCount()).To(Equal(1))
+				_, service, proto, name := srvResolver.LookupSRVArgsForCall(0)
+				Expect(service).To(Equal("ldap"))
+				Expect(proto).To(Equal("tcp"))
+				Expect(name).To(Equal("corp.test.com"))
+			})
+
+			It("tries the servers in priority order", func() {
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(ldapFake.DialCallCount()).To(Equal(2))
+				_, addr := ldapFake.DialArgsForCall(0)
+				Expect(addr).To(Equal("dc1.corp.test.com:389"))
+				_, addr = ldapFake.DialArgsForCall(1)
+				Expect(addr).To(Equal("dc2.corp.test.com:389"))
+			})
+
+			Context("when a later lookup fails", func() {
+				It("uses the servers found before", func() {
+					srvResolver.LookupSRVReturns("", nil, errors.New("no such host"))
+
+					_, _, _, err = ldapIdResolver.Resolve(env, user, "pw")
+					Expect(err).NotTo(HaveOccurred())
+					_, addr := ldapFake.DialArgsForCall(2)
+					Expect(addr).To(Equal("dc2.corp.test.com:389"))
+				})
+			})
+
+			Context("when no records are found", func() {
+				BeforeEach(func() {
+					srvResolver.LookupSRVReturns("", nil, errors.New("no such host"))
+				})
+
+				It("returns an error", func() {
+					Expect(err).To(MatchError("LDAP server could not be reached, please contact your system administrator"))
+					Expect(ldapFake.DialCallCount()).To(Equal(0))
+				})
+			})
+		})
+	})
+
 	Context("LDAP Server is unreachable", func() {
 		BeforeEach(func() {
 			ldapFake = &ldap_fake.FakeLdap{}
@@ -291,3 +853,23 @@ z6sbK6WkL0AwPEcI/HzUOrsAUBtyY8cfy6yVcuQ=
 		})
 	})
 })
+
+const testCACert = `-----BEGIN CERTIFICATE-----
+MIIDGTCCAgGgAwIBAgIRAIlVvSGFPY1EvNayuTpPAScwDQYJKoZIhvcNAQELBQAw
+EjEQMA4GA1UEChMHQWNtZSBDbzAeFw0xODA1MzExNzU5MTBaFw0xOTA1MzExNzU5
+MTBaMBIxEDAOBgNVBAoTB0FjbWUgQ28wggEiMA0GCSqGSIb3DQEBAQUAA4IBDwAw
+ggEKAoIBAQCSf8J68FYrRuE8+NumcleeI10+O5QGibQ3+axX79eFS3RGcQKn5UOr
+OFE/RM/ghc7sUD8urLhlA2QAua+0dZEr+QtNswDxLfWljw08azR4xkPnBejdwYKU
+jHHU9UoJrxEgWqNFwTWWCyHYERUK/RFSrSUJaZLv1fRa9C+wbkD2Wd+aesPU6TZr
+5f6DT1UdL5umykwVoKy9ymA1CUi3iRSPuIxF0iuwwNtgtS0Dswi9+gqICOYp+lGJ
+RM2zRZFas8clubvkIRYlO2YG8hb181uxW9nLAfUfJjjtDt7lp5z/eZqliFwzrl0i
+DG8xWUppHV9654hGRDOL2ow3u8kwNv9/AgMBAAGjajBoMA4GA1UdDwEB/wQEAwIC
+pDATBgNVHSUEDDAKBggrBgEFBQcDATAPBgNVHRMBAf8EBTADAQH/MDAGA1UdEQQp
+MCeCJW5mc3Rlc3RsZGFwc2VydmVyLnNlcnZpY2UuY2YuaW50ZXJuYWwwDQYJKoZI
+hvcNAQELBQADggEBAB/4KT3+G5YqrnCCF+GmYlxZO9ScRA6yPBtwXTQe7WH8Yfz2
+bnUs4jKhK2wh3+RSTsBwV9afF+xm/uVrD9iZveixC1E3NqJwlchHc2bv9NCvC8OY
+VShIx+8Joqpud6VIrzclhus2lo9Dvn55at3Z/5SYDf07fDmSJ5pZuLUVryiJk9AT
+G0GELNbBftMakAJaH6eqGvcNbDRMeFqq7VyjthQJRPWSaWKA6TsfzgiO9lwx1wd1
+1ZtN1nl1NexFqcan26vg0f1SwLM9r9mVXrKII/T60RXKvtcAkMS3XfaebG3ulout
+z6sbK6WkL0AwPEcI/HzUOrsAUBtyY8cfy6yVcuQ=
+-----END CERTIFICATE-----`
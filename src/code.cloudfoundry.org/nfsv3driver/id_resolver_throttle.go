@@ -0,0 +1,149 @@
+package nfsv3driver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/goshims/timeshim"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+const DefaultLoginMaxFailures = 5
+const DefaultLoginBackoff = time.Second
+const DefaultLoginLockout = time.Minute * 15
+const TooManyFailedLoginsErrorMessage = "Too many failed logins for this user"
+
+// throttlingIdResolver counts the wrong passwords given for a username. After each failure the username is
+// rejected for an exponentially growing backoff, and after maxFailures failures in a row it is locked out for
+// lockout, without the wrapped resolver (and so the directory) being asked. Failures are forgotten after a
+// successful resolve, or once lockout has passed since the last one.
+//
+// Every attempt is reserved before the wrapped resolver is asked, so that concurrent attempts cannot all
+// pass the check before the first of them fails: a username runs at most maxFailures attempts at a time,
+// and only one once it has failed. Further attempts wait for a running one to finish.
+type throttlingIdResolver struct {
+	resolver    IdResolver
+	clock       timeshim.Time
+	maxFailures int
+	backoff     time.Duration
+	lockout     time.Duration
+
+	lock     sync.Mutex
+	finished *sync.Cond
+	failures map[string]loginFailures
+	inFlight map[string]int
+}
+
+type loginFailures struct {
+	count        int
+	last         time.Time
+	blockedUntil time.Time
+}
+
+func NewThrottlingIdResolver(resolver IdResolver, clock timeshim.Time, maxFailures int, backoff time.Duration, lockout time.Duration) IdResolver {
+	t := &throttlingIdResolver{
+		resolver:    resolver,
+		clock:       clock,
+		maxFailures: maxFailures,
+		backoff:     backoff,
+		lockout:     lockout,
+		failures:    map[string]loginFailures{},
+		inFlight:    map[string]int{},
+	}
+	t.finished = sync.NewCond(&t.lock)
+	return t
+}
+
+func (t *throttlingIdResolver) Resolve(env dockerdriver.Env, username string, password string) (string, string, []string, error) {
+	logger := env.Logger().Session("throttled-resolve", lager.Data{"username": username})
+
+	// directories usually match usernames case insensitively, so must the failure counts
+	key := strings.ToLower(username)
+
+	t.lock.Lock()
+	for {
+		failures := t.failures[key]
+		if wait := failures.blockedUntil.Sub(t.clock.Now()); wait > 0 {
+			t.lock.Unlock()
+			logger.Info("login-throttled", lager.Data{"failures": failures.count, "blocked-until": failures.blockedUntil})
+			return "", "", nil, dockerdriver.SafeError{SafeDescription: fmt.Sprintf("%s, try again in %s", TooManyFailedLoginsErrorMessage, wait.Round(time.Second))}
+		}
+		if t.inFlight[key] == 0 || (failures.count == 0 && t.inFlight[key] < t.maxFailures) {
+			break
+		}
+		t.finished.Wait()
+	}
+	t.inFlight[key]++
+	t.lock.Unlock()
+
+	uid, gid, groups, err := t.resolver.Resolve(env, username, password)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	defer t.finished.Broadcast()
+	t.inFlight[key]--
+	if t.inFlight[key] == 0 {
+		delete(t.inFlight, key)
+	}
+	now := t.clock.Now()
+	t.prune(now)
+
+	if isInvalidCredentials(err) {
+		failures := t.failures[key]
+		failures.count++
+		failures.last = now
+		failures.blockedUntil = now.Add(t.delay(failures.count))
+		t.failures[key] = failures
+
+		if failures.count >= t.maxFailures {
+			logger.Info("user-locked-out", lager.Data{"failures": failures.count, "blocked-until": failures.blockedUntil})
+		} else {
+			logger.Info("login-failed", lager.Data{"failures": failures.count, "blocked-until": failures.blockedUntil})
+		}
+		return "", "", nil, err
+	}
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	delete(t.failures, key)
+	return uid, gid, groups, nil
+}
+
+// delay returns how long a username is rejected after its count-th failure in a row.
+func (t *throttlingIdResolver) delay(count int) time.Duration {
+	if count >= t.maxFailures {
+		return t.lockout
+	}
+
+	delay := t.backoff
+	for i := 1; i < count && delay < t.lockout; i++ {
+		delay *= 2
+	}
+	if delay > t.lockout {
+		return t.lockout
+	}
+	return delay
+}
+
+func (t *throttlingIdResolver) prune(now time.Time) {
+	for key, failures := range t.failures {
+		if now.Sub(failures.last) >= t.lockout {
+			delete(t.failures, key)
+		}
+	}
+}
+
+func isInvalidCredentials(err error) bool {
+	var safeErr dockerdriver.SafeError
+	return errors.As(err, &safeErr) && safeErr.SafeDescription == InvalidCredentialsErrorMessage
+}
+
+func isLoginThrottled(err error) bool {
+	var safeErr dockerdriver.SafeError
+	return errors.As(err, &safeErr) && strings.HasPrefix(safeErr.SafeDescription, TooManyFailedLoginsErrorMessage)
+}
@@ -0,0 +1,225 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/timeshim/time_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ThrottlingIdResolver", func() {
+	var (
+		logger       *lagertest.TestLogger
+		env          dockerdriver.Env
+		fakeResolver *nfsdriverfakes.FakeIdResolver
+		fakeTime     *time_fake.FakeTime
+		now          time.Time
+		subject      nfsv3driver.IdResolver
+	)
+
+	invalidCredentials := dockerdriver.SafeError{SafeDescription: nfsv3driver.InvalidCredentialsErrorMessage}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("throttling-id-resolver")
+		env = driverhttp.NewHttpDriverEnv(logger, context.TODO())
+
+		fakeResolver = &nfsdriverfakes.FakeIdResolver{}
+		fakeResolver.ResolveReturns("", "", nil, invalidCredentials)
+
+		now = time.Unix(1700000000, 0)
+		fakeTime = &time_fake.FakeTime{}
+		fakeTime.NowStub = func() time.Time { return now }
+
+		subject = nfsv3driver.NewThrottlingIdResolver(fakeResolver, fakeTime, 3, time.Second, time.Minute)
+	})
+
+	resolve := func(username string) error {
+		_, _, _, err := subject.Resolve(env, username, "pw")
+		return err
+	}
+
+	It("passes a wrong password on to the caller", func() {
+		Expect(resolve("user")).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+		Expect(fakeResolver.ResolveCallCount()).To(Equal(1))
+	})
+
+	It("rejects the user without asking the wrapped resolver during the backoff", func() {
+		Expect(resolve("user")).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+
+		err := resolve("user")
+		Expect(err).To(MatchError(ContainSubstring(nfsv3driver.TooManyFailedLoginsErrorMessage)))
+		Expect(err).To(MatchError(ContainSubstring("try again in 1s")))
+		Expect(err).To(BeAssignableToTypeOf(dockerdriver.SafeError{}))
+		Expect(fakeResolver.ResolveCallCount()).To(Equal(1))
+	})
+
+	It("matches usernames case insensitively", func() {
+		Expect(resolve("user")).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+		Expect(resolve("USER")).To(MatchError(ContainSubstring(nfsv3driver.TooManyFailedLoginsErrorMessage)))
+	})
+
+	It("does not throttle other users", func() {
+		Expect(resolve("user")).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+		Expect(resolve("other-user")).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+		Expect(fakeResolver.ResolveCallCount()).To(Equal(2))
+	})
+
+	It("doubles the backoff after each failure", func() {
+		Expect(resolve("user")).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+		now = now.Add(time.Second)
+		Expect(resolve("user")).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+
+		now = now.Add(time.Second)
+		Expect(resolve("user")).To(MatchError(ContainSubstring("try again in 1s")))
+		Expect(fakeResolver.ResolveCallCount()).To(Equal(2))
+
+		now = now.Add(time.Second)
+		Expect(resolve("user")).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+		Expect(fakeResolver.ResolveCallCount()).To(Equal(3))
+	})
+
+	Context("after too many failures in a row", func() {
+		BeforeEach(func() {
+			for i := 0; i < 3; i++ {
+				Expect(resolve("user")).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+				now = now.Add(10 * time.Second)
+			}
+		})
+
+		It("locks the user out, even with the right password", func() {
+			fakeResolver.ResolveReturns("100", "200", nil, nil)
+
+			Expect(resolve("user")).To(MatchError(ContainSubstring("try again in 50s")))
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(3))
+			Expect(logger.LogMessages()).To(ContainElement("throttling-id-resolver.throttled-resolve.user-locked-out"))
+		})
+
+		It("forgets the failures once the lockout has passed", func() {
+			now = now.Add(time.Minute)
+			Expect(resolve("user")).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+			now = now.Add(time.Second)
+			Expect(resolve("user")).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(5))
+		})
+	})
+
+	Context("when the user then logs in successfully", func() {
+		BeforeEach(func() {
+			Expect(resolve("user")).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+			now = now.Add(time.Second)
+			fakeResolver.ResolveReturns("100", "200", []string{"300"}, nil)
+		})
+
+		It("returns the ids and forgets the failures", func() {
+			uid, gid, groups, err := subject.Resolve(env, "user", "pw")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uid).To(Equal("100"))
+			Expect(gid).To(Equal("200"))
+			Expect(groups).To(Equal([]string{"300"}))
+
+			fakeResolver.ResolveReturns("", "", nil, invalidCredentials)
+			Expect(resolve("user")).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+			Expect(resolve("user")).To(MatchError(ContainSubstring("try again in 1s")))
+		})
+	})
+
+	Context("when attempts run concurrently", func() {
+		var (
+			release chan struct{}
+			started chan struct{}
+		)
+
+		BeforeEach(func() {
+			release = make(chan struct{})
+			started = make(chan struct{}, 10)
+			releaseCh, startedCh := release, started
+			fakeResolver.ResolveStub = func(dockerdriver.Env, string, string) (string, string, []string, error) {
+				startedCh <- struct{}{}
+				<-releaseCh
+				return "", "", nil, invalidCredentials
+			}
+		})
+
+		It("never lets more attempts reach the wrapped resolver than the user may fail", func() {
+			errs := make(chan error, 5)
+			for i := 0; i < 5; i++ {
+				go func() {
+					defer GinkgoRecover()
+					errs <- resolve("user")
+				}()
+			}
+
+			for i := 0; i < 3; i++ {
+				Eventually(started).Should(Receive())
+			}
+			Consistently(started).ShouldNot(Receive())
+			close(release)
+
+			var invalid, throttled int
+			for i := 0; i < 5; i++ {
+				var err error
+				Eventually(errs).Should(Receive(&err))
+				if err.Error() == nfsv3driver.InvalidCredentialsErrorMessage {
+					invalid++
+				} else {
+					Expect(err).To(MatchError(ContainSubstring(nfsv3driver.TooManyFailedLoginsErrorMessage)))
+					throttled++
+				}
+			}
+			Expect(invalid).To(Equal(3))
+			Expect(throttled).To(Equal(2))
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(3))
+		})
+
+		It("runs one attempt at a time once the user has failed", func() {
+			close(release)
+			Expect(resolve("user")).To(MatchError(nfsv3driver.InvalidCredentialsErrorMessage))
+			Eventually(started).Should(Receive())
+			now = now.Add(time.Second)
+
+			blocked := make(chan struct{})
+			blockedCh := blocked
+			fakeResolver.ResolveStub = func(dockerdriver.Env, string, string) (string, string, []string, error) {
+				started <- struct{}{}
+				<-blockedCh
+				return "100", "200", nil, nil
+			}
+
+			errs := make(chan error, 2)
+			for i := 0; i < 2; i++ {
+				go func() {
+					defer GinkgoRecover()
+					errs <- resolve("user")
+				}()
+			}
+
+			Eventually(started).Should(Receive())
+			Consistently(started).ShouldNot(Receive())
+			close(blocked)
+
+			Eventually(errs).Should(Receive(BeNil()))
+			Eventually(errs).Should(Receive(BeNil()))
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(3))
+		})
+	})
+
+	Context("when the wrapped resolver fails for another reason", func() {
+		BeforeEach(func() {
+			fakeResolver.ResolveReturns("", "", nil, errors.New("LDAP Result Code 200 \"Network Error\""))
+		})
+
+		It("does not count it as a failed login", func() {
+			Expect(resolve("user")).To(MatchError(ContainSubstring("Network Error")))
+			Expect(resolve("user")).To(MatchError(ContainSubstring("Network Error")))
+			Expect(fakeResolver.ResolveCallCount()).To(Equal(2))
+		})
+	})
+})
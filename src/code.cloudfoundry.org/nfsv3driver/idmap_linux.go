@@ -0,0 +1,125 @@
+//go:build linux
+// +build linux
+
+package nfsv3driver
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+type kernelIdMapper struct {
+	appUid uint32
+	appGid uint32
+
+	lock sync.Mutex
+	// unsupported is set once the kernel or the NFS client has refused an idmapped mount, so that later
+	// mounts fall back to mapfs without trying again
+	unsupported error
+}
+
+func NewKernelIdMapper(appUid uint32, appGid uint32) IdMapper {
+	return &kernelIdMapper{appUid: appUid, appGid: appGid}
+}
+
+// Supported only tells whether the kernel has mount_setattr(2). Whether the filesystem of a share allows
+// idmapped mounts (FS_ALLOW_IDMAP) is only known once it is mounted, so the first Map is the probe for that.
+// Mainline NFS clients do not allow them, and after such a refusal every Map fails with ErrIdmapUnsupported
+// without creating a user namespace.
+func (k *kernelIdMapper) Supported() error {
+	// an empty attribute set on an invalid fd fails with EBADF when mount_setattr(2) exists
+	err := unix.MountSetattr(-1, "", unix.AT_EMPTY_PATH, &unix.MountAttr{})
+	if errors.Is(err, unix.ENOSYS) {
+		return fmt.Errorf("%w: mount_setattr is not available", ErrIdmapUnsupported)
+	}
+
+	return nil
+}
+
+func (k *kernelIdMapper) Map(source string, target string, uid uint32, gid uint32) error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if k.unsupported != nil {
+		return k.unsupported
+	}
+
+	err := k.mapTree(source, target, uid, gid)
+	if errors.Is(err, ErrIdmapUnsupported) {
+		k.unsupported = err
+	}
+	return err
+}
+
+func (k *kernelIdMapper) mapTree(source string, target string, uid uint32, gid uint32) error {
+	treeFd, err := unix.OpenTree(unix.AT_FDCWD, source, unix.OPEN_TREE_CLONE|unix.OPEN_TREE_CLOEXEC)
+	if err != nil {
+		return unsupportedIfNotImplemented("open_tree", err)
+	}
+	defer unix.Close(treeFd)
+
+	// the idmapped mount keeps its own reference to the user namespace, so the fd is only needed until then
+	usernsFd, err := k.userNamespace(uid, gid)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(usernsFd)
+
+	err = unix.MountSetattr(treeFd, "", unix.AT_EMPTY_PATH, &unix.MountAttr{
+		Attr_set:  unix.MOUNT_ATTR_IDMAP,
+		Userns_fd: uint64(usernsFd),
+	})
+	if err != nil {
+		// filesystems that do not support idmapped mounts (FS_ALLOW_IDMAP) are rejected with EINVAL
+		return unsupportedIfNotImplemented("mount_setattr", err)
+	}
+
+	err = unix.MoveMount(treeFd, "", unix.AT_FDCWD, target, unix.MOVE_MOUNT_F_EMPTY_PATH)
+	if err != nil {
+		return fmt.Errorf("move_mount: %w", err)
+	}
+
+	return nil
+}
+
+// userNamespace returns an fd for a new user namespace that maps uid/gid to the app user. The namespace is
+// created by a child process that is killed and reaped as soon as the fd is open.
+func (k *kernelIdMapper) userNamespace(uid uint32, gid uint32) (int, error) {
+	cmd := exec.Command("sleep", "infinity")
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: int(uid), HostID: int(k.appUid), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: int(gid), HostID: int(k.appGid), Size: 1}},
+		Pdeathsig:   syscall.SIGKILL,
+	}
+
+	err := cmd.Start()
+	if errors.Is(err, exec.ErrNotFound) {
+		return -1, fmt.Errorf("%w: %s", ErrIdmapUnsupported, err.Error())
+	}
+	if err != nil {
+		return -1, unsupportedIfNotImplemented("clone", err)
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	fd, err := unix.Open(fmt.Sprintf("/proc/%d/ns/user", cmd.Process.Pid), unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return -1, fmt.Errorf("open user namespace: %w", err)
+	}
+	return fd, nil
+}
+
+func unsupportedIfNotImplemented(call string, err error) error {
+	if errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EINVAL) || errors.Is(err, unix.EOPNOTSUPP) {
+		return fmt.Errorf("%w: %s: %s", ErrIdmapUnsupported, call, err.Error())
+	}
+	return fmt.Errorf("%s: %w", call, err)
+}
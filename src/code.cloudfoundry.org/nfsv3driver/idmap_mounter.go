@@ -0,0 +1,84 @@
+package nfsv3driver
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/goshims/osshim"
+	"code.cloudfoundry.org/goshims/syscallshim"
+	"code.cloudfoundry.org/lager/v3"
+	vmo "code.cloudfoundry.org/volume-mount-options"
+	"code.cloudfoundry.org/volumedriver/invoker"
+	"code.cloudfoundry.org/volumedriver/mountchecker"
+)
+
+const DefaultIdmapAppId = uint32(2000)
+
+// ErrIdmapUnsupported is returned by an IdMapper when the kernel (or the filesystem being mapped)
+// cannot create idmapped mounts.
+var ErrIdmapUnsupported = errors.New("idmapped mounts are not supported")
+
+//counterfeiter:generate -o nfsdriverfakes/fake_id_mapper.go . IdMapper
+type IdMapper interface {
+	Supported() error
+	// Map creates an idmapped bind mount of source on target, so that files owned by uid/gid on
+	// source appear to be owned by the app user on target.
+	Map(source string, target string, uid uint32, gid uint32) error
+}
+
+// idmapMounter is a mapfsMounter that maps uids with a kernel idmapped mount instead of a mapfs
+// FUSE process, falling back to mapfs for mounts that the kernel cannot idmap.
+type idmapMounter struct {
+	*mapfsMounter
+	idMapper IdMapper
+}
+
+func NewIdmapMounter(
+	invoker invoker.Invoker,
+	osshim osshim.Os,
+	syscallshim syscallshim.Syscall,
+	mountChecker mountchecker.MountChecker,
+	fstype string,
+	defaultOpts string,
+	resolver IdResolver,
+	mask vmo.MountOptsMask,
+	mapfsPath string,
+	idMapper IdMapper,
+	options ...MapfsMounterOption,
+) RecoverableMounter {
+	m := NewMapfsMounter(invoker, osshim, syscallshim, mountChecker, fstype, defaultOpts, resolver, mask, mapfsPath, options...)
+
+	return &idmapMounter{
+		mapfsMounter: m.(*mapfsMounter),
+		idMapper:     idMapper,
+	}
+}
+
+func (m *idmapMounter) Mount(env dockerdriver.Env, remote string, target string, opts map[string]interface{}) error {
+	defer m.lockTarget(target)()
+	return m.auditedMount(env, AuditEventMount, remote, target, opts, m.idmapMount)
+}
+
+func (m *idmapMounter) idmapMount(env dockerdriver.Env, source string, target string, uid int, gid int, opts vmo.MountOpts) error {
+	logger := env.Logger().Session("idmap-mount", lager.Data{"source": source, "target": target})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	// an idmapped mount maps a single gid, so supplementary groups need mapfs
+	if _, ok := opts["groups"]; ok {
+		logger.Info("supplementary-groups-need-mapfs")
+		return m.mapfsMount(env, source, target, uid, gid, opts)
+	}
+
+	err := m.idMapper.Map(source, target, uint32(uid), uint32(gid))
+	if errors.Is(err, ErrIdmapUnsupported) {
+		logger.Info("idmap-unsupported-falling-back-to-mapfs", lager.Data{"reason": err.Error()})
+		return m.mapfsMount(env, source, target, uid, gid, opts)
+	}
+	if err != nil {
+		logger.Error("idmap-failed", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/osshim/os_fake"
+	"code.cloudfoundry.org/goshims/syscallshim/syscall_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	"code.cloudfoundry.org/volumedriver"
+	"code.cloudfoundry.org/volumedriver/invokerfakes"
+	nfsfakes "code.cloudfoundry.org/volumedriver/volumedriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IdmapMounter", func() {
+	var (
+		logger           *lagertest.TestLogger
+		env              dockerdriver.Env
+		err              error
+		fakeInvoker      *invokerfakes.FakeInvoker
+		fakeInvokeResult *invokerfakes.FakeInvokeResult
+		fakeOs           *os_fake.FakeOs
+		fakeSyscall      *syscall_fake.FakeSyscall
+		fakeMountChecker *nfsfakes.FakeMountChecker
+		fakeIdMapper     *nfsdriverfakes.FakeIdMapper
+
+		subject   volumedriver.Mounter
+		opts      map[string]interface{}
+		mapfsPath string
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("idmap-mounter")
+		env = driverhttp.NewHttpDriverEnv(logger, context.TODO())
+		mapfsPath = "/var/vcap/packages/mapfs/bin/mapfs"
+		opts = map[string]interface{}{"uid": "2000", "gid": "3000"}
+
+		fakeInvoker = &invokerfakes.FakeInvoker{}
+		fakeInvokeResult = &invokerfakes.FakeInvokeResult{}
+		fakeInvoker.InvokeReturns(fakeInvokeResult)
+		fakeOs = &os_fake.FakeOs{}
+		fakeSyscall = &syscall_fake.FakeSyscall{}
+		fakeSyscall.StatStub = func(path string, st *syscall.Stat_t) error {
+			st.Mode = 0777
+			return nil
+		}
+		fakeMountChecker = &nfsfakes.FakeMountChecker{}
+		fakeIdMapper = &nfsdriverfakes.FakeIdMapper{}
+
+		mask, err := nfsv3driver.NewMapFsVolumeMountMask()
+		Expect(err).NotTo(HaveOccurred())
+
+		subject = nfsv3driver.NewIdmapMounter(fakeInvoker, fakeOs, fakeSyscall, fakeMountChecker, "my-fs", "my-mount-options", nil, mask, mapfsPath, fakeIdMapper)
+	})
+
+	Context("#Mount", func() {
+		JustBeforeEach(func() {
+			err = subject.Mount(env, "source", "target", opts)
+		})
+
+		It("mounts the share on the intermediate directory", func() {
+			Expect(err).NotTo(HaveOccurred())
+			_, cmd, args, _ := fakeInvoker.InvokeArgsForCall(0)
+			Expect(cmd).To(Equal("mount"))
+			Expect(args).To(ContainElement("source"))
+			Expect(args).To(ContainElement("target_mapfs"))
+		})
+
+		It("creates an idmapped mount of the intermediate directory on the target instead of launching mapfs", func() {
+			Expect(fakeIdMapper.MapCallCount()).To(Equal(1))
+			source, target, uid, gid := fakeIdMapper.MapArgsForCall(0)
+			Expect(source).To(Equal("target_mapfs"))
+			Expect(target).To(Equal("target"))
+			Expect(uid).To(Equal(uint32(2000)))
+			Expect(gid).To(Equal(uint32(3000)))
+
+			Expect(fakeInvoker.InvokeCallCount()).To(Equal(1))
+		})
+
+		Context("when the kernel cannot idmap the share", func() {
+			BeforeEach(func() {
+				fakeIdMapper.MapReturns(fmt.Errorf("%w: mount_setattr: invalid argument", nfsv3driver.ErrIdmapUnsupported))
+			})
+
+			It("falls back to mapfs", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeInvoker.InvokeCallCount()).To(Equal(2))
+				_, cmd, args, _ := fakeInvoker.InvokeArgsForCall(1)
+				Expect(cmd).To(Equal(mapfsPath))
+				Expect(args).To(Equal([]string{"-uid", "2000", "-gid", "3000", "-auto_cache", "target", "target_mapfs"}))
+				Expect(logger.LogMessages()).To(ContainElement(ContainSubstring("idmap-unsupported-falling-back-to-mapfs")))
+			})
+		})
+
+		Context("when the LDAP user has supplementary groups", func() {
+			BeforeEach(func() {
+				fakeIdResolver := &nfsdriverfakes.FakeIdResolver{}
+				fakeIdResolver.ResolveReturns("2000", "3000", []string{"4000"}, nil)
+				mask, err := nfsv3driver.NewMapFsVolumeMountMask()
+				Expect(err).NotTo(HaveOccurred())
+				subject = nfsv3driver.NewIdmapMounter(fakeInvoker, fakeOs, fakeSyscall, fakeMountChecker, "my-fs", "my-mount-options", fakeIdResolver, mask, mapfsPath, fakeIdMapper, nfsv3driver.WithSupplementaryGroups())
+				opts = map[string]interface{}{"username": "user", "password": "pw"}
+			})
+
+			It("mounts with mapfs, which can map them", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeIdMapper.MapCallCount()).To(Equal(0))
+				_, cmd, args, _ := fakeInvoker.InvokeArgsForCall(1)
+				Expect(cmd).To(Equal(mapfsPath))
+				Expect(args).To(Equal([]string{"-uid", "2000", "-gid", "3000", "-groups", "4000", "-auto_cache", "target", "target_mapfs"}))
+			})
+		})
+
+		Context("when the idmapped mount fails", func() {
+			BeforeEach(func() {
+				fakeIdMapper.MapReturns(errors.New("move_mount: permission denied"))
+			})
+
+			It("cleans up the intermediate mount and returns a safe error", func() {
+				Expect(err).To(MatchError("move_mount: permission denied"))
+				_, ok := err.(dockerdriver.SafeError)
+				Expect(ok).To(BeTrue())
+
+				_, cmd, args, _ := fakeInvoker.InvokeArgsForCall(1)
+				Expect(cmd).To(Equal("umount"))
+				Expect(args).To(Equal([]string{"target_mapfs"}))
+				Expect(fakeOs.RemoveCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when there is no uid", func() {
+			BeforeEach(func() {
+				opts = map[string]interface{}{}
+			})
+
+			It("mounts directly to the target without mapping", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeIdMapper.MapCallCount()).To(Equal(0))
+				_, _, args, _ := fakeInvoker.InvokeArgsForCall(0)
+				Expect(args).To(ContainElement("target"))
+			})
+		})
+	})
+
+	Context("#Unmount", func() {
+		BeforeEach(func() {
+			fakeMountChecker.ExistsReturns(true, nil)
+		})
+
+		It("unmounts the idmapped mount and the intermediate mount", func() {
+			Expect(subject.Unmount(env, "target")).To(Succeed())
+			Expect(fakeInvoker.InvokeCallCount()).To(Equal(2))
+			_, _, args, _ := fakeInvoker.InvokeArgsForCall(0)
+			Expect(args).To(Equal([]string{"-l", "target"}))
+			_, _, args, _ = fakeInvoker.InvokeArgsForCall(1)
+			Expect(args).To(Equal([]string{"-l", "target_mapfs"}))
+		})
+	})
+})
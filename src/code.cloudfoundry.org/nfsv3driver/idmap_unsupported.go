@@ -0,0 +1,18 @@
+//go:build !linux
+// +build !linux
+
+package nfsv3driver
+
+type kernelIdMapper struct{}
+
+func NewKernelIdMapper(appUid uint32, appGid uint32) IdMapper {
+	return &kernelIdMapper{}
+}
+
+func (k *kernelIdMapper) Supported() error {
+	return ErrIdmapUnsupported
+}
+
+func (k *kernelIdMapper) Map(source string, target string, uid uint32, gid uint32) error {
+	return ErrIdmapUnsupported
+}
@@ -0,0 +1,332 @@
+package nfsv3driver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/osshim"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/volumedriver/invoker"
+)
+
+// KerberosCachePrefix is the prefix rpc.gssd looks for in its credential cache directory. It only uses a cache
+// for a uid when the uid follows the prefix as "_<uid>_" and the file is owned by that uid.
+const KerberosCachePrefix = "krb5cc_"
+const KerberosCacheOwner = "nfsv3driver"
+const KerberosKeytabSuffix = ".keytab"
+const DefaultKerberosRenewInterval = time.Hour
+
+// DefaultGssdCacheDir is the directory rpc.gssd reads credential caches from when it is started without -d.
+const DefaultGssdCacheDir = "/tmp"
+
+var kerberosSecurityFlavors = []string{"sys", "krb5", "krb5i", "krb5p"}
+
+//counterfeiter:generate -o nfsdriverfakes/fake_kerberos_credentials.go . KerberosCredentials
+type KerberosCredentials interface {
+	// Acquire obtains a ticket for principal using keytab and stores it where rpc.gssd finds it for each of uids,
+	// which are the users the kernel will make NFS requests for on target.
+	Acquire(env dockerdriver.Env, target string, principal string, keytab []byte, uids []int) error
+	Release(env dockerdriver.Env, target string)
+	ReleaseAll(env dockerdriver.Env)
+}
+
+// kerberosTickets are the credential caches of a target and the renewal that keeps them current, which closes
+// done once it has stopped writing them.
+type kerberosTickets struct {
+	uids []int
+	stop chan struct{}
+	done chan struct{}
+}
+
+// stopRenewal stops the renewal of the tickets and waits for a kinit that is still running, so that the caches
+// are not written again once this returns.
+func (t kerberosTickets) stopRenewal() {
+	close(t.stop)
+	<-t.done
+}
+
+// uidClaim is the principal whose tickets the caches of a uid hold, and the targets that use them.
+type uidClaim struct {
+	principal string
+	targets   map[string]bool
+}
+
+type kinitCredentials struct {
+	invoker       invoker.Invoker
+	osshim        osshim.Os
+	keytabDir     string
+	ccacheDir     string
+	renewInterval time.Duration
+
+	lock    sync.Mutex
+	tickets map[string]kerberosTickets
+	claims  map[int]*uidClaim
+}
+
+// NewKinitCredentials returns KerberosCredentials that run kinit with the keytab of each mount, which is kept in
+// keytabDir. The tickets are written to ccacheDir, which must be the directory rpc.gssd reads credential caches
+// from (its -d option), because mount.nfs does not pass its environment to rpc.gssd. rpc.gssd only uses the cache
+// of uid 0, which the kernel mount is made with, when it is started with -n. It uses any one of the caches of a
+// uid, so mounts that share a uid must share a principal, and Acquire refuses another principal for such a uid.
+func NewKinitCredentials(invoker invoker.Invoker, osshim osshim.Os, keytabDir string, ccacheDir string, renewInterval time.Duration) KerberosCredentials {
+	return &kinitCredentials{
+		invoker:       invoker,
+		osshim:        osshim,
+		keytabDir:     keytabDir,
+		ccacheDir:     ccacheDir,
+		renewInterval: renewInterval,
+		tickets:       map[string]kerberosTickets{},
+		claims:        map[int]*uidClaim{},
+	}
+}
+
+func (k *kinitCredentials) Acquire(env dockerdriver.Env, target string, principal string, keytab []byte, uids []int) error {
+	logger := env.Logger().Session("kerberos-acquire", lager.Data{"target": target, "principal": principal, "uids": uids})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	k.lock.Lock()
+	previous, renewing := k.tickets[target]
+	delete(k.tickets, target)
+	if renewing {
+		k.unclaimUids(target, previous.uids)
+	}
+	err := k.claimUids(target, principal, uids)
+	k.lock.Unlock()
+	if renewing {
+		previous.stopRenewal()
+	}
+	if err != nil {
+		logger.Error("uid-claimed-by-another-principal", err)
+		if renewing {
+			k.removeFiles(logger, append(k.cachePaths(target, previous.uids), k.keytabPath(target))...)
+		}
+		return err
+	}
+
+	keytabFile := k.keytabPath(target)
+	err = k.osshim.MkdirAll(k.keytabDir, 0700)
+	if err == nil {
+		err = k.osshim.WriteFile(keytabFile, keytab, 0600)
+	}
+	if err != nil {
+		logger.Error("write-keytab-failed", err)
+		k.unclaim(target, uids)
+		return err
+	}
+
+	err = k.kinit(env, principal, keytabFile, target, uids)
+	if err != nil {
+		logger.Error("kinit-failed", err)
+		k.unclaim(target, uids)
+		k.removeFiles(logger, append(k.cachePaths(target, uids), keytabFile)...)
+		return err
+	}
+
+	tickets := kerberosTickets{uids: uids, stop: make(chan struct{}), done: make(chan struct{})}
+	k.lock.Lock()
+	k.tickets[target] = tickets
+	k.lock.Unlock()
+
+	// renew from the keytab rather than with kinit -R so that tickets keep working past their renewable lifetime
+	renewEnv := driverhttp.NewHttpDriverEnv(env.Logger(), context.Background())
+	go k.renew(renewEnv, principal, keytabFile, target, tickets)
+
+	return nil
+}
+
+func (k *kinitCredentials) Release(env dockerdriver.Env, target string) {
+	logger := env.Logger().Session("kerberos-release", lager.Data{"target": target})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	k.lock.Lock()
+	tickets, ok := k.tickets[target]
+	delete(k.tickets, target)
+	if ok {
+		k.unclaimUids(target, tickets.uids)
+	}
+	k.lock.Unlock()
+
+	if !ok {
+		return
+	}
+	tickets.stopRenewal()
+
+	k.removeFiles(logger, append(k.cachePaths(target, tickets.uids), k.keytabPath(target))...)
+}
+
+func (k *kinitCredentials) ReleaseAll(env dockerdriver.Env) {
+	logger := env.Logger().Session("kerberos-release-all")
+	logger.Info("start")
+	defer logger.Info("end")
+
+	k.lock.Lock()
+	released := k.tickets
+	k.tickets = map[string]kerberosTickets{}
+	k.claims = map[int]*uidClaim{}
+	k.lock.Unlock()
+
+	for _, tickets := range released {
+		tickets.stopRenewal()
+	}
+
+	k.removeDirEntries(logger, k.keytabDir, func(string) bool { return true })
+	// the credential cache directory is shared with other users of rpc.gssd
+	k.removeDirEntries(logger, k.ccacheDir, func(name string) bool {
+		return strings.HasPrefix(name, KerberosCachePrefix) && strings.Contains(name, "_"+KerberosCacheOwner+"_")
+	})
+}
+
+// claimUids records that target uses the tickets of principal for uids, unless one of the uids already holds the
+// tickets of another principal. The caller holds k.lock.
+func (k *kinitCredentials) claimUids(target string, principal string, uids []int) error {
+	for _, uid := range uids {
+		if claim, ok := k.claims[uid]; ok && claim.principal != principal {
+			return fmt.Errorf("uid %d already uses the credentials of another Kerberos principal on this host", uid)
+		}
+	}
+
+	for _, uid := range uids {
+		claim, ok := k.claims[uid]
+		if !ok {
+			claim = &uidClaim{principal: principal, targets: map[string]bool{}}
+			k.claims[uid] = claim
+		}
+		claim.targets[target] = true
+	}
+	return nil
+}
+
+// unclaimUids drops the claims of target on uids. The caller holds k.lock.
+func (k *kinitCredentials) unclaimUids(target string, uids []int) {
+	for _, uid := range uids {
+		if claim, ok := k.claims[uid]; ok {
+			delete(claim.targets, target)
+			if len(claim.targets) == 0 {
+				delete(k.claims, uid)
+			}
+		}
+	}
+}
+
+func (k *kinitCredentials) unclaim(target string, uids []int) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	k.unclaimUids(target, uids)
+}
+
+func (k *kinitCredentials) renew(env dockerdriver.Env, principal, keytabFile, target string, tickets kerberosTickets) {
+	logger := env.Logger().Session("kerberos-renew", lager.Data{"principal": principal, "target": target, "uids": tickets.uids})
+	defer close(tickets.done)
+
+	ticker := time.NewTicker(k.renewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-tickets.stop:
+			return
+		case <-ticker.C:
+			if err := k.kinit(env, principal, keytabFile, target, tickets.uids); err != nil {
+				logger.Error("renew-failed", err)
+				continue
+			}
+			logger.Debug("renewed")
+		}
+	}
+}
+
+// kinit writes a ticket for principal to the cache of each uid, and gives the uid the cache so that rpc.gssd
+// accepts it. kinit may replace the cache file when it renews it, so the owner is set every time.
+func (k *kinitCredentials) kinit(env dockerdriver.Env, principal, keytabFile, target string, uids []int) error {
+	for _, uid := range uids {
+		cache := k.cachePath(target, uid)
+		result := k.invoker.Invoke(env, "kinit", []string{"-k", "-t", keytabFile, "-c", "FILE:" + cache, principal})
+		err := result.Wait()
+		if err != nil && result.StdError() != "" {
+			return errors.New(result.StdError())
+		}
+		if err != nil {
+			return err
+		}
+
+		err = k.osshim.Chown(cache, uid, -1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *kinitCredentials) keytabPath(target string) string {
+	return filepath.Join(k.keytabDir, targetHash(target)+KerberosKeytabSuffix)
+}
+
+func (k *kinitCredentials) cachePath(target string, uid int) string {
+	return filepath.Join(k.ccacheDir, fmt.Sprintf("%s%d_%s_%s", KerberosCachePrefix, uid, KerberosCacheOwner, targetHash(target)))
+}
+
+func (k *kinitCredentials) removeDirEntries(logger lager.Logger, dir string, match func(string) bool) {
+	entries, err := k.osshim.ReadDir(dir)
+	if err != nil {
+		if !k.osshim.IsNotExist(err) {
+			logger.Error("read-dir-failed", err, lager.Data{"dir": dir})
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		if !match(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if err := k.osshim.Remove(path); err != nil {
+			logger.Error("remove-failed", err, lager.Data{"path": path})
+		}
+	}
+}
+
+func (k *kinitCredentials) removeFiles(logger lager.Logger, files ...string) {
+	for _, f := range files {
+		if err := k.osshim.Remove(f); err != nil && !k.osshim.IsNotExist(err) {
+			logger.Error("remove-failed", err, lager.Data{"path": f})
+		}
+	}
+}
+
+func (k *kinitCredentials) cachePaths(target string, uids []int) []string {
+	caches := []string{}
+	for _, uid := range uids {
+		caches = append(caches, k.cachePath(target, uid))
+	}
+	return caches
+}
+
+func targetHash(target string) string {
+	sum := sha256.Sum256([]byte(target))
+	return hex.EncodeToString(sum[:])
+}
+
+func validSecurityFlavor(sec string) bool {
+	for _, s := range kerberosSecurityFlavors {
+		if s == sec {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeKeytab(keytab string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(keytab)
+}
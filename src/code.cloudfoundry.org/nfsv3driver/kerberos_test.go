@@ -0,0 +1,270 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/osshim/os_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/volumedriver/invokerfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("KinitCredentials", func() {
+	var (
+		logger           *lagertest.TestLogger
+		env              dockerdriver.Env
+		fakeInvoker      *invokerfakes.FakeInvoker
+		fakeInvokeResult *invokerfakes.FakeInvokeResult
+		fakeOs           *os_fake.FakeOs
+		renewInterval    time.Duration
+		uids             []int
+
+		subject nfsv3driver.KerberosCredentials
+		err     error
+	)
+
+	kinitCaches := func() []string {
+		caches := []string{}
+		for i := 0; i < fakeInvoker.InvokeCallCount(); i++ {
+			_, _, args, _ := fakeInvoker.InvokeArgsForCall(i)
+			caches = append(caches, strings.TrimPrefix(args[4], "FILE:"))
+		}
+		return caches
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("kerberos")
+		env = driverhttp.NewHttpDriverEnv(logger, context.TODO())
+
+		fakeInvoker = &invokerfakes.FakeInvoker{}
+		fakeInvokeResult = &invokerfakes.FakeInvokeResult{}
+		fakeInvoker.InvokeReturns(fakeInvokeResult)
+		fakeOs = &os_fake.FakeOs{}
+		renewInterval = time.Hour
+		uids = []int{0, 2000}
+	})
+
+	JustBeforeEach(func() {
+		subject = nfsv3driver.NewKinitCredentials(fakeInvoker, fakeOs, "/var/vcap/data/krb5", "/tmp", renewInterval)
+		err = subject.Acquire(env, "/some/target", "nfs-user@EXAMPLE.COM", []byte("keytab-bytes"), uids)
+	})
+
+	Context("#Acquire", func() {
+		It("writes a credential cache for each uid where rpc.gssd looks for it", func() {
+			Expect(err).NotTo(HaveOccurred())
+			caches := kinitCaches()
+			Expect(caches).To(HaveLen(2))
+
+			// rpc.gssd picks the caches in its directory whose name starts with krb5cc and contains _<uid>_
+			Expect(filepath.Dir(caches[0])).To(Equal("/tmp"))
+			Expect(filepath.Base(caches[0])).To(MatchRegexp(`^krb5cc_0_nfsv3driver_[0-9a-f]{64}$`))
+			Expect(filepath.Dir(caches[1])).To(Equal("/tmp"))
+			Expect(filepath.Base(caches[1])).To(MatchRegexp(`^krb5cc_2000_nfsv3driver_[0-9a-f]{64}$`))
+		})
+
+		It("gives each credential cache to its uid, because rpc.gssd ignores caches owned by other users", func() {
+			caches := kinitCaches()
+			Expect(fakeOs.ChownCallCount()).To(Equal(2))
+			path, uid, gid := fakeOs.ChownArgsForCall(0)
+			Expect([]interface{}{path, uid, gid}).To(Equal([]interface{}{caches[0], 0, -1}))
+			path, uid, gid = fakeOs.ChownArgsForCall(1)
+			Expect([]interface{}{path, uid, gid}).To(Equal([]interface{}{caches[1], 2000, -1}))
+		})
+
+		It("keeps the credential caches of different targets apart", func() {
+			err := subject.Acquire(env, "/some/other/target", "nfs-user@EXAMPLE.COM", []byte("keytab-bytes"), []int{0})
+			Expect(err).NotTo(HaveOccurred())
+			caches := kinitCaches()
+			Expect(caches).To(HaveLen(3))
+			Expect(caches[2]).To(HavePrefix("/tmp/krb5cc_0_nfsv3driver_"))
+			Expect(caches[2]).NotTo(Equal(caches[0]))
+		})
+
+		Context("when another target uses a uid with a different principal", func() {
+			It("refuses the principal, because rpc.gssd uses any one of the caches of a uid", func() {
+				err := subject.Acquire(env, "/some/other/target", "other-user@EXAMPLE.COM", []byte("other-keytab"), []int{2000})
+				Expect(err).To(MatchError(ContainSubstring("uid 2000 already uses the credentials of another Kerberos principal")))
+				Expect(fakeInvoker.InvokeCallCount()).To(Equal(2))
+				Expect(fakeOs.WriteFileCallCount()).To(Equal(1))
+			})
+
+			It("accepts the principal once the other target has released the uid", func() {
+				subject.Release(env, "/some/target")
+				err := subject.Acquire(env, "/some/other/target", "other-user@EXAMPLE.COM", []byte("other-keytab"), []int{2000})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(kinitCaches()).To(HaveLen(3))
+			})
+		})
+
+		It("writes the keytab to the private keytab directory with restrictive permissions", func() {
+			Expect(fakeOs.MkdirAllCallCount()).To(Equal(1))
+			dir, mode := fakeOs.MkdirAllArgsForCall(0)
+			Expect(dir).To(Equal("/var/vcap/data/krb5"))
+			Expect(mode).To(Equal(os.FileMode(0700)))
+
+			Expect(fakeOs.WriteFileCallCount()).To(Equal(1))
+			path, data, mode := fakeOs.WriteFileArgsForCall(0)
+			Expect(path).To(MatchRegexp(`^/var/vcap/data/krb5/[0-9a-f]{64}\.keytab$`))
+			Expect(data).To(Equal([]byte("keytab-bytes")))
+			Expect(mode).To(Equal(os.FileMode(0600)))
+		})
+
+		It("runs kinit against the keytab", func() {
+			keytab, _, _ := fakeOs.WriteFileArgsForCall(0)
+			Expect(fakeInvoker.InvokeCallCount()).To(Equal(2))
+			_, cmd, args, _ := fakeInvoker.InvokeArgsForCall(0)
+			Expect(cmd).To(Equal("kinit"))
+			Expect(args).To(Equal([]string{"-k", "-t", keytab, "-c", "FILE:" + kinitCaches()[0], "nfs-user@EXAMPLE.COM"}))
+		})
+
+		Context("when kinit fails", func() {
+			BeforeEach(func() {
+				fakeInvokeResult.WaitReturns(errors.New("exit status 1"))
+				fakeInvokeResult.StdErrorReturns("kinit: Client not found in Kerberos database")
+			})
+
+			It("returns the kinit error and removes the caches and the keytab", func() {
+				Expect(err).To(MatchError("kinit: Client not found in Kerberos database"))
+				Expect(fakeOs.RemoveCallCount()).To(Equal(3))
+				removed := []string{fakeOs.RemoveArgsForCall(0), fakeOs.RemoveArgsForCall(1), fakeOs.RemoveArgsForCall(2)}
+				Expect(removed).To(ContainElement(HavePrefix("/tmp/krb5cc_0_nfsv3driver_")))
+				Expect(removed).To(ContainElement(HavePrefix("/tmp/krb5cc_2000_nfsv3driver_")))
+				Expect(removed).To(ContainElement(HaveSuffix(".keytab")))
+			})
+		})
+
+		Context("when a credential cache cannot be given to its uid", func() {
+			BeforeEach(func() {
+				fakeOs.ChownReturns(errors.New("chown-failed"))
+			})
+
+			It("returns the error", func() {
+				Expect(err).To(MatchError("chown-failed"))
+				Expect(fakeOs.RemoveCallCount()).To(Equal(3))
+			})
+		})
+
+		Context("when the keytab directory cannot be created", func() {
+			BeforeEach(func() {
+				fakeOs.MkdirAllReturns(errors.New("mkdir-failed"))
+			})
+
+			It("returns an error without running kinit", func() {
+				Expect(err).To(MatchError("mkdir-failed"))
+				Expect(fakeInvoker.InvokeCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the renew interval elapses", func() {
+			BeforeEach(func() {
+				renewInterval = 10 * time.Millisecond
+				uids = []int{2000}
+			})
+
+			AfterEach(func() {
+				subject.Release(env, "/some/target")
+			})
+
+			It("renews the ticket from the keytab and gives the cache to its uid again", func() {
+				Eventually(fakeInvoker.InvokeCallCount).Should(BeNumerically(">=", 2))
+				caches := kinitCaches()
+				Expect(caches[1]).To(Equal(caches[0]))
+				Eventually(fakeOs.ChownCallCount).Should(BeNumerically(">=", 2))
+				path, uid, _ := fakeOs.ChownArgsForCall(1)
+				Expect(path).To(Equal(caches[0]))
+				Expect(uid).To(Equal(2000))
+			})
+
+			It("waits for a running renewal before removing the caches on release", func() {
+				blocked := make(chan struct{})
+				unblock := make(chan struct{})
+				var once sync.Once
+				blockingResult := &invokerfakes.FakeInvokeResult{}
+				blockingResult.WaitStub = func() error {
+					once.Do(func() { close(blocked) })
+					<-unblock
+					return nil
+				}
+				fakeInvoker.InvokeReturns(blockingResult)
+				Eventually(blocked).Should(BeClosed())
+
+				released := make(chan struct{})
+				go func() {
+					subject.Release(env, "/some/target")
+					close(released)
+				}()
+				Consistently(fakeOs.RemoveCallCount, 50*time.Millisecond).Should(BeZero())
+
+				close(unblock)
+				Eventually(released).Should(BeClosed())
+				Expect(fakeOs.RemoveCallCount()).To(Equal(2))
+				Consistently(fakeInvoker.InvokeCallCount, 50*time.Millisecond).Should(Equal(fakeInvoker.InvokeCallCount()))
+			})
+		})
+	})
+
+	Context("#Release", func() {
+		JustBeforeEach(func() {
+			subject.Release(env, "/some/target")
+		})
+
+		It("removes the credential caches and the keytab", func() {
+			keytab, _, _ := fakeOs.WriteFileArgsForCall(0)
+			Expect(fakeOs.RemoveCallCount()).To(Equal(3))
+			Expect(fakeOs.RemoveArgsForCall(0)).To(Equal(kinitCaches()[0]))
+			Expect(fakeOs.RemoveArgsForCall(1)).To(Equal(kinitCaches()[1]))
+			Expect(fakeOs.RemoveArgsForCall(2)).To(Equal(keytab))
+		})
+
+		Context("when the target has no credentials", func() {
+			It("does nothing", func() {
+				subject.Release(env, "/unknown/target")
+				Expect(fakeOs.RemoveCallCount()).To(Equal(3))
+			})
+		})
+	})
+
+	Context("#ReleaseAll", func() {
+		BeforeEach(func() {
+			fakeOs.ReadDirStub = func(dir string) ([]os.DirEntry, error) {
+				if dir == "/tmp" {
+					return []os.DirEntry{fakeDirEntry("krb5cc_2000_nfsv3driver_abc"), fakeDirEntry("krb5cc_2000"), fakeDirEntry("krb5cc_0_someone_else")}, nil
+				}
+				return []os.DirEntry{fakeDirEntry("abc.keytab")}, nil
+			}
+		})
+
+		JustBeforeEach(func() {
+			subject.ReleaseAll(env)
+		})
+
+		It("removes the keytabs and only the credential caches of the driver", func() {
+			Expect(fakeOs.ReadDirCallCount()).To(Equal(2))
+			Expect(fakeOs.RemoveCallCount()).To(Equal(2))
+			Expect(fakeOs.RemoveArgsForCall(0)).To(Equal("/var/vcap/data/krb5/abc.keytab"))
+			Expect(fakeOs.RemoveArgsForCall(1)).To(Equal("/tmp/krb5cc_2000_nfsv3driver_abc"))
+		})
+
+		It("stops renewing the released tickets", func() {
+			Consistently(fakeInvoker.InvokeCallCount, 50*time.Millisecond).Should(Equal(2))
+			Expect(strings.Join(logger.LogMessages(), " ")).NotTo(ContainSubstring("renew-failed"))
+		})
+	})
+})
+
+type fakeDirEntry string
+
+func (f fakeDirEntry) Name() string               { return string(f) }
+func (f fakeDirEntry) IsDir() bool                { return false }
+func (f fakeDirEntry) Type() os.FileMode          { return 0 }
+func (f fakeDirEntry) Info() (os.FileInfo, error) { return nil, nil }
@@ -0,0 +1,120 @@
+package nfsv3driver
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/goshims/ldapshim"
+)
+
+const DefaultLdapPoolSize = 4
+const DefaultLdapPoolIdleTimeout = time.Minute * 5
+
+// LdapPoolHealthCheckInterval is how long a pooled connection may sit idle before it is checked by
+// binding as the service account again when it is next used.
+var LdapPoolHealthCheckInterval = time.Second * 30
+
+// ldapConnectionPool holds up to size connections bound as the service account. Connections idle for
+// longer than idleTimeout are closed instead of being reused.
+type ldapConnectionPool struct {
+	dial        func(dockerdriver.Env) (ldapshim.LdapConnection, error)
+	healthCheck func(ldapshim.LdapConnection) error
+	idleTimeout time.Duration
+
+	slots chan struct{}
+
+	lock sync.Mutex
+	idle []idleLdapConnection
+}
+
+type idleLdapConnection struct {
+	conn  ldapshim.LdapConnection
+	since time.Time
+}
+
+func newLdapConnectionPool(size int, idleTimeout time.Duration, dial func(dockerdriver.Env) (ldapshim.LdapConnection, error), healthCheck func(ldapshim.LdapConnection) error) *ldapConnectionPool {
+	return &ldapConnectionPool{
+		dial:        dial,
+		healthCheck: healthCheck,
+		idleTimeout: idleTimeout,
+		slots:       make(chan struct{}, size),
+	}
+}
+
+// get returns a connection bound as the service account, waiting for one to be put back when size
+// connections are already in use. Every connection returned by get must be put back.
+func (p *ldapConnectionPool) get(env dockerdriver.Env) (ldapshim.LdapConnection, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-env.Context().Done():
+		return nil, env.Context().Err()
+	}
+
+	for {
+		idle, ok := p.pop()
+		if !ok {
+			break
+		}
+
+		if time.Since(idle.since) > p.idleTimeout {
+			idle.conn.Close()
+			continue
+		}
+
+		if time.Since(idle.since) > LdapPoolHealthCheckInterval {
+			if err := p.healthCheck(idle.conn); err != nil {
+				idle.conn.Close()
+				continue
+			}
+		}
+
+		return idle.conn, nil
+	}
+
+	conn, err := p.dial(env)
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+	return conn, nil
+}
+
+// put returns conn to the pool, or closes it when it is no longer usable.
+func (p *ldapConnectionPool) put(conn ldapshim.LdapConnection, usable bool) {
+	defer func() { <-p.slots }()
+
+	if !usable {
+		conn.Close()
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.idle = append(p.idle, idleLdapConnection{conn: conn, since: time.Now()})
+}
+
+// drain closes every idle connection, for example after the server has dropped one of them.
+func (p *ldapConnectionPool) drain() {
+	p.lock.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.lock.Unlock()
+
+	for _, i := range idle {
+		i.conn.Close()
+	}
+}
+
+// pop returns the most recently used idle connection.
+func (p *ldapConnectionPool) pop() (idleLdapConnection, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if len(p.idle) == 0 {
+		return idleLdapConnection{}, false
+	}
+	idle := p.idle[len(p.idle)-1]
+	p.idle = p.idle[:len(p.idle)-1]
+	return idle, true
+}
@@ -0,0 +1,111 @@
+package nfsv3driver
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/ldap.v2"
+)
+
+const (
+	DefaultLdapUserFilter   = "(&(objectClass=User)(cn={username}))"
+	DefaultLdapUidAttribute = "uidNumber"
+	DefaultLdapGidAttribute = "gidNumber"
+)
+
+// LdapSearch configures how users, and optionally their primary group, are looked up. Filters are
+// templates in which {username} is replaced by the escaped login name, and for group filters {userdn} by
+// the escaped DN of the user entry.
+type LdapSearch struct {
+	UserFilter   string
+	UidAttribute string
+	GidAttribute string
+
+	// GroupFilter, when set, looks up the group whose GroupGidAttribute is the primary gid of the user,
+	// under GroupBaseDN or the base DN of users when GroupBaseDN is empty. Users without such a group
+	// keep the gid of their own entry.
+	GroupFilter       string
+	GroupBaseDN       string
+	GroupGidAttribute string
+
+	// SupplementaryGroupFilter, when set, finds every group the user is a member of under the same base
+	// DN as GroupFilter, such as (&(objectClass=posixGroup)(memberUid={username})). MemberOfAttribute,
+	// when set, names an attribute of the user entry, such as memberOf, listing the DNs of their groups.
+	// The numeric GroupGidAttribute of those groups are the supplementary gids of the user.
+	SupplementaryGroupFilter string
+	MemberOfAttribute        string
+}
+
+// WithLdapSearch looks users up with search instead of by cn among AD User entries. Fields left empty
+// keep their defaults.
+func WithLdapSearch(search LdapSearch) LdapIdResolverOption {
+	return func(d *ldapIdResolver) {
+		d.ldapSearch = search.withDefaults()
+	}
+}
+
+func (s LdapSearch) withDefaults() LdapSearch {
+	if s.UserFilter == "" {
+		s.UserFilter = DefaultLdapUserFilter
+	}
+	if s.UidAttribute == "" {
+		s.UidAttribute = DefaultLdapUidAttribute
+	}
+	if s.GidAttribute == "" {
+		s.GidAttribute = DefaultLdapGidAttribute
+	}
+	if s.GroupGidAttribute == "" {
+		s.GroupGidAttribute = DefaultLdapGidAttribute
+	}
+	return s
+}
+
+// Validate checks that the filters are valid LDAP filters once their placeholders are replaced, and that
+// the user filter depends on the username.
+func (s LdapSearch) Validate() error {
+	s = s.withDefaults()
+
+	if !strings.Contains(s.UserFilter, "{username}") {
+		return fmt.Errorf("LDAP user filter %q does not contain {username}", s.UserFilter)
+	}
+	if _, err := ldap.CompileFilter(s.userFilter("user")); err != nil {
+		return fmt.Errorf("invalid LDAP user filter %q: %s", s.UserFilter, err.Error())
+	}
+
+	if s.GroupFilter != "" {
+		if _, err := ldap.CompileFilter(s.groupFilter("user", "cn=user")); err != nil {
+			return fmt.Errorf("invalid LDAP group filter %q: %s", s.GroupFilter, err.Error())
+		}
+	}
+
+	if s.SupplementaryGroupFilter != "" {
+		if _, err := ldap.CompileFilter(s.supplementaryGroupFilter("user", "cn=user")); err != nil {
+			return fmt.Errorf("invalid LDAP supplementary group filter %q: %s", s.SupplementaryGroupFilter, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (s LdapSearch) userFilter(username string) string {
+	return strings.ReplaceAll(s.UserFilter, "{username}", ldap.EscapeFilter(username))
+}
+
+func (s LdapSearch) groupFilter(username string, userdn string) string {
+	return replacePlaceholders(s.GroupFilter, username, userdn)
+}
+
+func (s LdapSearch) supplementaryGroupFilter(username string, userdn string) string {
+	return replacePlaceholders(s.SupplementaryGroupFilter, username, userdn)
+}
+
+func (s LdapSearch) resolvesSupplementaryGroups() bool {
+	return s.SupplementaryGroupFilter != "" || s.MemberOfAttribute != ""
+}
+
+func replacePlaceholders(filter string, username string, userdn string) string {
+	return strings.NewReplacer(
+		"{username}", ldap.EscapeFilter(username),
+		"{userdn}", ldap.EscapeFilter(userdn),
+	).Replace(filter)
+}
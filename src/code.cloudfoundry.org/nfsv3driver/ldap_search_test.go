@@ -0,0 +1,31 @@
+package nfsv3driver_test
+
+import (
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LdapSearch", func() {
+	Describe("Validate", func() {
+		It("accepts the defaults", func() {
+			Expect(nfsv3driver.LdapSearch{}.Validate()).To(Succeed())
+		})
+
+		It("accepts filters with placeholders", func() {
+			Expect(nfsv3driver.LdapSearch{
+				UserFilter:  "(sAMAccountName={username})",
+				GroupFilter: "(|(memberUid={username})(member={userdn}))",
+			}.Validate()).To(Succeed())
+		})
+
+		It("rejects a user filter without {username}", func() {
+			Expect(nfsv3driver.LdapSearch{UserFilter: "(objectClass=User)"}.Validate()).To(MatchError(ContainSubstring("does not contain {username}")))
+		})
+
+		It("rejects invalid filters", func() {
+			Expect(nfsv3driver.LdapSearch{UserFilter: "(uid={username}"}.Validate()).To(MatchError(ContainSubstring("invalid LDAP user filter")))
+			Expect(nfsv3driver.LdapSearch{GroupFilter: "member={userdn})"}.Validate()).To(MatchError(ContainSubstring("invalid LDAP group filter")))
+		})
+	})
+})
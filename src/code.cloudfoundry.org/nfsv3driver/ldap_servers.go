@@ -0,0 +1,183 @@
+package nfsv3driver
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+const DefaultLdapFailoverCooldown = time.Minute
+
+//counterfeiter:generate -o nfsdriverfakes/fake_srv_resolver.go . SrvResolver
+
+// SrvResolver looks up DNS SRV records; *net.Resolver implements it.
+type SrvResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+type LdapEndpoint struct {
+	Host string
+	Port int
+}
+
+func (e LdapEndpoint) String() string {
+	return net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+}
+
+// ParseLdapEndpoints parses a comma separated list of LDAP servers of the form host, host:port or
+// [ipv6]:port, using defaultPort for the ones without a port.
+func ParseLdapEndpoints(servers string, defaultPort int) ([]LdapEndpoint, error) {
+	var endpoints []LdapEndpoint
+	for _, server := range strings.Split(servers, ",") {
+		server = strings.TrimSpace(server)
+		if server == "" {
+			continue
+		}
+
+		host, port, err := net.SplitHostPort(server)
+		if err != nil {
+			endpoints = append(endpoints, LdapEndpoint{Host: strings.Trim(server, "[]"), Port: defaultPort})
+			continue
+		}
+
+		p, err := strconv.Atoi(port)
+		if err != nil || p <= 0 {
+			return nil, fmt.Errorf("invalid port in LDAP server %q", server)
+		}
+		endpoints = append(endpoints, LdapEndpoint{Host: host, Port: p})
+	}
+	return endpoints, nil
+}
+
+// LdapServers are the LDAP servers to fail over between. Servers that could not be reached are tried
+// after the others until cooldown has passed.
+type LdapServers struct {
+	endpoints []LdapEndpoint
+	resolver  SrvResolver
+	domain    string
+	cooldown  time.Duration
+
+	lock       sync.Mutex
+	discovered []LdapEndpoint
+	down       map[string]time.Time
+}
+
+// NewStaticLdapServers fails over between endpoints in the order given.
+func NewStaticLdapServers(endpoints []LdapEndpoint, cooldown time.Duration) *LdapServers {
+	return &LdapServers{endpoints: endpoints, cooldown: cooldown, down: map[string]time.Time{}}
+}
+
+// NewSrvLdapServers discovers the servers of domain from its _ldap._tcp SRV records, and fails over
+// between them in priority and weight order.
+func NewSrvLdapServers(resolver SrvResolver, domain string, cooldown time.Duration) *LdapServers {
+	return &LdapServers{resolver: resolver, domain: domain, cooldown: cooldown, down: map[string]time.Time{}}
+}
+
+// WithLdapServers fails over between servers instead of only using the host and port of the resolver.
+func WithLdapServers(servers *LdapServers) LdapIdResolverOption {
+	return func(d *ldapIdResolver) {
+		d.servers = servers
+	}
+}
+
+// candidates returns the servers in the order they should be tried.
+func (s *LdapServers) candidates(env dockerdriver.Env) []LdapEndpoint {
+	endpoints := s.endpoints
+	if s.resolver != nil {
+		endpoints = s.discover(env)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	var up, down []LdapEndpoint
+	for _, endpoint := range endpoints {
+		if until, ok := s.down[endpoint.String()]; ok && now.Before(until) {
+			down = append(down, endpoint)
+		} else {
+			up = append(up, endpoint)
+		}
+	}
+	return append(up, down...)
+}
+
+func (s *LdapServers) markDown(endpoint LdapEndpoint) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.down[endpoint.String()] = time.Now().Add(s.cooldown)
+}
+
+func (s *LdapServers) markUp(endpoint LdapEndpoint) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.down, endpoint.String())
+}
+
+// discover looks up the SRV records of the domain, falling back to the servers found last time when
+// the lookup fails.
+func (s *LdapServers) discover(env dockerdriver.Env) []LdapEndpoint {
+	logger := env.Logger().Session("discover-ldap-servers", lager.Data{"domain": s.domain})
+
+	_, records, err := s.resolver.LookupSRV(env.Context(), "ldap", "tcp", s.domain)
+	if err == nil && len(records) == 0 {
+		err = fmt.Errorf("no _ldap._tcp SRV records for %s", s.domain)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err != nil {
+		logger.Error("lookup-srv-failed", err, lager.Data{"using": s.discovered})
+		return s.discovered
+	}
+
+	s.discovered = orderSrvRecords(records)
+	return s.discovered
+}
+
+// orderSrvRecords orders records by priority, and randomly by weight within a priority (RFC 2782).
+func orderSrvRecords(records []*net.SRV) []LdapEndpoint {
+	sorted := append([]*net.SRV{}, records...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Priority < sorted[j].Priority })
+
+	var endpoints []LdapEndpoint
+	for start := 0; start < len(sorted); {
+		end := start
+		for end < len(sorted) && sorted[end].Priority == sorted[start].Priority {
+			end++
+		}
+
+		group := sorted[start:end]
+		for len(group) > 0 {
+			total := 0
+			for _, record := range group {
+				total += int(record.Weight)
+			}
+
+			pick := 0
+			if total > 0 {
+				// #nosec G404 - the order servers are tried in needs no cryptographic randomness
+				n := rand.Intn(total)
+				for pick = 0; n >= int(group[pick].Weight); pick++ {
+					n -= int(group[pick].Weight)
+				}
+			}
+
+			record := group[pick]
+			endpoints = append(endpoints, LdapEndpoint{Host: strings.TrimSuffix(record.Target, "."), Port: int(record.Port)})
+			group = append(group[:pick:pick], group[pick+1:]...)
+		}
+		start = end
+	}
+	return endpoints
+}
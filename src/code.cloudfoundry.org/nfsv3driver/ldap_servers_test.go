@@ -0,0 +1,29 @@
+package nfsv3driver_test
+
+import (
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseLdapEndpoints", func() {
+	It("parses servers with and without ports", func() {
+		endpoints, err := nfsv3driver.ParseLdapEndpoints("dc1, dc2:636,[fd00::1]:3269,fd00::2,", 389)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(endpoints).To(Equal([]nfsv3driver.LdapEndpoint{
+			{Host: "dc1", Port: 389},
+			{Host: "dc2", Port: 636},
+			{Host: "fd00::1", Port: 3269},
+			{Host: "fd00::2", Port: 389},
+		}))
+	})
+
+	It("formats IPv6 endpoints with brackets", func() {
+		Expect(nfsv3driver.LdapEndpoint{Host: "fd00::1", Port: 389}.String()).To(Equal("[fd00::1]:389"))
+	})
+
+	It("rejects invalid ports", func() {
+		_, err := nfsv3driver.ParseLdapEndpoints("dc1:ldap", 389)
+		Expect(err).To(MatchError(ContainSubstring("invalid port")))
+	})
+})
@@ -0,0 +1,60 @@
+package nfsv3driver
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+
+	"code.cloudfoundry.org/goshims/ldapshim"
+)
+
+type LdapTLSMode string
+
+const (
+	// LdapTLSModeNone sends binds in plaintext.
+	LdapTLSModeNone LdapTLSMode = "none"
+	// LdapTLSModeLdaps connects with TLS from the start, usually to port 636.
+	LdapTLSModeLdaps LdapTLSMode = "ldaps"
+	// LdapTLSModeStartTLS upgrades a plaintext connection, usually to port 389, before binding.
+	LdapTLSModeStartTLS LdapTLSMode = "starttls"
+)
+
+// ParseLdapTLSMode parses mode, defaulting to ldaps when a CA certificate is configured and to none
+// otherwise, as the driver did before the mode could be chosen.
+func ParseLdapTLSMode(mode string, caCert string) (LdapTLSMode, error) {
+	switch LdapTLSMode(mode) {
+	case "":
+		if caCert != "" {
+			return LdapTLSModeLdaps, nil
+		}
+		return LdapTLSModeNone, nil
+	case LdapTLSModeNone, LdapTLSModeLdaps, LdapTLSModeStartTLS:
+		return LdapTLSMode(mode), nil
+	default:
+		return "", fmt.Errorf("invalid LDAP TLS mode %q, expected none, ldaps or starttls", mode)
+	}
+}
+
+// WithLdapTLSMode secures connections according to mode, verifying the server certificate against the
+// CA certificate of the resolver, or the system roots when there is none.
+func WithLdapTLSMode(mode LdapTLSMode) LdapIdResolverOption {
+	return func(d *ldapIdResolver) {
+		d.tlsMode = mode
+	}
+}
+
+//counterfeiter:generate -o nfsdriverfakes/fake_start_tls_ldap_connection.go . StartTLSLdapConnection
+
+// StartTLSLdapConnection is an LDAP connection that can be upgraded to TLS; *ldap.Conn implements it.
+type StartTLSLdapConnection interface {
+	ldapshim.LdapConnection
+	StartTLS(config *tls.Config) error
+}
+
+func startTLS(l ldapshim.LdapConnection, config *tls.Config) error {
+	conn, ok := l.(StartTLSLdapConnection)
+	if !ok {
+		return errors.New("LDAP connection does not support StartTLS")
+	}
+	return conn.StartTLS(config)
+}
@@ -0,0 +1,27 @@
+package nfsv3driver_test
+
+import (
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseLdapTLSMode", func() {
+	DescribeTable("valid modes",
+		func(mode string, caCert string, expected nfsv3driver.LdapTLSMode) {
+			parsed, err := nfsv3driver.ParseLdapTLSMode(mode, caCert)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(Equal(expected))
+		},
+		Entry("defaults to none without a CA cert", "", "", nfsv3driver.LdapTLSModeNone),
+		Entry("defaults to ldaps with a CA cert", "", "some-ca-cert", nfsv3driver.LdapTLSModeLdaps),
+		Entry("none", "none", "some-ca-cert", nfsv3driver.LdapTLSModeNone),
+		Entry("ldaps", "ldaps", "", nfsv3driver.LdapTLSModeLdaps),
+		Entry("starttls", "starttls", "some-ca-cert", nfsv3driver.LdapTLSModeStartTLS),
+	)
+
+	It("rejects unknown modes", func() {
+		_, err := nfsv3driver.ParseLdapTLSMode("tls", "")
+		Expect(err).To(MatchError(ContainSubstring("invalid LDAP TLS mode")))
+	})
+})
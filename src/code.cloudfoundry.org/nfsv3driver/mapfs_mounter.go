@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -16,6 +18,7 @@ import (
 	"code.cloudfoundry.org/goshims/osshim"
 	"code.cloudfoundry.org/goshims/syscallshim"
 	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
 	vmo "code.cloudfoundry.org/volume-mount-options"
 	"code.cloudfoundry.org/volumedriver"
 	"code.cloudfoundry.org/volumedriver/invoker"
@@ -28,6 +31,8 @@ const NobodyId = uint32(65534)
 const UnknownId = uint32(4294967294)
 const InvalidUidValueErrorMessage = "Invalid 'uid' option (0, negative, or non-integer)"
 const InvalidGidValueErrorMessage = "Invalid 'gid' option (0, negative, or non-integer)"
+const InvalidSecValueErrorMessage = "Invalid 'sec' option (must be one of sys, krb5, krb5i or krb5p)"
+const KerberosNotConfiguredErrorMessage = "Kerberos credentials are specified but Kerberos is not configured"
 
 type mapfsMounter struct {
 	invoker      invoker.Invoker
@@ -39,12 +44,98 @@ type mapfsMounter struct {
 	resolver     IdResolver
 	mask         vmo.MountOptsMask
 	mapfsPath    string
+	kerberos     KerberosCredentials
+	prober       ExportProber
+	processes    *mapfsProcesses
+	supervisor   *mapfsSupervisor
+	mountSyscall MountSyscall
+	policy       *MountPolicy
+	allowlist    *ServerAllowlist
+	groups       bool
+	audit        AuditLog
+
+	lock            sync.Mutex
+	mounts          map[string]mountRecord
+	unmountFailures map[string]unmountFailure
+	targetLocks     map[string]*targetLock
+	pendingProbes   map[string]*pendingProbe
+}
+
+// targetLock serializes the mounts, remounts and unmounts of a target. It is dropped when nobody holds or
+// waits for it.
+type targetLock struct {
+	sync.Mutex
+	refs int
+}
+
+// pendingProbe is a stat of a mountpoint that has not returned yet.
+type pendingProbe struct {
+	done chan struct{}
+	err  error
+}
+
+type unmountFailure struct {
+	time time.Time
+	err  string
+}
+
+// mountRecord keeps what is needed to mount target again. Volume options are not persisted by the
+// volume driver, so records only live as long as the driver process.
+type mountRecord struct {
+	remote  string
+	opts    map[string]interface{}
+	mapUids uidMapFunc
+	uid     string
+	gid     string
+}
+
+//counterfeiter:generate -o nfsdriverfakes/fake_recoverable_mounter.go . RecoverableMounter
+
+// RecoverableMounter is a Mounter that can replace an unhealthy mount with a fresh one.
+type RecoverableMounter interface {
+	volumedriver.Mounter
+	// Remount lazily unmounts target and mounts it again with the options of its last successful mount.
+	Remount(env dockerdriver.Env, target string) error
+}
+
+type MapfsMounterOption func(*mapfsMounter)
+
+// WithKerberosCredentials enables per-binding Kerberos credentials for sec=krb5* mounts.
+func WithKerberosCredentials(credentials KerberosCredentials) MapfsMounterOption {
+	return func(m *mapfsMounter) {
+		m.kerberos = credentials
+	}
+}
+
+// WithExportProbe checks each share with prober before mounting it.
+func WithExportProbe(prober ExportProber) MapfsMounterOption {
+	return func(m *mapfsMounter) {
+		m.prober = prober
+	}
+}
+
+// WithSupplementaryGroups passes the supplementary gids resolved for LDAP users to mapfs with -groups,
+// so that files shared through those groups are accessible to the app. It requires a mapfs that
+// supports -groups.
+func WithSupplementaryGroups() MapfsMounterOption {
+	return func(m *mapfsMounter) {
+		m.groups = true
+	}
+}
+
+// WithAuditLog records every mount, remount and unmount, successful or not, in audit.
+func WithAuditLog(audit AuditLog) MapfsMounterOption {
+	return func(m *mapfsMounter) {
+		m.audit = audit
+	}
 }
 
 var legacyNfsSharePattern *regexp.Regexp
 
 var PurgeTimeToSleep = time.Millisecond * 100
 
+var CheckTimeout = time.Second * 5
+
 func init() {
 	legacyNfsSharePattern, _ = regexp.Compile("^nfs://([^/]+)(/.*)?$")
 }
@@ -59,15 +150,104 @@ func NewMapfsMounter(
 	resolver IdResolver,
 	mask vmo.MountOptsMask,
 	mapfsPath string,
-) volumedriver.Mounter {
-	return &mapfsMounter{invoker, osshim, syscallshim, mountChecker, fstype, defaultOpts, resolver, mask, mapfsPath}
+	options ...MapfsMounterOption,
+) RecoverableMounter {
+	m := &mapfsMounter{
+		invoker:         invoker,
+		osshim:          osshim,
+		syscallshim:     syscallshim,
+		mountChecker:    mountChecker,
+		fstype:          fstype,
+		defaultOpts:     defaultOpts,
+		resolver:        resolver,
+		mask:            mask,
+		mapfsPath:       mapfsPath,
+		mounts:          map[string]mountRecord{},
+		unmountFailures: map[string]unmountFailure{},
+		targetLocks:     map[string]*targetLock{},
+		pendingProbes:   map[string]*pendingProbe{},
+		processes:       newMapfsProcesses(osshim, syscallshim, mapfsPath),
+	}
+	for _, option := range options {
+		option(m)
+	}
+	for _, target := range m.processes.targets() {
+		m.superviseMapfs(target)
+	}
+	return m
 }
 
+// uidMapFunc presents the kernel mount at source on target with files owned by uid/gid mapped to the app user.
+type uidMapFunc func(env dockerdriver.Env, source string, target string, uid int, gid int, opts vmo.MountOpts) error
+
 func (m *mapfsMounter) Mount(env dockerdriver.Env, remote string, target string, opts map[string]interface{}) error {
+	defer m.lockTarget(target)()
+	return m.auditedMount(env, AuditEventMount, remote, target, opts, m.mapfsMount)
+}
+
+// lockTarget waits until no other mount, remount or unmount of target is running, and returns the function
+// that lets the next one run.
+func (m *mapfsMounter) lockTarget(target string) func() {
+	target = strings.TrimSuffix(target, "/")
+
+	m.lock.Lock()
+	l, ok := m.targetLocks[target]
+	if !ok {
+		l = &targetLock{}
+		m.targetLocks[target] = l
+	}
+	l.refs++
+	m.lock.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		l.refs--
+		if l.refs == 0 {
+			delete(m.targetLocks, target)
+		}
+	}
+}
+
+// auditedMount mounts remote on target and records the mount in the audit log, with the uid and gid
+// resolved for the username into opts by then.
+func (m *mapfsMounter) auditedMount(env dockerdriver.Env, event string, remote string, target string, opts map[string]interface{}, mapUids uidMapFunc) error {
+	start := time.Now()
+	err := m.mount(env, remote, target, opts, mapUids)
+	if m.audit == nil {
+		return safeMountError(err)
+	}
+
+	record := AuditRecord{Event: event, Volume: volumeName(target), Remote: remote, Target: target}
+	if val, ok := opts["uid"]; ok {
+		record.Uid = uniformData(val)
+	}
+	if val, ok := opts["gid"]; ok {
+		record.Gid = uniformData(val)
+	}
+	if val, ok := opts["username"]; ok {
+		record.Username = uniformData(val)
+	}
+	m.audit.Record(env, record.finished(start, err))
+	return safeMountError(err)
+}
+
+// volumeName returns the name of the volume mounted on target, which the volume driver names after it.
+func volumeName(target string) string {
+	return filepath.Base(strings.TrimSuffix(target, "/"))
+}
+
+func (m *mapfsMounter) mount(env dockerdriver.Env, remote string, target string, opts map[string]interface{}, mapUids uidMapFunc) error {
 	logger := env.Logger().Session("mount")
 	logger.Info("mount-start")
 	defer logger.Info("mount-end")
 
+	recordOpts := copyOpts(opts)
+
+	var groups []string
 	if username, ok := opts["username"]; ok {
 		if _, found := opts["uid"]; found {
 			return dockerdriver.SafeError{SafeDescription: "Not allowed options"}
@@ -85,13 +265,16 @@ func (m *mapfsMounter) Mount(env dockerdriver.Env, remote string, target string,
 			return dockerdriver.SafeError{SafeDescription: "LDAP username is specified but LDAP password is missing"}
 		}
 
-		uid, gid, err := m.resolver.Resolve(env, username.(string), password.(string))
+		uid, gid, resolvedGroups, err := m.resolver.Resolve(env, username.(string), password.(string))
 		if err != nil {
 			return err
 		}
 
 		opts["uid"] = uid
 		opts["gid"] = gid
+		if m.groups {
+			groups = resolvedGroups
+		}
 	}
 
 	_, uidok := opts["uid"]
@@ -100,15 +283,33 @@ func (m *mapfsMounter) Mount(env dockerdriver.Env, remote string, target string,
 		return dockerdriver.SafeError{SafeDescription: "required 'gid' option is missing"}
 	}
 
+	// validate the ids before anything is mounted or any credentials are obtained for them
+	var uid, gid int
+	var err error
+	if uidok {
+		uid, err = strconv.Atoi(uniformData(opts["uid"]))
+		if err != nil || uid <= 0 {
+			return dockerdriver.SafeError{SafeDescription: InvalidUidValueErrorMessage}
+		}
+
+		gid, err = strconv.Atoi(uniformData(opts["gid"]))
+		if err != nil || gid <= 0 {
+			return dockerdriver.SafeError{SafeDescription: InvalidGidValueErrorMessage}
+		}
+	}
+
 	optsToUse, err := vmo.NewMountOpts(opts, m.mask)
 	if err != nil {
 		logger.Debug("mount-options-failed", lager.Data{
 			"source":  remote,
 			"target":  target,
-			"options": opts,
+			"options": loggableOpts(opts),
 		})
 		return dockerdriver.SafeError{SafeDescription: err.Error()}
 	}
+	if len(groups) > 0 {
+		optsToUse["groups"] = strings.Join(groups, ",")
+	}
 
 	// check for legacy URL formatted mounts and rewrite to standard nfs format as necessary
 	match := legacyNfsSharePattern.FindStringSubmatch(remote)
@@ -124,6 +325,15 @@ func (m *mapfsMounter) Mount(env dockerdriver.Env, remote string, target string,
 		}
 	}
 
+	if m.allowlist != nil {
+		allowed, err := m.allowlist.Check(env, remote)
+		if err != nil {
+			logger.Error("server-not-allowed", err, lager.Data{"source": remote})
+			return err
+		}
+		remote = allowed
+	}
+
 	target = strings.TrimSuffix(target, "/")
 
 	intermediateMount := target + MapfsDirectorySuffix
@@ -154,6 +364,7 @@ func (m *mapfsMounter) Mount(env dockerdriver.Env, remote string, target string,
 		mountOptions = strings.ReplaceAll(mountOptions, ",actimeo=0", "")
 	}
 
+	nfsVersion := ""
 	if version, ok := opts["version"].(string); ok {
 		versionFloat, err := strconv.ParseFloat(version, 64)
 		if err != nil {
@@ -172,6 +383,51 @@ func (m *mapfsMounter) Mount(env dockerdriver.Env, remote string, target string,
 		}
 
 		mountOptions = mountOptions + ",vers=" + version
+		nfsVersion = version
+	}
+
+	sec := ""
+	if val, ok := opts["sec"]; ok {
+		sec = uniformData(val)
+		if !validSecurityFlavor(sec) {
+			return dockerdriver.SafeError{SafeDescription: InvalidSecValueErrorMessage}
+		}
+		mountOptions = mountOptions + ",sec=" + sec
+	}
+
+	if m.policy != nil {
+		mountOptions, err = m.policy.Apply(mountOptions, nfsVersion)
+		if err != nil {
+			logger.Error("mount-policy-rejected-mount", err)
+			err1 := m.osshim.Remove(intermediateMount)
+			if err1 != nil {
+				logger.Error("remove-failed", err1)
+			}
+			return dockerdriver.SafeError{SafeDescription: err.Error()}
+		}
+	}
+
+	if m.prober != nil {
+		err = m.prober.Probe(env, remote, nfsVersion)
+		if err != nil {
+			logger.Error("probe-export-failed", err)
+			err1 := m.osshim.Remove(intermediateMount)
+			if err1 != nil {
+				logger.Error("remove-failed", err1)
+			}
+			return err
+		}
+	}
+
+	if _, ok := opts["kerberos_principal"]; ok {
+		err := m.acquireKerberosCredentials(env, target, sec, uid, opts)
+		if err != nil {
+			err1 := m.osshim.Remove(intermediateMount)
+			if err1 != nil {
+				logger.Error("remove-failed", err1)
+			}
+			return err
+		}
 	}
 
 	t := intermediateMount
@@ -179,36 +435,20 @@ func (m *mapfsMounter) Mount(env dockerdriver.Env, remote string, target string,
 		t = target
 	}
 
-	err = m.invoker.Invoke(env, "mount", []string{"-t", m.fstype, "-o", mountOptions, remote, t}).Wait()
+	err = m.kernelMount(driverhttp.EnvWithLogger(logger, env), remote, t, mountOptions)
 	if err != nil {
-		logger.Error("invoke-mount-failed", err)
+		m.releaseKerberosCredentials(env, target)
 		err1 := m.osshim.Remove(intermediateMount)
 		if err1 != nil {
 			logger.Error("remove-failed", err1)
 		}
-		return dockerdriver.SafeError{SafeDescription: err.Error()}
+		return err
 	}
 
 	if uidok {
 		// make sure the mapped user has read access to the directory before doing the mapfs mount
 		// this check is best effort--root may not be able to stat the directory, or the server may
 		// anonymize the owner UID.
-		uid, err := strconv.Atoi(uniformData(opts["uid"]))
-		if err != nil {
-			return dockerdriver.SafeError{SafeDescription: InvalidUidValueErrorMessage}
-		}
-		if uid <= 0 {
-			return dockerdriver.SafeError{SafeDescription: InvalidUidValueErrorMessage}
-		}
-
-		gid, err := strconv.Atoi(uniformData(opts["gid"]))
-		if err != nil {
-			return dockerdriver.SafeError{SafeDescription: InvalidGidValueErrorMessage}
-		}
-		if gid <= 0 {
-			return dockerdriver.SafeError{SafeDescription: InvalidGidValueErrorMessage}
-		}
-
 		st := syscall.Stat_t{}
 		err = m.syscallshim.Stat(intermediateMount, &st)
 		if err != nil {
@@ -216,15 +456,16 @@ func (m *mapfsMounter) Mount(env dockerdriver.Env, remote string, target string,
 			err = nil
 		} else {
 			if (st.Mode&04 == 0) &&
-				((uint32(gid) != st.Gid && NobodyId != st.Gid && UnknownId != st.Gid) || st.Mode&040 == 0) &&
+				((!inGroups(st.Gid, gid, groups) && NobodyId != st.Gid && UnknownId != st.Gid) || st.Mode&040 == 0) &&
 				((uint32(uid) != st.Uid && NobodyId != st.Uid && UnknownId != st.Uid) || st.Mode&0400 == 0) {
 				err = errors.New("user lacks read access to share")
 			}
 		}
 		if err != nil {
 			logger.Error("mount-read-access-check-failed", err)
+			m.releaseKerberosCredentials(env, target)
 
-			err1 := m.invoker.Invoke(env, "umount", []string{intermediateMount}).Wait()
+			err1 := m.umount(env, intermediateMount, false, false)
 			if err1 != nil {
 				logger.Error("intermediate-unmount-failed", err1)
 			}
@@ -239,12 +480,11 @@ func (m *mapfsMounter) Mount(env dockerdriver.Env, remote string, target string,
 			return dockerdriver.SafeError{SafeDescription: err.Error()}
 		}
 
-		args := mapfsOptions(optsToUse)
-		args = append(args, target, intermediateMount)
-		mountError := m.invoker.Invoke(env, m.mapfsPath, args).WaitFor("Mounted!", MapfsMountTimeout)
+		mountError := mapUids(env, intermediateMount, target, uid, gid, optsToUse)
 		if mountError != nil {
 			logger.Error("background-invoke-mount-failed", err)
-			err = m.invoker.Invoke(env, "umount", []string{intermediateMount}).Wait()
+			m.releaseKerberosCredentials(env, target)
+			err = m.umount(env, intermediateMount, false, false)
 			if err != nil {
 				logger.Error("unmount-failed", err)
 				return dockerdriver.SafeError{SafeDescription: mountError.Error()}
@@ -261,10 +501,249 @@ func (m *mapfsMounter) Mount(env dockerdriver.Env, remote string, target string,
 
 	}
 
+	m.lock.Lock()
+	m.mounts[target] = mountRecord{remote: remote, opts: recordOpts, mapUids: mapUids, uid: uniformData(opts["uid"]), gid: uniformData(opts["gid"])}
+	m.lock.Unlock()
+
+	return nil
+}
+
+func (m *mapfsMounter) Remount(env dockerdriver.Env, target string) error {
+	logger := env.Logger().Session("remount", lager.Data{"target": target})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	target = strings.TrimSuffix(target, "/")
+	intermediateMount := target + MapfsDirectorySuffix
+
+	defer m.lockTarget(target)()
+
+	m.lock.Lock()
+	record, ok := m.mounts[target]
+	m.lock.Unlock()
+	if !ok {
+		return fmt.Errorf("no mount of %s has been recorded since the driver started", target)
+	}
+
+	m.unsuperviseMapfs(target)
+	err := m.umount(env, target, true, false)
+	if err != nil {
+		logger.Info("umount-failed", lager.Data{"err": err.Error()})
+	}
+	m.stopMapfs(logger, target)
+
+	if exists, _ := m.mountChecker.Exists(intermediateMount); exists {
+		err = m.umount(env, intermediateMount, true, false)
+		if err != nil {
+			logger.Info("umount-intermediate-failed", lager.Data{"err": err.Error()})
+		}
+	}
+
+	m.releaseKerberosCredentials(env, target)
+
+	return m.auditedMount(env, AuditEventRemount, record.remote, target, copyOpts(record.opts), record.mapUids)
+}
+
+// ForceUnmount forcibly and lazily unmounts target and its intermediate mount, so that it does not wait for an
+// NFS server that no longer answers, stops its mapfs process and removes the intermediate directory. Every
+// step is attempted and the first error is returned. The mount stays recorded, so that it can be mounted
+// again in place with Remount.
+func (m *mapfsMounter) ForceUnmount(env dockerdriver.Env, target string) error {
+	defer m.lockTarget(target)()
+	return m.auditedUnmount(env, AuditEventForceUnmount, target, m.forceUnmount)
+}
+
+func (m *mapfsMounter) forceUnmount(env dockerdriver.Env, target string) error {
+	logger := env.Logger().Session("force-unmount", lager.Data{"target": target})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	target = strings.TrimSuffix(target, "/")
+	intermediateMount := target + MapfsDirectorySuffix
+
+	var errs []error
+	m.unsuperviseMapfs(target)
+	if exists, _ := m.mountChecker.Exists(target); exists {
+		err := m.umount(env, target, true, true)
+		if err != nil {
+			logger.Error("umount-failed", err)
+			errs = append(errs, err)
+		}
+	}
+	m.stopMapfs(logger, target)
+	m.forgetMapfsRestarts(target)
+
+	if exists, _ := m.mountChecker.Exists(intermediateMount); exists {
+		err := m.umount(env, intermediateMount, true, true)
+		if err != nil {
+			logger.Error("umount-intermediate-failed", err)
+			errs = append(errs, err)
+		}
+	}
+
+	m.releaseKerberosCredentials(env, target)
+
+	err := m.osshim.Remove(intermediateMount)
+	if err != nil && !m.osshim.IsNotExist(err) {
+		logger.Error("remove-intermediate-failed", err)
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// InspectMount describes the mount on mountpoint. The options and ids are only known for mounts made since the
+// driver started. For older mounts the share is read from /proc/mounts.
+func (m *mapfsMounter) InspectMount(mountpoint string) driveradmin.MountDetails {
+	target := strings.TrimSuffix(mountpoint, "/")
+	details := driveradmin.MountDetails{IntermediateMount: target + MapfsDirectorySuffix}
+
+	m.lock.Lock()
+	record, ok := m.mounts[target]
+	m.lock.Unlock()
+	if ok {
+		details.Source = redactedSource(record.remote)
+		details.Options = map[string]string{}
+		for k, v := range loggableOpts(record.opts) {
+			details.Options[k] = fmt.Sprint(v)
+		}
+		details.Uid = record.uid
+		details.Gid = record.gid
+	} else {
+		details.Source = m.mountedSource(target)
+	}
+
+	if process, ok := m.processes.get(target); ok {
+		details.MapfsPid = process.Pid
+	}
+	details.IntermediateMounted, _ = m.mountChecker.Exists(details.IntermediateMount)
+
+	return details
+}
+
+// mountedSource returns the share listed in /proc/mounts for the intermediate mount of target or, for mounts
+// without mapfs, for target itself, or "" when neither is mounted.
+func (m *mapfsMounter) mountedSource(target string) string {
+	data, err := m.osshim.ReadFile(filepath.Join(procRoot, "mounts"))
+	if err != nil {
+		return ""
+	}
+
+	source := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[2] != m.fstype {
+			continue
+		}
+		if mountpoint := unescapeMountField(fields[1]); mountpoint == target || mountpoint == target+MapfsDirectorySuffix {
+			source = unescapeMountField(fields[0])
+		}
+	}
+	return source
+}
+
+// unescapeMountField decodes the \ooo octal escapes of spaces, tabs, newlines and backslashes in /proc/mounts.
+func unescapeMountField(field string) string {
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if c, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(field[i])
+	}
+	return b.String()
+}
+
+// redactedSource returns remote without the credentials of a user:password@server share.
+func redactedSource(remote string) string {
+	scheme := ""
+	if i := strings.Index(remote, "://"); i >= 0 {
+		scheme, remote = remote[:i+3], remote[i+3:]
+	}
+	if at := strings.Index(remote, "@"); at >= 0 && !strings.ContainsAny(remote[:at], "/") {
+		remote = "REDACTED" + remote[at:]
+	}
+	return scheme + remote
+}
+
+func (m *mapfsMounter) mapfsMount(env dockerdriver.Env, source string, target string, _ int, _ int, opts vmo.MountOpts) error {
+	args := mapfsOptions(opts)
+	args = append(args, target, source)
+	err := m.invoker.Invoke(env, m.mapfsPath, args).WaitFor("Mounted!", MapfsMountTimeout)
+	if err != nil {
+		return err
+	}
+
+	err = m.processes.find(env.Logger(), target, args)
+	if err != nil {
+		env.Logger().Error("find-mapfs-process-failed", err, lager.Data{"target": target})
+	}
+	m.superviseMapfs(target)
 	return nil
 }
 
 func (m *mapfsMounter) Unmount(env dockerdriver.Env, target string) error {
+	unlock := m.lockTarget(target)
+	err := m.auditedUnmount(env, AuditEventUnmount, target, m.unmount)
+	unlock()
+
+	target = strings.TrimSuffix(target, "/")
+	m.lock.Lock()
+	if err != nil {
+		m.unmountFailures[target] = unmountFailure{time: time.Now(), err: err.Error()}
+	} else {
+		delete(m.unmountFailures, target)
+	}
+	m.lock.Unlock()
+
+	return err
+}
+
+// UnmountFailures returns the error of each target whose last unmount, since since, has failed.
+func (m *mapfsMounter) UnmountFailures(since time.Time) map[string]string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	ret := map[string]string{}
+	for target, failure := range m.unmountFailures {
+		if !failure.time.Before(since) {
+			ret[target] = failure.err
+		}
+	}
+	return ret
+}
+
+// auditedUnmount unmounts target with unmount and records the unmount in the audit log, with the share and
+// user of the mount when it has been recorded.
+func (m *mapfsMounter) auditedUnmount(env dockerdriver.Env, event string, target string, unmount func(dockerdriver.Env, string) error) error {
+	start := time.Now()
+
+	m.lock.Lock()
+	record, recorded := m.mounts[strings.TrimSuffix(target, "/")]
+	m.lock.Unlock()
+
+	err := unmount(env, target)
+	if m.audit != nil {
+		auditRecord := AuditRecord{Event: event, Volume: volumeName(target), Target: target}
+		if recorded {
+			auditRecord.Remote = record.remote
+			if val, ok := record.opts["username"]; ok {
+				auditRecord.Username = uniformData(val)
+			}
+		}
+		m.audit.Record(env, auditRecord.finished(start, err))
+	}
+	return err
+}
+
+func (m *mapfsMounter) unmount(env dockerdriver.Env, target string) error {
 	logger := env.Logger().Session("unmount")
 	logger.Info("unmount-start")
 	defer logger.Info("unmount-end")
@@ -272,13 +751,22 @@ func (m *mapfsMounter) Unmount(env dockerdriver.Env, target string) error {
 	target = strings.TrimSuffix(target, "/")
 	intermediateMount := target + MapfsDirectorySuffix
 
-	waitError := m.invoker.Invoke(env, "umount", []string{"-l", target}).Wait()
+	// the supervisor would otherwise restart the mapfs process that exits once its mount is gone
+	m.unsuperviseMapfs(target)
+	waitError := m.umount(env, target, true, false)
 	if waitError != nil {
+		m.superviseMapfs(target)
 		return dockerdriver.SafeError{SafeDescription: waitError.Error()}
 	}
 
+	m.stopMapfs(logger, target)
+	m.forgetMapfsRestarts(target)
+
+	m.releaseKerberosCredentials(env, target)
+	m.forgetMount(target)
+
 	if exists, err := m.mountChecker.Exists(intermediateMount); exists {
-		err = m.invoker.Invoke(env, "umount", []string{"-l", intermediateMount}).Wait()
+		err = m.umount(env, intermediateMount, true, false)
 		if err != nil {
 			logger.Error("warning-umount-intermediate-failed", err)
 			return nil
@@ -303,40 +791,81 @@ func (m *mapfsMounter) Check(env dockerdriver.Env, name, mountPoint string) bool
 	logger.Info("check-start")
 	defer logger.Info("check-end")
 
-	ctx, cancel := context.WithDeadline(context.TODO(), time.Now().Add(time.Second*5))
+	ctx, cancel := context.WithDeadline(context.TODO(), time.Now().Add(CheckTimeout))
 	defer cancel()
 	env = driverhttp.EnvWithContext(ctx, env)
-	err := m.invoker.Invoke(env, "mountpoint", []string{"-q", mountPoint}).Wait()
+	err := m.checkMountpoint(env, mountPoint)
 	if err != nil {
 		logger.Info(fmt.Sprintf("unable to verify volume %s (%s)", name, err.Error()))
 		return false
 	}
+
+	// a mount whose export was recreated or whose server went away still looks like a mountpoint
+	err = m.statMountRoot(ctx, mountPoint)
+	if unhealthyMount(err) {
+		logger.Info(fmt.Sprintf("volume %s is unhealthy (%s)", name, err.Error()))
+		return false
+	}
+	if err != nil {
+		logger.Info("stat-failed", lager.Data{"name": name, "err": err.Error()})
+	}
 	return true
 }
 
+// statMountRoot stats mountPoint, giving up when ctx is done.
+func (m *mapfsMounter) statMountRoot(ctx context.Context, mountPoint string) error {
+	return m.probeMountpoint(ctx, "stat:"+mountPoint, func() error {
+		st := syscall.Stat_t{}
+		return m.syscallshim.Stat(mountPoint, &st)
+	})
+}
+
+// probeMountpoint runs probe in its own goroutine and waits for it until ctx is done. A probe of a hard NFS
+// mount whose server does not answer blocks until the server comes back, so while the probe named key is
+// pending, later probes wait for its result instead of starting another goroutine that would block as well.
+func (m *mapfsMounter) probeMountpoint(ctx context.Context, key string, probe func() error) error {
+	m.lock.Lock()
+	pending, ok := m.pendingProbes[key]
+	if !ok {
+		pending = &pendingProbe{done: make(chan struct{})}
+		m.pendingProbes[key] = pending
+		go func() {
+			err := probe()
+
+			m.lock.Lock()
+			delete(m.pendingProbes, key)
+			pending.err = err
+			m.lock.Unlock()
+			close(pending.done)
+		}()
+	}
+	m.lock.Unlock()
+
+	select {
+	case <-pending.done:
+		return pending.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func unhealthyMount(err error) bool {
+	return errors.Is(err, syscall.ESTALE) ||
+		errors.Is(err, syscall.EIO) ||
+		errors.Is(err, syscall.ENOTCONN) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
 func (m *mapfsMounter) Purge(env dockerdriver.Env, path string) {
 	logger := env.Logger().Session("purge")
 	logger.Info("purge-start")
 	defer logger.Info("purge-end")
 
-	pkillInvokeResult := m.invoker.Invoke(env, "pkill", []string{"mapfs"})
-	err := pkillInvokeResult.Wait()
-	if err != nil {
-		logger.Info("pkill", lager.Data{"err": err.Error(), "output": pkillInvokeResult.StdOutput()})
-	} else {
-		logger.Debug("pkill", lager.Data{"output": pkillInvokeResult.StdOutput()})
-	}
-
-	for i := 0; i < 30; i++ {
-		logger.Info("waiting-for-kill")
-		time.Sleep(PurgeTimeToSleep)
-		invokeResult := m.invoker.Invoke(env, "pgrep", []string{"mapfs"})
-		err = invokeResult.Wait()
-		if err != nil {
-			logger.Info("pgrep", lager.Data{"err": err.Error(), "output": invokeResult.StdOutput()})
-			break
+	for _, target := range m.processes.targets() {
+		if strings.HasPrefix(target, path) {
+			m.stopMapfs(logger, target)
+			m.forgetMapfsRestarts(target)
 		}
-		logger.Debug("pgrep", lager.Data{"output": invokeResult.StdOutput()})
 	}
 
 	mountPattern, err := regexp.Compile("^" + path + ".*" + MapfsDirectorySuffix + "$")
@@ -356,7 +885,7 @@ func (m *mapfsMounter) Purge(env dockerdriver.Env, path string) {
 	for _, mountDir := range mounts {
 		realMountpoint := strings.TrimSuffix(mountDir, MapfsDirectorySuffix)
 
-		err = m.invoker.Invoke(env, "umount", []string{"-l", "-f", realMountpoint}).Wait()
+		err = m.umount(env, realMountpoint, true, true)
 		if err != nil {
 			logger.Error("warning-umount-command-intermediate-failed", err)
 		}
@@ -369,7 +898,7 @@ func (m *mapfsMounter) Purge(env dockerdriver.Env, path string) {
 
 		logger.Info("remove-directory-successful", lager.Data{"path": realMountpoint})
 
-		err = m.invoker.Invoke(env, "umount", []string{"-l", "-f", mountDir}).Wait()
+		err = m.umount(env, mountDir, true, true)
 		if err != nil {
 			logger.Error("warning-umount-mapfs-failed", err)
 		}
@@ -382,10 +911,69 @@ func (m *mapfsMounter) Purge(env dockerdriver.Env, path string) {
 
 		logger.Info("remove-directory-successful", lager.Data{"path": mountDir})
 	}
+
+	if m.kerberos != nil {
+		m.kerberos.ReleaseAll(env)
+	}
+
+	m.lock.Lock()
+	m.mounts = map[string]mountRecord{}
+	m.lock.Unlock()
+}
+
+func (m *mapfsMounter) forgetMount(target string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.mounts, target)
+}
+
+// acquireKerberosCredentials gets tickets for the users the kernel makes NFS requests on target for: root, which
+// makes the mount, and the mapped uid, which mapfs accesses the share as.
+func (m *mapfsMounter) acquireKerberosCredentials(env dockerdriver.Env, target string, sec string, uid int, opts map[string]interface{}) error {
+	if m.kerberos == nil {
+		return dockerdriver.SafeError{SafeDescription: KerberosNotConfiguredErrorMessage}
+	}
+
+	if !strings.HasPrefix(sec, "krb5") {
+		return dockerdriver.SafeError{SafeDescription: "'kerberos_principal' requires 'sec' to be one of krb5, krb5i or krb5p"}
+	}
+
+	principal := uniformData(opts["kerberos_principal"])
+	if principal == "" {
+		return dockerdriver.SafeError{SafeDescription: "Invalid 'kerberos_principal' option"}
+	}
+
+	encodedKeytab, ok := opts["kerberos_keytab"]
+	if !ok {
+		return dockerdriver.SafeError{SafeDescription: "required 'kerberos_keytab' option is missing"}
+	}
+
+	keytab, err := decodeKeytab(uniformData(encodedKeytab))
+	if err != nil || len(keytab) == 0 {
+		return dockerdriver.SafeError{SafeDescription: "Invalid 'kerberos_keytab' option (must be a base64 encoded keytab)"}
+	}
+
+	uids := []int{0}
+	if uid != 0 {
+		uids = append(uids, uid)
+	}
+
+	err = m.kerberos.Acquire(env, target, principal, keytab, uids)
+	if err != nil {
+		return dockerdriver.SafeError{SafeDescription: fmt.Sprintf("unable to obtain Kerberos credentials for %s: %s", principal, err.Error())}
+	}
+
+	return nil
+}
+
+func (m *mapfsMounter) releaseKerberosCredentials(env dockerdriver.Env, target string) {
+	if m.kerberos != nil {
+		m.kerberos.Release(env, target)
+	}
 }
 
 func NewMapFsVolumeMountMask() (vmo.MountOptsMask, error) {
-	allowed := []string{"auto_cache", "mount", "source", "experimental", "uid", "gid", "username", "password", "readonly", "version", "cache"}
+	allowed := []string{"auto_cache", "mount", "source", "experimental", "uid", "gid", "username", "password", "readonly", "version", "cache", "sec", "kerberos_principal", "kerberos_keytab"}
 
 	defaultMap := map[string]interface{}{
 		"auto_cache": "true",
@@ -413,6 +1001,26 @@ func uniformData(data interface{}) string {
 	return ""
 }
 
+// loggableOpts returns a copy of opts without values that must never be written to the logs.
+func loggableOpts(opts map[string]interface{}) map[string]interface{} {
+	ret := map[string]interface{}{}
+	for k, v := range opts {
+		if k == "password" || k == "kerberos_keytab" {
+			continue
+		}
+		ret[k] = v
+	}
+	return ret
+}
+
+func copyOpts(opts map[string]interface{}) map[string]interface{} {
+	ret := make(map[string]interface{}, len(opts))
+	for k, v := range opts {
+		ret[k] = v
+	}
+	return ret
+}
+
 func mapfsOptions(opts vmo.MountOpts) []string {
 	var ret []string
 	if uid, ok := opts["uid"]; ok {
@@ -421,8 +1029,24 @@ func mapfsOptions(opts vmo.MountOpts) []string {
 	if gid, ok := opts["gid"]; ok {
 		ret = append(ret, "-gid", uniformData(gid))
 	}
+	if groups, ok := opts["groups"]; ok {
+		ret = append(ret, "-groups", uniformData(groups))
+	}
 	if _, ok := opts["auto_cache"]; ok {
 		ret = append(ret, "-auto_cache")
 	}
 	return ret
 }
+
+// inGroups reports whether fileGid is gid or one of the supplementary groups.
+func inGroups(fileGid uint32, gid int, groups []string) bool {
+	if fileGid == uint32(gid) {
+		return true
+	}
+	for _, group := range groups {
+		if group == strconv.FormatUint(uint64(fileGid), 10) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,335 @@
+package nfsv3driver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/goshims/osshim"
+	"code.cloudfoundry.org/goshims/syscallshim"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+const procRoot = "/proc"
+
+// MapfsProcess is a mapfs process started by this driver.
+type MapfsProcess struct {
+	Pid  int      `json:"pid"`
+	Pgid int      `json:"pgid"`
+	Args []string `json:"args,omitempty"`
+}
+
+// mapfsProcesses records the mapfs process serving each target, so that the driver only ever signals
+// the processes it started. When path is set the records are persisted there and survive a restart of
+// the driver.
+type mapfsProcesses struct {
+	osshim      osshim.Os
+	syscallshim syscallshim.Syscall
+	mapfsPath   string
+	path        string
+
+	lock      sync.Mutex
+	processes map[string]MapfsProcess
+
+	// persistLock keeps the registry writes in the order of the changes they record
+	persistLock sync.Mutex
+}
+
+func newMapfsProcesses(osshim osshim.Os, syscallshim syscallshim.Syscall, mapfsPath string) *mapfsProcesses {
+	return &mapfsProcesses{
+		osshim:      osshim,
+		syscallshim: syscallshim,
+		mapfsPath:   mapfsPath,
+		processes:   map[string]MapfsProcess{},
+	}
+}
+
+// WithMapfsProcessRegistry persists the mapfs processes started by the mounter in path, and restores the
+// ones that are still running from a previous run of the driver.
+func WithMapfsProcessRegistry(logger lager.Logger, path string) MapfsMounterOption {
+	return func(m *mapfsMounter) {
+		m.processes.path = path
+		m.processes.restore(logger)
+	}
+}
+
+// find looks for the mapfs process that was started with args and records it for target.
+func (p *mapfsProcesses) find(logger lager.Logger, target string, args []string) error {
+	entries, err := p.osshim.ReadDir(procRoot)
+	if err != nil {
+		return err
+	}
+
+	want := append([]string{p.mapfsPath}, args...)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		argv, err := p.cmdline(pid)
+		if err != nil || !slices.Equal(argv, want) {
+			continue
+		}
+
+		pgid := p.pgid(logger, pid)
+
+		p.lock.Lock()
+		p.processes[target] = MapfsProcess{Pid: pid, Pgid: pgid, Args: args}
+		p.lock.Unlock()
+
+		logger.Info("mapfs-process-recorded", lager.Data{"target": target, "pid": pid, "pgid": pgid})
+		p.persist(logger)
+		return nil
+	}
+
+	return fmt.Errorf("no mapfs process serving %s was found", target)
+}
+
+// lost records that the mapfs process serving target has exited without a replacement being found. The
+// arguments are kept so that it can be started again.
+func (p *mapfsProcesses) lost(logger lager.Logger, target string) {
+	p.lock.Lock()
+	process, ok := p.processes[target]
+	if ok {
+		p.processes[target] = MapfsProcess{Args: process.Args}
+	}
+	p.lock.Unlock()
+
+	if ok {
+		p.persist(logger)
+	}
+}
+
+// stop signals the mapfs process serving target and waits for it to exit.
+func (p *mapfsProcesses) stop(logger lager.Logger, target string) {
+	p.lock.Lock()
+	process, ok := p.processes[target]
+	delete(p.processes, target)
+	p.lock.Unlock()
+
+	if !ok {
+		return
+	}
+	defer p.persist(logger)
+
+	logger = logger.Session("stop-mapfs", lager.Data{"target": target, "pid": process.Pid})
+
+	if !p.owns(process.Pid, target) {
+		logger.Info("process-no-longer-mapfs")
+		return
+	}
+
+	err := p.signal(process, syscall.SIGTERM)
+	if err != nil {
+		logger.Info("sigterm-failed", lager.Data{"err": err.Error()})
+	}
+
+	for i := 0; i < 30; i++ {
+		if !p.alive(process.Pid) {
+			logger.Info("exited")
+			return
+		}
+		logger.Info("waiting-for-exit")
+		time.Sleep(PurgeTimeToSleep)
+	}
+
+	err = p.signal(process, syscall.SIGKILL)
+	if err != nil {
+		logger.Info("sigkill-failed", lager.Data{"err": err.Error()})
+	}
+}
+
+func (p *mapfsProcesses) get(target string) (MapfsProcess, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	process, ok := p.processes[target]
+	return process, ok
+}
+
+// targets returns the targets with a recorded mapfs process.
+func (p *mapfsProcesses) targets() []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var ret []string
+	for target := range p.processes {
+		ret = append(ret, target)
+	}
+	return ret
+}
+
+func (p *mapfsProcesses) restore(logger lager.Logger) {
+	logger = logger.Session("restore-mapfs-processes", lager.Data{"path": p.path})
+
+	data, err := p.osshim.ReadFile(p.path)
+	if err != nil {
+		logger.Info("no-registry", lager.Data{"err": err.Error()})
+		return
+	}
+
+	var processes map[string]MapfsProcess
+	err = json.Unmarshal(data, &processes)
+	if err != nil {
+		logger.Error("invalid-registry", err)
+		p.scan(logger)
+		return
+	}
+
+	p.lock.Lock()
+	for target, process := range processes {
+		// the pid may have been reused by an unrelated process since the registry was written
+		if !p.owns(process.Pid, target) {
+			logger.Info("dropping-exited-process", lager.Data{"target": target, "pid": process.Pid})
+			continue
+		}
+		p.processes[target] = process
+	}
+	logger.Info("restored", lager.Data{"processes": p.processes})
+	p.lock.Unlock()
+
+	p.persist(logger)
+}
+
+// scan records every running mapfs process for the target it serves. It stands in for a registry that
+// cannot be read back.
+func (p *mapfsProcesses) scan(logger lager.Logger) {
+	entries, err := p.osshim.ReadDir(procRoot)
+	if err != nil {
+		logger.Error("list-processes-failed", err)
+		return
+	}
+
+	p.lock.Lock()
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		argv, err := p.cmdline(pid)
+		if err != nil {
+			continue
+		}
+		target, ok := p.mapfsTarget(argv)
+		if !ok {
+			continue
+		}
+
+		p.processes[target] = MapfsProcess{Pid: pid, Pgid: p.pgid(logger, pid), Args: argv[1:]}
+	}
+	logger.Info("scanned", lager.Data{"processes": p.processes})
+	p.lock.Unlock()
+
+	p.persist(logger)
+}
+
+func (p *mapfsProcesses) persist(logger lager.Logger) {
+	if p.path == "" {
+		return
+	}
+
+	p.persistLock.Lock()
+	defer p.persistLock.Unlock()
+
+	p.lock.Lock()
+	data, err := json.Marshal(p.processes)
+	p.lock.Unlock()
+	if err != nil {
+		logger.Error("marshal-mapfs-processes-failed", err)
+		return
+	}
+
+	err = p.writeRegistry(data)
+	if err != nil {
+		logger.Error("persist-mapfs-processes-failed", err, lager.Data{"path": p.path})
+	}
+}
+
+// writeRegistry replaces the registry with data through a synced temporary file, so that a crash of the
+// driver or the cell leaves either the previous registry or the new one.
+func (p *mapfsProcesses) writeRegistry(data []byte) error {
+	tmp := p.path + ".tmp"
+	f, err := p.osshim.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = p.syscallshim.Fsync(int(f.Fd()))
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = p.osshim.Rename(tmp, p.path)
+	}
+
+	if err != nil {
+		_ = p.osshim.Remove(tmp)
+	}
+	return err
+}
+
+// owns reports whether pid is still the mapfs process serving target.
+func (p *mapfsProcesses) owns(pid int, target string) bool {
+	argv, err := p.cmdline(pid)
+	if err != nil {
+		return false
+	}
+	mapfsTarget, ok := p.mapfsTarget(argv)
+	return ok && mapfsTarget == target
+}
+
+// mapfsTarget returns the target served by the process started with argv, if it is a mapfs process.
+func (p *mapfsProcesses) mapfsTarget(argv []string) (string, bool) {
+	if len(argv) < 3 || argv[0] != p.mapfsPath {
+		return "", false
+	}
+	// mapfs is invoked with the target and the intermediate mount as its last two arguments
+	return argv[len(argv)-2], true
+}
+
+func (p *mapfsProcesses) pgid(logger lager.Logger, pid int) int {
+	pgid, err := p.syscallshim.Getpgid(pid)
+	if err != nil {
+		logger.Error("getpgid-failed", err, lager.Data{"pid": pid})
+		return 0
+	}
+	return pgid
+}
+
+func (p *mapfsProcesses) cmdline(pid int) ([]string, error) {
+	data, err := p.osshim.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return nil, err
+	}
+
+	var argv []string
+	for _, arg := range bytes.Split(bytes.TrimSuffix(data, []byte{0}), []byte{0}) {
+		argv = append(argv, string(arg))
+	}
+	return argv, nil
+}
+
+func (p *mapfsProcesses) signal(process MapfsProcess, signal syscall.Signal) error {
+	// the invoker starts mapfs in a process group of its own; signal the group only when that still holds
+	if process.Pgid == process.Pid {
+		return p.syscallshim.Kill(-process.Pgid, signal)
+	}
+	return p.syscallshim.Kill(process.Pid, signal)
+}
+
+func (p *mapfsProcesses) alive(pid int) bool {
+	return !errors.Is(p.syscallshim.Kill(pid, 0), syscall.ESRCH)
+}
@@ -0,0 +1,209 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"syscall"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/osshim"
+	"code.cloudfoundry.org/goshims/osshim/os_fake"
+	"code.cloudfoundry.org/goshims/syscallshim/syscall_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/volumedriver/invokerfakes"
+	nfsfakes "code.cloudfoundry.org/volumedriver/volumedriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("MapfsProcessRegistry", func() {
+	const (
+		registryPath = "/var/vcap/data/volumes/nfs/mapfs-processes.json"
+		mapfsPath    = "/var/vcap/packages/mapfs/bin/mapfs"
+	)
+
+	var (
+		logger           *lagertest.TestLogger
+		env              dockerdriver.Env
+		fakeInvoker      *invokerfakes.FakeInvoker
+		fakeOs           *os_fake.FakeOs
+		fakeSyscall      *syscall_fake.FakeSyscall
+		fakeMountChecker *nfsfakes.FakeMountChecker
+		registry         []byte
+		cmdlines         map[string]string
+		written          [][]byte
+		subject          nfsv3driver.RecoverableMounter
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("mapfs-processes")
+		env = driverhttp.NewHttpDriverEnv(logger, context.TODO())
+
+		fakeInvoker = &invokerfakes.FakeInvoker{}
+		fakeInvoker.InvokeReturns(&invokerfakes.FakeInvokeResult{})
+		fakeOs = &os_fake.FakeOs{}
+		fakeSyscall = &syscall_fake.FakeSyscall{}
+		fakeSyscall.StatStub = func(path string, st *syscall.Stat_t) error {
+			st.Mode = 0777
+			return nil
+		}
+		fakeSyscall.KillStub = func(_ int, signal syscall.Signal) error {
+			if signal == 0 {
+				return syscall.ESRCH
+			}
+			return nil
+		}
+		fakeMountChecker = &nfsfakes.FakeMountChecker{}
+
+		written = nil
+		fakeOs.OpenFileStub = func(string, int, os.FileMode) (osshim.File, error) {
+			file := &os_fake.FakeFile{}
+			file.FdReturns(42)
+			file.WriteStub = func(data []byte) (int, error) {
+				written = append(written, data)
+				return len(data), nil
+			}
+			return file, nil
+		}
+
+		var err error
+		registry, err = json.Marshal(map[string]nfsv3driver.MapfsProcess{
+			"/mounts/alive":  {Pid: 100, Pgid: 100},
+			"/mounts/reused": {Pid: 200, Pgid: 200},
+			"/mounts/exited": {Pid: 300, Pgid: 300},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		cmdlines = map[string]string{
+			"/proc/100/cmdline": mapfsPath + "\x00-uid\x002000\x00-gid\x002000\x00/mounts/alive\x00/mounts/alive_mapfs\x00",
+			"/proc/200/cmdline": "/usr/sbin/sshd\x00-D\x00",
+		}
+		fakeOs.ReadFileStub = func(name string) ([]byte, error) {
+			if name == registryPath {
+				return registry, nil
+			}
+			if cmdline, ok := cmdlines[name]; ok {
+				return []byte(cmdline), nil
+			}
+			return nil, os.ErrNotExist
+		}
+	})
+
+	JustBeforeEach(func() {
+		mask, err := nfsv3driver.NewMapFsVolumeMountMask()
+		Expect(err).NotTo(HaveOccurred())
+
+		subject = nfsv3driver.NewMapfsMounter(fakeInvoker, fakeOs, fakeSyscall, fakeMountChecker, "nfs", "", nil, mask, mapfsPath,
+			nfsv3driver.WithMapfsProcessRegistry(logger, registryPath))
+	})
+
+	It("only restores processes that are still the mapfs for their target", func() {
+		Expect(written).To(HaveLen(1))
+		Expect(written[0]).To(MatchJSON(`{"/mounts/alive": {"pid": 100, "pgid": 100}}`))
+	})
+
+	It("replaces the registry with a synced temporary file", func() {
+		Expect(fakeOs.OpenFileCallCount()).To(Equal(1))
+		path, flag, perm := fakeOs.OpenFileArgsForCall(0)
+		Expect(path).To(Equal(registryPath + ".tmp"))
+		Expect(flag).To(Equal(os.O_WRONLY | os.O_CREATE | os.O_TRUNC))
+		Expect(perm).To(Equal(os.FileMode(0600)))
+
+		Expect(fakeSyscall.FsyncCallCount()).To(Equal(1))
+		Expect(fakeSyscall.FsyncArgsForCall(0)).To(Equal(42))
+
+		Expect(fakeOs.RenameCallCount()).To(Equal(1))
+		from, to := fakeOs.RenameArgsForCall(0)
+		Expect(from).To(Equal(registryPath + ".tmp"))
+		Expect(to).To(Equal(registryPath))
+		Expect(fakeOs.WriteFileCallCount()).To(BeZero())
+	})
+
+	Context("when the temporary file cannot be synced", func() {
+		BeforeEach(func() {
+			fakeSyscall.FsyncReturns(errors.New("fsync-failed"))
+		})
+
+		It("keeps the previous registry", func() {
+			Expect(fakeOs.RenameCallCount()).To(BeZero())
+			Expect(fakeOs.RemoveCallCount()).To(Equal(1))
+			Expect(fakeOs.RemoveArgsForCall(0)).To(Equal(registryPath + ".tmp"))
+			Expect(logger.Buffer()).To(gbytes.Say("persist-mapfs-processes-failed"))
+		})
+	})
+
+	It("signals restored processes when their target is unmounted", func() {
+		Expect(subject.Unmount(env, "/mounts/alive")).To(Succeed())
+
+		pid, signal := fakeSyscall.KillArgsForCall(0)
+		Expect(pid).To(Equal(-100))
+		Expect(signal).To(Equal(syscall.SIGTERM))
+
+		Expect(written[len(written)-1]).To(MatchJSON(`{}`))
+	})
+
+	It("does not signal processes whose pid was reused", func() {
+		Expect(subject.Unmount(env, "/mounts/reused")).To(Succeed())
+		Expect(fakeSyscall.KillCallCount()).To(BeZero())
+	})
+
+	It("records new mapfs processes", func() {
+		fakeOs.ReadDirReturns([]os.DirEntry{fakeDirEntry("100"), fakeDirEntry("400")}, nil)
+		cmdlines["/proc/400/cmdline"] = mapfsPath + "\x00-uid\x002000\x00-gid\x002000\x00-auto_cache\x00/mounts/new\x00/mounts/new_mapfs\x00"
+		fakeSyscall.GetpgidReturns(400, nil)
+
+		Expect(subject.Mount(env, "server:/export", "/mounts/new", map[string]interface{}{"uid": "2000", "gid": "2000"})).To(Succeed())
+
+		Expect(written[len(written)-1]).To(MatchJSON(`{"/mounts/alive": {"pid": 100, "pgid": 100}, "/mounts/new": {"pid": 400, "pgid": 400, "args": ["-uid", "2000", "-gid", "2000", "-auto_cache", "/mounts/new", "/mounts/new_mapfs"]}}`))
+	})
+
+	Context("when the registry cannot be read", func() {
+		BeforeEach(func() {
+			fakeOs.ReadFileReturns(nil, errors.New("no such file"))
+			fakeOs.ReadFileStub = nil
+		})
+
+		It("starts empty", func() {
+			Expect(written).To(BeEmpty())
+			Expect(logger.Buffer()).To(gbytes.Say("no-registry"))
+		})
+	})
+
+	Context("when the registry is corrupt", func() {
+		BeforeEach(func() {
+			registry = []byte("{")
+		})
+
+		It("replaces it with the running mapfs processes", func() {
+			Expect(logger.Buffer()).To(gbytes.Say("invalid-registry"))
+			Expect(written).To(HaveLen(1))
+			Expect(written[0]).To(MatchJSON(`{}`))
+		})
+
+		Context("and mapfs processes are running", func() {
+			BeforeEach(func() {
+				fakeOs.ReadDirReturns([]os.DirEntry{fakeDirEntry("self"), fakeDirEntry("100"), fakeDirEntry("200"), fakeDirEntry("300")}, nil)
+				fakeSyscall.GetpgidReturns(100, nil)
+			})
+
+			It("records them for the targets they serve", func() {
+				Expect(fakeOs.ReadDirArgsForCall(0)).To(Equal("/proc"))
+				Expect(written).To(HaveLen(1))
+				Expect(written[0]).To(MatchJSON(`{"/mounts/alive": {"pid": 100, "pgid": 100, "args": ["-uid", "2000", "-gid", "2000", "/mounts/alive", "/mounts/alive_mapfs"]}}`))
+			})
+
+			It("signals them when their target is unmounted", func() {
+				Expect(subject.Unmount(env, "/mounts/alive")).To(Succeed())
+
+				pid, signal := fakeSyscall.KillArgsForCall(0)
+				Expect(pid).To(Equal(-100))
+				Expect(signal).To(Equal(syscall.SIGTERM))
+			})
+		})
+	})
+})
@@ -0,0 +1,212 @@
+package nfsv3driver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+const DefaultMapfsSupervisionInterval = time.Second * 5
+const DefaultMapfsRestartMaxBackoff = time.Minute * 5
+
+// mapfsSupervisor restarts mapfs processes that exit while their volume is still mounted. A dead mapfs
+// leaves its FUSE mountpoint failing with "Transport endpoint is not connected" until it is replaced.
+type mapfsSupervisor struct {
+	logger     lager.Logger
+	interval   time.Duration
+	maxBackoff time.Duration
+
+	lock     sync.Mutex
+	watchers map[string]*mapfsWatcher
+	restarts map[string]int
+}
+
+type mapfsWatcher struct {
+	// lock is held while the process is being restarted, so that stopping the watcher waits for a
+	// restart in progress instead of racing with it.
+	lock    sync.Mutex
+	stopped bool
+	stop    chan struct{}
+}
+
+// WithMapfsSupervision checks the mapfs process of every mapfs mount each interval, and restarts the
+// ones that have exited, backing off exponentially up to maxBackoff while restarts keep failing.
+func WithMapfsSupervision(logger lager.Logger, interval time.Duration, maxBackoff time.Duration) MapfsMounterOption {
+	return func(m *mapfsMounter) {
+		m.supervisor = &mapfsSupervisor{
+			logger:     logger.Session("mapfs-supervisor"),
+			interval:   interval,
+			maxBackoff: maxBackoff,
+			watchers:   map[string]*mapfsWatcher{},
+			restarts:   map[string]int{},
+		}
+	}
+}
+
+// MapfsRestarts returns how many times the mapfs process of each target has been restarted.
+func (m *mapfsMounter) MapfsRestarts() map[string]int {
+	ret := map[string]int{}
+	if m.supervisor == nil {
+		return ret
+	}
+
+	m.supervisor.lock.Lock()
+	defer m.supervisor.lock.Unlock()
+	for target, count := range m.supervisor.restarts {
+		ret[target] = count
+	}
+	return ret
+}
+
+// forgetMapfsRestarts drops the restart count of target once its volume is unmounted.
+func (m *mapfsMounter) forgetMapfsRestarts(target string) {
+	if m.supervisor == nil {
+		return
+	}
+
+	m.supervisor.lock.Lock()
+	defer m.supervisor.lock.Unlock()
+	delete(m.supervisor.restarts, target)
+}
+
+func (m *mapfsMounter) stopMapfs(logger lager.Logger, target string) {
+	m.unsuperviseMapfs(target)
+	m.processes.stop(logger, target)
+}
+
+func (m *mapfsMounter) superviseMapfs(target string) {
+	if m.supervisor == nil {
+		return
+	}
+
+	m.supervisor.lock.Lock()
+	defer m.supervisor.lock.Unlock()
+	if _, ok := m.supervisor.watchers[target]; ok {
+		return
+	}
+
+	watcher := &mapfsWatcher{stop: make(chan struct{})}
+	m.supervisor.watchers[target] = watcher
+	go m.watchMapfs(target, watcher)
+}
+
+func (m *mapfsMounter) unsuperviseMapfs(target string) {
+	if m.supervisor == nil {
+		return
+	}
+
+	m.supervisor.lock.Lock()
+	watcher, ok := m.supervisor.watchers[target]
+	delete(m.supervisor.watchers, target)
+	m.supervisor.lock.Unlock()
+
+	if !ok {
+		return
+	}
+
+	watcher.lock.Lock()
+	defer watcher.lock.Unlock()
+	watcher.stopped = true
+	close(watcher.stop)
+}
+
+var errMapfsUnsupervised = errors.New("mapfs is no longer supervised")
+
+func (m *mapfsMounter) watchMapfs(target string, watcher *mapfsWatcher) {
+	logger := m.supervisor.logger.Session("watch", lager.Data{"target": target})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	wait := m.supervisor.interval
+	backoff := m.supervisor.interval
+	for {
+		select {
+		case <-watcher.stop:
+			return
+		case <-time.After(wait):
+		}
+		wait = m.supervisor.interval
+
+		process, ok := m.processes.get(target)
+		if !ok {
+			logger.Info("mapfs-no-longer-recorded")
+			m.unsuperviseMapfs(target)
+			return
+		}
+
+		if m.processes.owns(process.Pid, target) {
+			backoff = m.supervisor.interval
+			continue
+		}
+
+		err := m.restartMapfs(logger, target, process, watcher)
+		if errors.Is(err, errMapfsUnsupervised) {
+			return
+		}
+		if err != nil {
+			logger.Error("restart-failed", err, lager.Data{"retry-in": backoff.String()})
+			wait = backoff
+			backoff = min(backoff*2, m.supervisor.maxBackoff)
+			continue
+		}
+		backoff = m.supervisor.interval
+	}
+}
+
+func (m *mapfsMounter) restartMapfs(logger lager.Logger, target string, process MapfsProcess, watcher *mapfsWatcher) error {
+	// taken before the watcher lock, in the same order as Unmount, Remount and ForceUnmount, which stop the
+	// watcher while they hold the target lock
+	defer m.lockTarget(target)()
+	watcher.lock.Lock()
+	defer watcher.lock.Unlock()
+	if watcher.stopped {
+		return errMapfsUnsupervised
+	}
+
+	logger = logger.Session("restart", lager.Data{"pid": process.Pid})
+	logger.Info("start")
+	defer logger.Info("end")
+
+	if len(process.Args) == 0 {
+		return fmt.Errorf("the arguments of mapfs process %d were not recorded", process.Pid)
+	}
+
+	env := driverhttp.NewHttpDriverEnv(logger, context.Background())
+
+	err := m.umount(env, target, true, false)
+	if err != nil {
+		logger.Info("umount-failed", lager.Data{"err": err.Error()})
+	}
+
+	intermediateMount := target + MapfsDirectorySuffix
+	exists, err := m.mountChecker.Exists(intermediateMount)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%s is no longer mounted", intermediateMount)
+	}
+
+	err = m.invoker.Invoke(env, m.mapfsPath, process.Args).WaitFor("Mounted!", MapfsMountTimeout)
+	if err != nil {
+		return err
+	}
+	err = m.processes.find(logger, target, process.Args)
+	if err != nil {
+		m.processes.lost(logger, target)
+		return err
+	}
+
+	m.supervisor.lock.Lock()
+	m.supervisor.restarts[target]++
+	count := m.supervisor.restarts[target]
+	m.supervisor.lock.Unlock()
+
+	logger.Info("mapfs-restarted", lager.Data{"restarts": count})
+	return nil
+}
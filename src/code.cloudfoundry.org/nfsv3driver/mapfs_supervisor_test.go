@@ -0,0 +1,230 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/osshim/os_fake"
+	"code.cloudfoundry.org/goshims/syscallshim/syscall_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+	"code.cloudfoundry.org/volumedriver/invoker"
+	"code.cloudfoundry.org/volumedriver/invokerfakes"
+	nfsfakes "code.cloudfoundry.org/volumedriver/volumedriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("MapfsSupervision", func() {
+	const mapfsPath = "/var/vcap/packages/mapfs/bin/mapfs"
+
+	var (
+		logger           *lagertest.TestLogger
+		env              dockerdriver.Env
+		fakeInvoker      *invokerfakes.FakeInvoker
+		fakeOs           *os_fake.FakeOs
+		fakeSyscall      *syscall_fake.FakeSyscall
+		fakeMountChecker *nfsfakes.FakeMountChecker
+		dead             atomic.Bool
+		failRestart      atomic.Bool
+		lostRestart      atomic.Bool
+		exitOnUmount     atomic.Bool
+		subject          nfsv3driver.RecoverableMounter
+	)
+
+	mapfsInvocations := func() [][]string {
+		var ret [][]string
+		for i := 0; i < fakeInvoker.InvokeCallCount(); i++ {
+			_, cmd, args, _ := fakeInvoker.InvokeArgsForCall(i)
+			if cmd == mapfsPath {
+				ret = append(ret, args)
+			}
+		}
+		return ret
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("mapfs-supervisor")
+		env = driverhttp.NewHttpDriverEnv(logger, context.TODO())
+
+		dead.Store(false)
+		failRestart.Store(false)
+		lostRestart.Store(false)
+		exitOnUmount.Store(false)
+		fakeInvoker = &invokerfakes.FakeInvoker{}
+		fakeInvoker.InvokeStub = func(_ dockerdriver.Env, cmd string, _ []string, _ ...string) invoker.InvokeResult {
+			result := &invokerfakes.FakeInvokeResult{}
+			if cmd == mapfsPath {
+				if failRestart.Load() {
+					result.WaitForReturns(errors.New("command timed out"))
+				} else if !lostRestart.Load() {
+					dead.Store(false)
+				}
+			}
+			if cmd == "umount" && exitOnUmount.Load() {
+				// mapfs exits once its FUSE mount is gone, while umount has yet to return
+				dead.Store(true)
+				time.Sleep(20 * time.Millisecond)
+			}
+			return result
+		}
+
+		fakeOs = &os_fake.FakeOs{}
+		fakeOs.ReadDirReturns([]os.DirEntry{fakeDirEntry("1234")}, nil)
+		fakeOs.ReadFileStub = func(name string) ([]byte, error) {
+			invocations := mapfsInvocations()
+			if dead.Load() || name != "/proc/1234/cmdline" || len(invocations) == 0 {
+				return nil, os.ErrNotExist
+			}
+			args := invocations[len(invocations)-1]
+			return []byte(strings.Join(append([]string{mapfsPath}, args...), "\x00")), nil
+		}
+
+		fakeSyscall = &syscall_fake.FakeSyscall{}
+		fakeSyscall.GetpgidReturns(1234, nil)
+		fakeSyscall.StatStub = func(path string, st *syscall.Stat_t) error {
+			st.Mode = 0777
+			return nil
+		}
+		fakeSyscall.KillStub = func(_ int, signal syscall.Signal) error {
+			if signal == 0 {
+				return syscall.ESRCH
+			}
+			return nil
+		}
+
+		fakeMountChecker = &nfsfakes.FakeMountChecker{}
+		fakeMountChecker.ExistsReturns(true, nil)
+
+		mask, err := nfsv3driver.NewMapFsVolumeMountMask()
+		Expect(err).NotTo(HaveOccurred())
+
+		subject = nfsv3driver.NewMapfsMounter(fakeInvoker, fakeOs, fakeSyscall, fakeMountChecker, "nfs", "", nil, mask, mapfsPath,
+			nfsv3driver.WithMapfsSupervision(logger, time.Millisecond, 4*time.Millisecond))
+
+		Expect(subject.Mount(env, "server:/export", "/mounts/target", map[string]interface{}{"uid": "2000", "gid": "2000"})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(subject.Unmount(env, "/mounts/target")).To(Succeed())
+	})
+
+	restarts := func() map[string]int {
+		return subject.(interface{ MapfsRestarts() map[string]int }).MapfsRestarts()
+	}
+
+	It("leaves a running mapfs alone", func() {
+		Consistently(func() int { return len(mapfsInvocations()) }, 20*time.Millisecond).Should(Equal(1))
+		Expect(restarts()).To(BeEmpty())
+	})
+
+	Context("when mapfs exits", func() {
+		JustBeforeEach(func() {
+			dead.Store(true)
+		})
+
+		It("lazily unmounts the dead FUSE mount and restarts mapfs with the same arguments", func() {
+			Eventually(mapfsInvocations).Should(HaveLen(2))
+			invocations := mapfsInvocations()
+			Expect(invocations[1]).To(Equal(invocations[0]))
+
+			Eventually(restarts).Should(Equal(map[string]int{"/mounts/target": 1}))
+			Expect(logger.Buffer()).To(gbytes.Say("mapfs-restarted"))
+
+			_, cmd, args, _ := fakeInvoker.InvokeArgsForCall(fakeInvoker.InvokeCallCount() - 2)
+			Expect(cmd).To(Equal("umount"))
+			Expect(args).To(Equal([]string{"-l", "/mounts/target"}))
+		})
+
+		It("forgets the restarts once the volume is unmounted", func() {
+			Eventually(restarts).Should(Equal(map[string]int{"/mounts/target": 1}))
+			Expect(subject.Unmount(env, "/mounts/target")).To(Succeed())
+			Expect(restarts()).To(BeEmpty())
+		})
+
+		It("forgets the restarts once the volume is force-unmounted", func() {
+			Eventually(restarts).Should(Equal(map[string]int{"/mounts/target": 1}))
+			Expect(subject.(driveradmin.MountRecoverer).ForceUnmount(env, "/mounts/target")).To(Succeed())
+			Expect(restarts()).To(BeEmpty())
+		})
+
+		Context("when mapfs fails to start again", func() {
+			BeforeEach(func() {
+				failRestart.Store(true)
+			})
+
+			It("keeps retrying with a backoff", func() {
+				Eventually(func() int { return len(mapfsInvocations()) }).Should(BeNumerically(">=", 4))
+				Expect(logger.Buffer()).To(gbytes.Say("restart-failed"))
+				Expect(restarts()).To(BeEmpty())
+
+				failRestart.Store(false)
+				Eventually(restarts).Should(Equal(map[string]int{"/mounts/target": 1}))
+			})
+		})
+
+		Context("when the restarted mapfs cannot be found", func() {
+			BeforeEach(func() {
+				lostRestart.Store(true)
+			})
+
+			It("forgets the dead process and keeps retrying with a backoff", func() {
+				Eventually(func() int { return len(mapfsInvocations()) }).Should(BeNumerically(">=", 4))
+				Expect(logger.Buffer()).To(gbytes.Say("no mapfs process serving /mounts/target was found"))
+				Expect(restarts()).To(BeEmpty())
+
+				details := subject.(interface {
+					InspectMount(string) driveradmin.MountDetails
+				}).InspectMount("/mounts/target")
+				Expect(details.MapfsPid).To(BeZero())
+
+				lostRestart.Store(false)
+				Eventually(restarts).Should(Equal(map[string]int{"/mounts/target": 1}))
+			})
+		})
+
+		Context("when the intermediate mount is gone", func() {
+			BeforeEach(func() {
+				fakeMountChecker.ExistsReturns(false, nil)
+			})
+
+			It("does not start mapfs over an empty directory", func() {
+				Eventually(logger.Buffer()).Should(gbytes.Say("no longer mounted"))
+				Expect(mapfsInvocations()).To(HaveLen(1))
+			})
+		})
+	})
+
+	Context("when the volume is unmounted", func() {
+		It("stops supervising it", func() {
+			Expect(subject.Unmount(env, "/mounts/target")).To(Succeed())
+			dead.Store(true)
+
+			Consistently(func() int { return len(mapfsInvocations()) }, 20*time.Millisecond).Should(Equal(1))
+		})
+
+		It("stops supervising it before unmounting, so that mapfs exiting is not mistaken for a crash", func() {
+			exitOnUmount.Store(true)
+			Expect(subject.Unmount(env, "/mounts/target")).To(Succeed())
+			Expect(mapfsInvocations()).To(HaveLen(1))
+		})
+	})
+
+	Context("when the volume is remounted", func() {
+		It("does not restart the mapfs process it stops", func() {
+			exitOnUmount.Store(true)
+			Expect(subject.Remount(env, "/mounts/target")).To(Succeed())
+			Expect(mapfsInvocations()).To(HaveLen(2))
+			Expect(restarts()).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,128 @@
+package nfsv3driver
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"code.cloudfoundry.org/dockerdriver"
+)
+
+type MountErrorCode string
+
+const (
+	MountErrorAccessDenied         MountErrorCode = "NFS_ACCESS_DENIED"
+	MountErrorNoSuchExport         MountErrorCode = "NFS_NO_SUCH_EXPORT"
+	MountErrorUnknownHost          MountErrorCode = "NFS_UNKNOWN_HOST"
+	MountErrorServerUnreachable    MountErrorCode = "NFS_SERVER_UNREACHABLE"
+	MountErrorRPCTimeout           MountErrorCode = "NFS_RPC_TIMEOUT"
+	MountErrorProtocolNotSupported MountErrorCode = "NFS_PROTOCOL_NOT_SUPPORTED"
+	MountErrorStatdNotRunning      MountErrorCode = "NFS_STATD_NOT_RUNNING"
+	MountErrorUnknown              MountErrorCode = "NFS_MOUNT_FAILED"
+)
+
+type mountErrorClass struct {
+	pattern *regexp.Regexp
+	code    MountErrorCode
+	message string
+}
+
+// mountErrorClasses are matched in order against the stderr of mount.nfs; the first match wins.
+var mountErrorClasses = []mountErrorClass{
+	{
+		regexp.MustCompile(`(?i)rpc\.statd is not running`),
+		MountErrorStatdNotRunning,
+		"rpc.statd is not running on the cell, so NFSv3 locking is unavailable",
+	},
+	{
+		regexp.MustCompile(`(?i)access denied by server|permission denied|operation not permitted`),
+		MountErrorAccessDenied,
+		"access denied by the NFS server; check that the export allows this client",
+	},
+	{
+		regexp.MustCompile(`(?i)no such file or directory|no such export|bad export path`),
+		MountErrorNoSuchExport,
+		"the NFS server does not export the requested share",
+	},
+	{
+		regexp.MustCompile(`(?i)failed to resolve server|name or service not known|temporary failure in name resolution`),
+		MountErrorUnknownHost,
+		"the NFS server name could not be resolved",
+	},
+	{
+		regexp.MustCompile(`(?i)protocol not supported|version .*not supported|requested nfs version or transport protocol is not supported|program not registered`),
+		MountErrorProtocolNotSupported,
+		"the NFS server does not support the requested protocol version",
+	},
+	{
+		regexp.MustCompile(`(?i)timed out|rpc: timeout`),
+		MountErrorRPCTimeout,
+		"timed out waiting for the NFS server to respond",
+	},
+	{
+		regexp.MustCompile(`(?i)connection refused|no route to host|network is unreachable|unable to receive|port mapper failure`),
+		MountErrorServerUnreachable,
+		"the NFS server could not be reached",
+	},
+}
+
+// MountError is a mount failure classified with a MountErrorCode. Its SafeError is what the volume driver hands
+// to its clients, with the code in front of the description so that they can group failures.
+type MountError struct {
+	dockerdriver.SafeError
+	Code MountErrorCode
+}
+
+func NewMountError(code MountErrorCode, message string) MountError {
+	return MountError{
+		SafeError: dockerdriver.SafeError{SafeDescription: fmt.Sprintf("[%s] %s", code, message)},
+		Code:      code,
+	}
+}
+
+func (e MountError) Unwrap() error {
+	return e.SafeError
+}
+
+// MountErrorCodeOf returns the code of the MountError in err's chain, or "" if there is none.
+func MountErrorCodeOf(err error) MountErrorCode {
+	var mountError MountError
+	if errors.As(err, &mountError) {
+		return mountError.Code
+	}
+	return ""
+}
+
+// safeMountError returns the SafeError of a MountError, because the volume driver only passes on the
+// descriptions of errors that are a dockerdriver.SafeError.
+func safeMountError(err error) error {
+	var mountError MountError
+	if errors.As(err, &mountError) {
+		return mountError.SafeError
+	}
+	return err
+}
+
+// classifyMountError maps a failed mount.nfs invocation onto a MountErrorCode using its stderr.
+func classifyMountError(err error, stderr string) MountError {
+	detail := strings.TrimSpace(stderr)
+	if detail == "" {
+		detail = err.Error()
+	}
+
+	for _, class := range mountErrorClasses {
+		if class.pattern.MatchString(detail) {
+			return NewMountError(class.code, fmt.Sprintf("%s (%s)", class.message, firstLine(detail)))
+		}
+	}
+
+	return NewMountError(MountErrorUnknown, firstLine(detail))
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return strings.TrimSpace(s[:i])
+	}
+	return s
+}
@@ -0,0 +1,83 @@
+package nfsv3driver_test
+
+import (
+	"errors"
+	"fmt"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MountErrors", func() {
+	Context("NewMountError", func() {
+		It("carries the code and a safe description that starts with it", func() {
+			err := nfsv3driver.NewMountError(nfsv3driver.MountErrorRPCTimeout, "timed out")
+			Expect(err.Code).To(Equal(nfsv3driver.MountErrorRPCTimeout))
+			Expect(err.Error()).To(Equal("[NFS_RPC_TIMEOUT] timed out"))
+
+			var safeError dockerdriver.SafeError
+			Expect(errors.As(err, &safeError)).To(BeTrue())
+			Expect(safeError.SafeDescription).To(Equal("[NFS_RPC_TIMEOUT] timed out"))
+		})
+	})
+
+	Context("MountErrorCodeOf", func() {
+		It("returns the code of a mount error", func() {
+			err := nfsv3driver.NewMountError(nfsv3driver.MountErrorRPCTimeout, "timed out")
+			Expect(nfsv3driver.MountErrorCodeOf(err)).To(Equal(nfsv3driver.MountErrorRPCTimeout))
+		})
+
+		It("returns the code of a wrapped mount error", func() {
+			err := fmt.Errorf("probe: %w", nfsv3driver.NewMountError(nfsv3driver.MountErrorAccessDenied, "denied"))
+			Expect(nfsv3driver.MountErrorCodeOf(err)).To(Equal(nfsv3driver.MountErrorAccessDenied))
+		})
+
+		It("returns an empty code for other errors", func() {
+			Expect(nfsv3driver.MountErrorCodeOf(errors.New("exit status 32"))).To(BeEmpty())
+			Expect(nfsv3driver.MountErrorCodeOf(dockerdriver.SafeError{SafeDescription: "[NFS_RPC_TIMEOUT] looks like one"})).To(BeEmpty())
+			Expect(nfsv3driver.MountErrorCodeOf(nil)).To(BeEmpty())
+		})
+	})
+
+	Context("classifyMountError", func() {
+		DescribeTable("classifies the stderr of mount.nfs", func(stderr string, code nfsv3driver.MountErrorCode, description string) {
+			err := nfsv3driver.ClassifyMountError(errors.New("exit status 32"), stderr)
+			Expect(err.Code).To(Equal(code))
+			Expect(err.Error()).To(Equal(description))
+		},
+			Entry("statd not running", "mount.nfs: rpc.statd is not running but is required for remote locking.\nmount.nfs: Either use '-o nolock' to keep locks local, or start statd.",
+				nfsv3driver.MountErrorStatdNotRunning, "[NFS_STATD_NOT_RUNNING] rpc.statd is not running on the cell, so NFSv3 locking is unavailable (mount.nfs: rpc.statd is not running but is required for remote locking.)"),
+			Entry("access denied", "mount.nfs: access denied by server while mounting server:/export",
+				nfsv3driver.MountErrorAccessDenied, "[NFS_ACCESS_DENIED] access denied by the NFS server; check that the export allows this client (mount.nfs: access denied by server while mounting server:/export)"),
+			Entry("operation not permitted", "mount.nfs: Operation not permitted",
+				nfsv3driver.MountErrorAccessDenied, "[NFS_ACCESS_DENIED] access denied by the NFS server; check that the export allows this client (mount.nfs: Operation not permitted)"),
+			Entry("no such export", "mount.nfs: mounting server:/missing failed, reason given by server: No such file or directory",
+				nfsv3driver.MountErrorNoSuchExport, "[NFS_NO_SUCH_EXPORT] the NFS server does not export the requested share (mount.nfs: mounting server:/missing failed, reason given by server: No such file or directory)"),
+			Entry("unknown host", "mount.nfs: Failed to resolve server nowhere: Name or service not known",
+				nfsv3driver.MountErrorUnknownHost, "[NFS_UNKNOWN_HOST] the NFS server name could not be resolved (mount.nfs: Failed to resolve server nowhere: Name or service not known)"),
+			Entry("protocol not supported", "mount.nfs: Protocol not supported",
+				nfsv3driver.MountErrorProtocolNotSupported, "[NFS_PROTOCOL_NOT_SUPPORTED] the NFS server does not support the requested protocol version (mount.nfs: Protocol not supported)"),
+			Entry("rpc timeout", "mount.nfs: Connection timed out",
+				nfsv3driver.MountErrorRPCTimeout, "[NFS_RPC_TIMEOUT] timed out waiting for the NFS server to respond (mount.nfs: Connection timed out)"),
+			Entry("connection refused", "mount.nfs: Connection refused",
+				nfsv3driver.MountErrorServerUnreachable, "[NFS_SERVER_UNREACHABLE] the NFS server could not be reached (mount.nfs: Connection refused)"),
+			Entry("port mapper failure", "mount.nfs: portmap query failed: RPC: Port mapper failure - Unable to receive",
+				nfsv3driver.MountErrorServerUnreachable, "[NFS_SERVER_UNREACHABLE] the NFS server could not be reached (mount.nfs: portmap query failed: RPC: Port mapper failure - Unable to receive)"),
+			Entry("anything else", "mount.nfs: an incorrect mount option was specified\nmore detail",
+				nfsv3driver.MountErrorUnknown, "[NFS_MOUNT_FAILED] mount.nfs: an incorrect mount option was specified"),
+		)
+
+		It("falls back to the error when there is no stderr", func() {
+			err := nfsv3driver.ClassifyMountError(errors.New("exit status 32"), "  \n")
+			Expect(err.Code).To(Equal(nfsv3driver.MountErrorUnknown))
+			Expect(err.Error()).To(Equal("[NFS_MOUNT_FAILED] exit status 32"))
+		})
+
+		It("applies the first matching class", func() {
+			err := nfsv3driver.ClassifyMountError(errors.New("exit status 32"), "mount.nfs: Connection timed out: Connection refused")
+			Expect(err.Code).To(Equal(nfsv3driver.MountErrorRPCTimeout))
+		})
+	})
+})
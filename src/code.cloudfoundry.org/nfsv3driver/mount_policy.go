@@ -0,0 +1,160 @@
+package nfsv3driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/goshims/osshim"
+)
+
+// MountPolicy holds the kernel mount options an operator enforces on every NFS mount made by the driver.
+// It only governs the NFS mount: mapfs already mounts its FUSE filesystem with nosuid and nodev.
+type MountPolicy struct {
+	// Forced options are added to every mount, replacing any option of the same name, so that "nosuid"
+	// also replaces "suid".
+	Forced []string `json:"forced"`
+	// Forbidden options cause a mount to be rejected. An entry without a value, such as "sec", forbids
+	// the option whatever its value.
+	Forbidden []string `json:"forbidden"`
+	// VersionDefaults are added to mounts of an NFS version ("3", "4.1", ...) unless an option of the
+	// same name is already set. The DefaultVersion entry applies to mounts that request no version and
+	// leave it to the server and client to negotiate.
+	VersionDefaults map[string][]string `json:"version_defaults"`
+}
+
+// DefaultVersion is the VersionDefaults key of mounts that request no NFS version.
+const DefaultVersion = "default"
+
+// LoadMountPolicy reads a MountPolicy from the JSON file at path.
+func LoadMountPolicy(os osshim.Os, path string) (MountPolicy, error) {
+	var policy MountPolicy
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return policy, err
+	}
+
+	err = json.Unmarshal(data, &policy)
+	if err != nil {
+		return policy, fmt.Errorf("invalid mount policy %s: %s", path, err.Error())
+	}
+
+	return policy, policy.Validate()
+}
+
+// Validate checks that every version is a number or DefaultVersion, that every option is a single mount
+// option and that no forced option is forbidden.
+func (p MountPolicy) Validate() error {
+	for version := range p.VersionDefaults {
+		if n, err := strconv.ParseFloat(version, 64); version != DefaultVersion && (err != nil || n <= 0) {
+			return fmt.Errorf("invalid NFS version %q in mount policy, expected a version such as \"3\" or %q", version, DefaultVersion)
+		}
+	}
+
+	options := append(append([]string{}, p.Forced...), p.Forbidden...)
+	for _, defaults := range p.VersionDefaults {
+		options = append(options, defaults...)
+	}
+	for _, option := range options {
+		if option == "" || strings.ContainsAny(option, ", ") {
+			return fmt.Errorf("invalid mount option %q in mount policy", option)
+		}
+	}
+
+	for _, option := range p.Forced {
+		if forbidden, ok := p.forbidden(option); ok {
+			return fmt.Errorf("mount option %q is both forced and forbidden (%q) by the mount policy", option, forbidden)
+		}
+	}
+
+	return nil
+}
+
+// WithMountPolicy enforces policy on the options of every NFS mount.
+func WithMountPolicy(policy MountPolicy) MapfsMounterOption {
+	return func(m *mapfsMounter) {
+		m.policy = &policy
+	}
+}
+
+// Apply returns options, a mount(8) option string for a mount of the given NFS version, with the policy
+// applied, or an error naming the first forbidden option. Without a version, the one set by a vers option
+// is used, and the DefaultVersion defaults apply when options set none either.
+func (p MountPolicy) Apply(options string, version string) (string, error) {
+	var merged []string
+	for _, option := range strings.Split(options, ",") {
+		if option != "" {
+			merged = append(merged, option)
+		}
+	}
+
+	if version == "" {
+		version = DefaultVersion
+		if i := indexOfOption(merged, "vers="); i >= 0 {
+			version = optionValue(merged[i])
+		}
+	}
+
+	for _, option := range p.VersionDefaults[version] {
+		if indexOfOption(merged, option) < 0 {
+			merged = append(merged, option)
+		}
+	}
+
+	for _, option := range merged {
+		if _, ok := p.forbidden(option); ok {
+			return "", fmt.Errorf("mount option %q is not allowed by the operator's mount policy", option)
+		}
+	}
+
+	for _, option := range p.Forced {
+		for i := indexOfOption(merged, option); i >= 0; i = indexOfOption(merged, option) {
+			merged = append(merged[:i], merged[i+1:]...)
+		}
+		merged = append(merged, option)
+	}
+
+	return strings.Join(merged, ","), nil
+}
+
+func (p MountPolicy) forbidden(option string) (string, bool) {
+	for _, forbidden := range p.Forbidden {
+		if strings.Contains(forbidden, "=") {
+			if optionName(option) == optionName(forbidden) && optionValue(option) == optionValue(forbidden) {
+				return forbidden, true
+			}
+		} else if option == forbidden || (strings.Contains(option, "=") && optionName(option) == optionName(forbidden)) {
+			return forbidden, true
+		}
+	}
+	return "", false
+}
+
+// indexOfOption returns the index of the first of options with the same name as option, or -1.
+func indexOfOption(options []string, option string) int {
+	for i, o := range options {
+		if optionName(o) == optionName(option) {
+			return i
+		}
+	}
+	return -1
+}
+
+// optionName returns the name of a mount option, so that "vers=3" and "nfsvers=4.1" or "suid" and
+// "nosuid" have the same name.
+func optionName(option string) string {
+	if name, _, ok := strings.Cut(option, "="); ok {
+		if name == "nfsvers" {
+			return "vers"
+		}
+		return name
+	}
+	return strings.TrimPrefix(option, "no")
+}
+
+func optionValue(option string) string {
+	_, value, _ := strings.Cut(option, "=")
+	return value
+}
@@ -0,0 +1,121 @@
+package nfsv3driver_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/goshims/osshim/os_fake"
+	"code.cloudfoundry.org/nfsv3driver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MountPolicy", func() {
+	var policy nfsv3driver.MountPolicy
+
+	BeforeEach(func() {
+		policy = nfsv3driver.MountPolicy{
+			Forced:    []string{"nosuid", "nodev"},
+			Forbidden: []string{"suid", "dev", "exec", "sec", "vers=2"},
+			VersionDefaults: map[string][]string{
+				"3":       {"nolock", "timeo=100"},
+				"4.1":     {"nconnect=4"},
+				"default": {"retrans=3"},
+			},
+		}
+	})
+
+	DescribeTable("Apply",
+		func(options string, version string, expected string) {
+			result, err := policy.Apply(options, version)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(expected))
+		},
+		Entry("appends the forced options", "hard,timeo=600", "3", "hard,timeo=600,nolock,nosuid,nodev"),
+		Entry("replaces options of the same name as a forced option", "hard,nodev,noexec,timeo=600", "3", "hard,noexec,timeo=600,nolock,nosuid,nodev"),
+		Entry("adds the defaults of the version", "hard,vers=3", "3", "hard,vers=3,nolock,timeo=100,nosuid,nodev"),
+		Entry("keeps options that override a version default", "hard,timeo=600,vers=3", "3", "hard,timeo=600,vers=3,nolock,nosuid,nodev"),
+		Entry("ignores the defaults of other versions", "hard,vers=4.1", "4.1", "hard,vers=4.1,nconnect=4,nosuid,nodev"),
+		Entry("adds the default defaults without a version", "hard", "", "hard,retrans=3,nosuid,nodev"),
+		Entry("takes the version of a vers option without a version", "hard,nfsvers=3", "", "hard,nfsvers=3,nolock,timeo=100,nosuid,nodev"),
+	)
+
+	DescribeTable("Apply with forbidden options",
+		func(options string, rejected string) {
+			_, err := policy.Apply(options, "")
+			Expect(err).To(MatchError(`mount option "` + rejected + `" is not allowed by the operator's mount policy`))
+		},
+		Entry("rejects a forbidden flag", "hard,suid", "suid"),
+		Entry("rejects a forbidden option whatever its value", "hard,sec=krb5", "sec=krb5"),
+		Entry("rejects a forbidden option value", "hard,vers=2", "vers=2"),
+		Entry("rejects a forbidden option value under another name", "hard,nfsvers=2", "nfsvers=2"),
+	)
+
+	It("allows the negation of a forbidden flag", func() {
+		result, err := policy.Apply("hard,noexec", "4.1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal("hard,noexec,nconnect=4,nosuid,nodev"))
+	})
+
+	Context("Validate", func() {
+		It("accepts a consistent policy", func() {
+			Expect(policy.Validate()).To(Succeed())
+		})
+
+		It("rejects options that are both forced and forbidden", func() {
+			policy.Forced = append(policy.Forced, "sec=sys")
+			Expect(policy.Validate()).To(MatchError(ContainSubstring(`"sec=sys" is both forced and forbidden ("sec")`)))
+		})
+
+		It("rejects versions that are neither a number nor default", func() {
+			policy.VersionDefaults["v3"] = []string{"nolock"}
+			Expect(policy.Validate()).To(MatchError(ContainSubstring(`invalid NFS version "v3" in mount policy`)))
+		})
+
+		It("rejects entries holding more than one option", func() {
+			policy.Forced = []string{"nosuid,nodev"}
+			Expect(policy.Validate()).To(MatchError(ContainSubstring("invalid mount option")))
+		})
+	})
+
+	Context("LoadMountPolicy", func() {
+		var fakeOs *os_fake.FakeOs
+
+		BeforeEach(func() {
+			fakeOs = &os_fake.FakeOs{}
+		})
+
+		It("reads the policy from a JSON file", func() {
+			fakeOs.ReadFileReturns([]byte(`{"forced":["nosuid"],"forbidden":["suid"],"version_defaults":{"3":["nolock"]}}`), nil)
+
+			loaded, err := nfsv3driver.LoadMountPolicy(fakeOs, "/var/vcap/jobs/nfsv3driver/config/mount_policy.json")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeOs.ReadFileArgsForCall(0)).To(Equal("/var/vcap/jobs/nfsv3driver/config/mount_policy.json"))
+			Expect(loaded).To(Equal(nfsv3driver.MountPolicy{
+				Forced:          []string{"nosuid"},
+				Forbidden:       []string{"suid"},
+				VersionDefaults: map[string][]string{"3": {"nolock"}},
+			}))
+		})
+
+		It("fails when the file cannot be read", func() {
+			fakeOs.ReadFileReturns(nil, errors.New("no such file"))
+
+			_, err := nfsv3driver.LoadMountPolicy(fakeOs, "/missing.json")
+			Expect(err).To(MatchError("no such file"))
+		})
+
+		It("fails when the file is not valid", func() {
+			fakeOs.ReadFileReturns([]byte(`{"forced":"nosuid"}`), nil)
+
+			_, err := nfsv3driver.LoadMountPolicy(fakeOs, "/invalid.json")
+			Expect(err).To(MatchError(ContainSubstring("invalid mount policy /invalid.json")))
+		})
+
+		It("fails when the policy is inconsistent", func() {
+			fakeOs.ReadFileReturns([]byte(`{"forced":["suid"],"forbidden":["suid"]}`), nil)
+
+			_, err := nfsv3driver.LoadMountPolicy(fakeOs, "/inconsistent.json")
+			Expect(err).To(MatchError(ContainSubstring("both forced and forbidden")))
+		})
+	})
+})
@@ -0,0 +1,102 @@
+package nfsv3driver
+
+import (
+	"errors"
+	"net"
+	"syscall"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+var ErrNotMountpoint = errors.New("not a mountpoint")
+
+//counterfeiter:generate -o nfsdriverfakes/fake_mount_syscall.go . MountSyscall
+
+// MountSyscall is the part of syscallshim.Syscall needed to mount without exec'ing mount(8), together
+// with the mount(2) and umount2(2) calls that syscallshim does not wrap.
+type MountSyscall interface {
+	Mount(source string, target string, fstype string, flags uintptr, data string) error
+	Unmount(target string, flags int) error
+	Statfs(path string, buf *syscall.Statfs_t) error
+}
+
+// WithMountSyscalls mounts and unmounts with mount(2) and umount2(2), and checks mountpoints with
+// statfs(2), instead of forking mount, umount and mountpoint.
+func WithMountSyscalls(mountSyscall MountSyscall) MapfsMounterOption {
+	return func(m *mapfsMounter) {
+		m.mountSyscall = mountSyscall
+	}
+}
+
+// kernelMount mounts the NFS share remote on target with mountOptions.
+func (m *mapfsMounter) kernelMount(env dockerdriver.Env, remote string, target string, mountOptions string) error {
+	logger := env.Logger()
+
+	if m.mountSyscall == nil {
+		mountResult := m.invoker.Invoke(env, "mount", []string{"-t", m.fstype, "-o", mountOptions, remote, target})
+		err := mountResult.Wait()
+		if err != nil {
+			mountError := classifyMountError(err, mountResult.StdError())
+			logger.Error("invoke-mount-failed", err, lager.Data{"code": MountErrorCodeOf(mountError), "stderr": mountResult.StdError()})
+			return mountError
+		}
+		return nil
+	}
+
+	host, _, err := splitRemote(remote)
+	if err != nil {
+		return NewMountError(MountErrorNoSuchExport, err.Error())
+	}
+
+	// unlike mount.nfs, the kernel does not resolve the server name itself
+	addrs, err := net.DefaultResolver.LookupIPAddr(env.Context(), host)
+	if err != nil || len(addrs) == 0 {
+		logger.Error("resolve-server-failed", err, lager.Data{"host": host})
+		return NewMountError(MountErrorUnknownHost, "the NFS server name could not be resolved ("+host+")")
+	}
+
+	flags, data := mountFlags(mountOptions)
+	data = data + ",addr=" + addrs[0].IP.String()
+
+	err = m.mountSyscall.Mount(remote, target, m.fstype, flags, data)
+	if err != nil {
+		mountError := classifyMountError(err, "")
+		logger.Error("mount-syscall-failed", err, lager.Data{"code": MountErrorCodeOf(mountError), "data": data})
+		return mountError
+	}
+	return nil
+}
+
+// umount unmounts target, detaching it lazily and forcing the unmount of an unreachable server as asked.
+func (m *mapfsMounter) umount(env dockerdriver.Env, target string, lazy bool, force bool) error {
+	if m.mountSyscall != nil {
+		return m.mountSyscall.Unmount(target, unmountFlags(lazy, force))
+	}
+
+	var args []string
+	if lazy {
+		args = append(args, "-l")
+	}
+	if force {
+		args = append(args, "-f")
+	}
+	return m.invoker.Invoke(env, "umount", append(args, target)).Wait()
+}
+
+// checkMountpoint returns ErrNotMountpoint when nothing is mounted on mountPoint.
+func (m *mapfsMounter) checkMountpoint(env dockerdriver.Env, mountPoint string) error {
+	if m.mountSyscall == nil {
+		return m.invoker.Invoke(env, "mountpoint", []string{"-q", mountPoint}).Wait()
+	}
+
+	// like stat, statfs on a hard NFS mount blocks while its server does not answer
+	return m.probeMountpoint(env.Context(), "statfs:"+mountPoint, func() error {
+		st := syscall.Statfs_t{}
+		err := m.mountSyscall.Statfs(mountPoint, &st)
+		if err == nil && !mountedFilesystem(&st) {
+			err = ErrNotMountpoint
+		}
+		return err
+	})
+}
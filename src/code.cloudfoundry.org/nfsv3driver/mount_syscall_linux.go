@@ -0,0 +1,72 @@
+//go:build linux
+// +build linux
+
+package nfsv3driver
+
+import (
+	"strings"
+	"syscall"
+
+	"code.cloudfoundry.org/goshims/syscallshim"
+	"golang.org/x/sys/unix"
+)
+
+// MountSyscallShim is a syscallshim.SyscallShim that also wraps mount(2) and umount2(2).
+type MountSyscallShim struct {
+	syscallshim.SyscallShim
+}
+
+func (sh *MountSyscallShim) Mount(source string, target string, fstype string, flags uintptr, data string) error {
+	return unix.Mount(source, target, fstype, flags, data)
+}
+
+func (sh *MountSyscallShim) Unmount(target string, flags int) error {
+	return unix.Unmount(target, flags)
+}
+
+// genericMountFlags are the options that mount(8) turns into mount flags rather than passing them on to
+// the filesystem.
+var genericMountFlags = map[string]uintptr{
+	"ro":         unix.MS_RDONLY,
+	"nosuid":     unix.MS_NOSUID,
+	"nodev":      unix.MS_NODEV,
+	"noexec":     unix.MS_NOEXEC,
+	"sync":       unix.MS_SYNCHRONOUS,
+	"dirsync":    unix.MS_DIRSYNC,
+	"noatime":    unix.MS_NOATIME,
+	"nodiratime": unix.MS_NODIRATIME,
+	"relatime":   unix.MS_RELATIME,
+}
+
+// mountFlags splits a mount(8) option string into mount flags and the data passed to the filesystem.
+func mountFlags(options string) (uintptr, string) {
+	var flags uintptr
+	var data []string
+	for _, option := range strings.Split(options, ",") {
+		switch {
+		case option == "" || option == "rw":
+		case genericMountFlags[option] != 0:
+			flags |= genericMountFlags[option]
+		default:
+			data = append(data, option)
+		}
+	}
+	return flags, strings.Join(data, ",")
+}
+
+func unmountFlags(lazy bool, force bool) int {
+	flags := 0
+	if lazy {
+		flags |= unix.MNT_DETACH
+	}
+	if force {
+		flags |= unix.MNT_FORCE
+	}
+	return flags
+}
+
+// mountedFilesystem reports whether st describes one of the filesystems this driver mounts. Mountpoints
+// live on a local filesystem, so anything else means nothing is mounted there.
+func mountedFilesystem(st *syscall.Statfs_t) bool {
+	return st.Type == unix.NFS_SUPER_MAGIC || st.Type == unix.FUSE_SUPER_MAGIC
+}
@@ -0,0 +1,290 @@
+//go:build linux
+// +build linux
+
+package nfsv3driver_test
+
+import (
+	"context"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/goshims/osshim/os_fake"
+	"code.cloudfoundry.org/goshims/syscallshim/syscall_fake"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	vmo "code.cloudfoundry.org/volume-mount-options"
+	"code.cloudfoundry.org/volumedriver/invokerfakes"
+	nfsfakes "code.cloudfoundry.org/volumedriver/volumedriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/sys/unix"
+)
+
+var _ = Describe("MountSyscalls", func() {
+	const mapfsPath = "/var/vcap/packages/mapfs/bin/mapfs"
+
+	var (
+		env              dockerdriver.Env
+		fakeInvoker      *invokerfakes.FakeInvoker
+		fakeOs           *os_fake.FakeOs
+		fakeSyscall      *syscall_fake.FakeSyscall
+		fakeMountChecker *nfsfakes.FakeMountChecker
+		fakeMountSyscall *nfsdriverfakes.FakeMountSyscall
+		opts             map[string]interface{}
+		mask             vmo.MountOptsMask
+		subject          nfsv3driver.RecoverableMounter
+		err              error
+	)
+
+	BeforeEach(func() {
+		env = driverhttp.NewHttpDriverEnv(lagertest.NewTestLogger("mount-syscalls"), context.TODO())
+		opts = map[string]interface{}{}
+
+		fakeInvoker = &invokerfakes.FakeInvoker{}
+		fakeInvoker.InvokeReturns(&invokerfakes.FakeInvokeResult{})
+		fakeOs = &os_fake.FakeOs{}
+		fakeSyscall = &syscall_fake.FakeSyscall{}
+		fakeSyscall.StatStub = func(path string, st *syscall.Stat_t) error {
+			st.Mode = 0777
+			return nil
+		}
+		fakeMountChecker = &nfsfakes.FakeMountChecker{}
+		fakeMountChecker.ExistsReturns(true, nil)
+		fakeMountSyscall = &nfsdriverfakes.FakeMountSyscall{}
+
+		mask, err = nfsv3driver.NewMapFsVolumeMountMask()
+		Expect(err).NotTo(HaveOccurred())
+
+		subject = nfsv3driver.NewMapfsMounter(fakeInvoker, fakeOs, fakeSyscall, fakeMountChecker, "nfs", "rsize=1048576,hard,nosuid,timeo=600,actimeo=0", nil, mask, mapfsPath,
+			nfsv3driver.WithMountSyscalls(fakeMountSyscall))
+	})
+
+	invokedCommands := func() []string {
+		var cmds []string
+		for i := 0; i < fakeInvoker.InvokeCallCount(); i++ {
+			_, cmd, _, _ := fakeInvoker.InvokeArgsForCall(i)
+			cmds = append(cmds, cmd)
+		}
+		return cmds
+	}
+
+	Describe("#Mount", func() {
+		var remote string
+
+		BeforeEach(func() {
+			remote = "10.0.0.1:/export"
+		})
+
+		JustBeforeEach(func() {
+			err = subject.Mount(env, remote, "/mounts/target", opts)
+		})
+
+		It("mounts the share with mount(2), passing the server address to the kernel", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeMountSyscall.MountCallCount()).To(Equal(1))
+
+			source, target, fstype, flags, data := fakeMountSyscall.MountArgsForCall(0)
+			Expect(source).To(Equal("10.0.0.1:/export"))
+			Expect(target).To(Equal("/mounts/target"))
+			Expect(fstype).To(Equal("nfs"))
+			Expect(flags).To(Equal(uintptr(unix.MS_NOSUID)))
+			Expect(data).To(Equal("rsize=1048576,hard,timeo=600,actimeo=0,addr=10.0.0.1"))
+
+			Expect(invokedCommands()).NotTo(ContainElement("mount"))
+		})
+
+		Context("when the share is on an ipv6 server", func() {
+			BeforeEach(func() {
+				remote = "[fd00::1]:/export"
+			})
+
+			It("passes the address without brackets", func() {
+				Expect(err).NotTo(HaveOccurred())
+				_, _, _, _, data := fakeMountSyscall.MountArgsForCall(0)
+				Expect(data).To(HaveSuffix(",addr=fd00::1"))
+			})
+		})
+
+		Context("when the share is mounted with uid mapping", func() {
+			BeforeEach(func() {
+				opts["uid"] = "2000"
+				opts["gid"] = "2000"
+				opts["version"] = "3"
+			})
+
+			It("mounts the share on the intermediate directory and starts mapfs over it", func() {
+				Expect(err).NotTo(HaveOccurred())
+				_, target, _, _, data := fakeMountSyscall.MountArgsForCall(0)
+				Expect(target).To(Equal("/mounts/target_mapfs"))
+				Expect(data).To(ContainSubstring(",vers=3,"))
+				Expect(invokedCommands()).To(Equal([]string{mapfsPath}))
+			})
+		})
+
+		Context("when the kernel refuses the mount", func() {
+			BeforeEach(func() {
+				fakeMountSyscall.MountReturns(syscall.EACCES)
+			})
+
+			It("classifies the error and removes the intermediate directory", func() {
+				Expect(err).To(MatchError(HavePrefix("[NFS_ACCESS_DENIED] ")))
+				Expect(fakeOs.RemoveCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when the server name cannot be resolved", func() {
+			BeforeEach(func() {
+				remote = "nfs-server.invalid:/export"
+			})
+
+			It("fails with an unknown host error", func() {
+				Expect(err).To(MatchError(HavePrefix("[NFS_UNKNOWN_HOST] ")))
+				Expect(fakeMountSyscall.MountCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when Kerberos credentials are needed", func() {
+			BeforeEach(func() {
+				subject = nfsv3driver.NewMapfsMounter(fakeInvoker, fakeOs, fakeSyscall, fakeMountChecker, "nfs", "hard", nil, mask, mapfsPath,
+					nfsv3driver.WithMountSyscalls(fakeMountSyscall),
+					nfsv3driver.WithKerberosCredentials(&nfsdriverfakes.FakeKerberosCredentials{}))
+				opts["sec"] = "krb5"
+				opts["kerberos_principal"] = "user@EXAMPLE.COM"
+				opts["kerberos_keytab"] = "a2V5dGFi"
+			})
+
+			It("mounts with mount(2) and leaves the credentials to rpc.gssd", func() {
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeMountSyscall.MountCallCount()).To(Equal(1))
+				_, _, _, _, data := fakeMountSyscall.MountArgsForCall(0)
+				Expect(data).To(ContainSubstring("sec=krb5"))
+				Expect(invokedCommands()).NotTo(ContainElement("mount"))
+			})
+		})
+	})
+
+	Describe("#Unmount", func() {
+		JustBeforeEach(func() {
+			err = subject.Unmount(env, "/mounts/target")
+		})
+
+		It("lazily unmounts the target and the intermediate mount with umount2(2)", func() {
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeMountSyscall.UnmountCallCount()).To(Equal(2))
+
+			target, flags := fakeMountSyscall.UnmountArgsForCall(0)
+			Expect(target).To(Equal("/mounts/target"))
+			Expect(flags).To(Equal(unix.MNT_DETACH))
+
+			target, flags = fakeMountSyscall.UnmountArgsForCall(1)
+			Expect(target).To(Equal("/mounts/target_mapfs"))
+			Expect(flags).To(Equal(unix.MNT_DETACH))
+
+			Expect(fakeInvoker.InvokeCallCount()).To(Equal(0))
+		})
+
+		Context("when the target is not mounted", func() {
+			BeforeEach(func() {
+				fakeMountSyscall.UnmountReturns(syscall.EINVAL)
+			})
+
+			It("returns the error", func() {
+				Expect(err).To(MatchError(ContainSubstring("invalid argument")))
+			})
+		})
+	})
+
+	Describe("#Purge", func() {
+		BeforeEach(func() {
+			fakeMountChecker.ListReturns([]string{"/mounts/target_mapfs"}, nil)
+		})
+
+		It("forcibly unmounts the mounts with umount2(2)", func() {
+			subject.Purge(env, "/mounts")
+
+			Expect(fakeMountSyscall.UnmountCallCount()).To(Equal(2))
+			target, flags := fakeMountSyscall.UnmountArgsForCall(0)
+			Expect(target).To(Equal("/mounts/target"))
+			Expect(flags).To(Equal(unix.MNT_DETACH | unix.MNT_FORCE))
+
+			target, flags = fakeMountSyscall.UnmountArgsForCall(1)
+			Expect(target).To(Equal("/mounts/target_mapfs"))
+			Expect(flags).To(Equal(unix.MNT_DETACH | unix.MNT_FORCE))
+		})
+	})
+
+	Describe("#Check", func() {
+		var fsType int64
+
+		BeforeEach(func() {
+			fsType = unix.NFS_SUPER_MAGIC
+			fakeMountSyscall.StatfsStub = func(path string, st *syscall.Statfs_t) error {
+				st.Type = fsType
+				return nil
+			}
+		})
+
+		It("checks the mountpoint with statfs(2)", func() {
+			Expect(subject.Check(env, "volume", "/mounts/target")).To(BeTrue())
+			path, _ := fakeMountSyscall.StatfsArgsForCall(0)
+			Expect(path).To(Equal("/mounts/target"))
+			Expect(fakeInvoker.InvokeCallCount()).To(Equal(0))
+		})
+
+		Context("when mapfs is mounted there", func() {
+			BeforeEach(func() {
+				fsType = unix.FUSE_SUPER_MAGIC
+			})
+
+			It("returns true", func() {
+				Expect(subject.Check(env, "volume", "/mounts/target")).To(BeTrue())
+			})
+		})
+
+		Context("when nothing is mounted there", func() {
+			BeforeEach(func() {
+				fsType = unix.EXT4_SUPER_MAGIC
+			})
+
+			It("returns false", func() {
+				Expect(subject.Check(env, "volume", "/mounts/target")).To(BeFalse())
+			})
+		})
+
+		Context("when the mount is stale", func() {
+			BeforeEach(func() {
+				fakeMountSyscall.StatfsStub = nil
+				fakeMountSyscall.StatfsReturns(syscall.ESTALE)
+			})
+
+			It("returns false", func() {
+				Expect(subject.Check(env, "volume", "/mounts/target")).To(BeFalse())
+			})
+		})
+
+		Context("when statfs hangs", func() {
+			var release chan struct{}
+
+			BeforeEach(func() {
+				release = make(chan struct{})
+				nfsv3driver.CheckTimeout = 10 * time.Millisecond
+				fakeMountSyscall.StatfsStub = func(string, *syscall.Statfs_t) error {
+					<-release
+					return nil
+				}
+			})
+
+			AfterEach(func() {
+				close(release)
+				nfsv3driver.CheckTimeout = 5 * time.Second
+			})
+
+			It("gives up after the check timeout", func() {
+				Expect(subject.Check(env, "volume", "/mounts/target")).To(BeFalse())
+			})
+		})
+	})
+})
@@ -0,0 +1,38 @@
+//go:build !linux
+// +build !linux
+
+package nfsv3driver
+
+import (
+	"errors"
+	"syscall"
+
+	"code.cloudfoundry.org/goshims/syscallshim"
+)
+
+var errMountSyscallUnsupported = errors.New("mount syscalls are only supported on linux")
+
+// MountSyscallShim is a syscallshim.SyscallShim that also wraps mount(2) and umount2(2).
+type MountSyscallShim struct {
+	syscallshim.SyscallShim
+}
+
+func (sh *MountSyscallShim) Mount(source string, target string, fstype string, flags uintptr, data string) error {
+	return errMountSyscallUnsupported
+}
+
+func (sh *MountSyscallShim) Unmount(target string, flags int) error {
+	return errMountSyscallUnsupported
+}
+
+func mountFlags(options string) (uintptr, string) {
+	return 0, options
+}
+
+func unmountFlags(lazy bool, force bool) int {
+	return 0
+}
+
+func mountedFilesystem(st *syscall.Statfs_t) bool {
+	return false
+}
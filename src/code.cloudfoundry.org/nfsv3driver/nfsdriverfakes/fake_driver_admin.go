@@ -9,16 +9,51 @@ import (
 )
 
 type FakeDriverAdmin struct {
-	EvacuateStub        func(dockerdriver.Env) driveradmin.ErrorResponse
-	evacuateMutex       sync.RWMutex
-	evacuateArgsForCall []struct {
+	EvacuationStatusStub        func(dockerdriver.Env) driveradmin.EvacuationStatusResponse
+	evacuationStatusMutex       sync.RWMutex
+	evacuationStatusArgsForCall []struct {
 		arg1 dockerdriver.Env
 	}
-	evacuateReturns struct {
-		result1 driveradmin.ErrorResponse
+	evacuationStatusReturns struct {
+		result1 driveradmin.EvacuationStatusResponse
 	}
-	evacuateReturnsOnCall map[int]struct {
-		result1 driveradmin.ErrorResponse
+	evacuationStatusReturnsOnCall map[int]struct {
+		result1 driveradmin.EvacuationStatusResponse
+	}
+	ForceRemountStub        func(dockerdriver.Env, driveradmin.VolumeSelector) driveradmin.VolumeOperationsResponse
+	forceRemountMutex       sync.RWMutex
+	forceRemountArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 driveradmin.VolumeSelector
+	}
+	forceRemountReturns struct {
+		result1 driveradmin.VolumeOperationsResponse
+	}
+	forceRemountReturnsOnCall map[int]struct {
+		result1 driveradmin.VolumeOperationsResponse
+	}
+	ForceUnmountStub        func(dockerdriver.Env, driveradmin.VolumeSelector) driveradmin.VolumeOperationsResponse
+	forceUnmountMutex       sync.RWMutex
+	forceUnmountArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 driveradmin.VolumeSelector
+	}
+	forceUnmountReturns struct {
+		result1 driveradmin.VolumeOperationsResponse
+	}
+	forceUnmountReturnsOnCall map[int]struct {
+		result1 driveradmin.VolumeOperationsResponse
+	}
+	MapfsRestartsStub        func(dockerdriver.Env) driveradmin.MapfsRestartsResponse
+	mapfsRestartsMutex       sync.RWMutex
+	mapfsRestartsArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	mapfsRestartsReturns struct {
+		result1 driveradmin.MapfsRestartsResponse
+	}
+	mapfsRestartsReturnsOnCall map[int]struct {
+		result1 driveradmin.MapfsRestartsResponse
 	}
 	PingStub        func(dockerdriver.Env) driveradmin.ErrorResponse
 	pingMutex       sync.RWMutex
@@ -31,20 +66,65 @@ type FakeDriverAdmin struct {
 	pingReturnsOnCall map[int]struct {
 		result1 driveradmin.ErrorResponse
 	}
+	ReadyStub        func(dockerdriver.Env) driveradmin.ReadyResponse
+	readyMutex       sync.RWMutex
+	readyArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	readyReturns struct {
+		result1 driveradmin.ReadyResponse
+	}
+	readyReturnsOnCall map[int]struct {
+		result1 driveradmin.ReadyResponse
+	}
+	StartEvacuationStub        func(dockerdriver.Env) driveradmin.EvacuationStatusResponse
+	startEvacuationMutex       sync.RWMutex
+	startEvacuationArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	startEvacuationReturns struct {
+		result1 driveradmin.EvacuationStatusResponse
+	}
+	startEvacuationReturnsOnCall map[int]struct {
+		result1 driveradmin.EvacuationStatusResponse
+	}
+	VolumeStub        func(dockerdriver.Env, string) driveradmin.VolumeResponse
+	volumeMutex       sync.RWMutex
+	volumeArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}
+	volumeReturns struct {
+		result1 driveradmin.VolumeResponse
+	}
+	volumeReturnsOnCall map[int]struct {
+		result1 driveradmin.VolumeResponse
+	}
+	VolumesStub        func(dockerdriver.Env) driveradmin.VolumesResponse
+	volumesMutex       sync.RWMutex
+	volumesArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	volumesReturns struct {
+		result1 driveradmin.VolumesResponse
+	}
+	volumesReturnsOnCall map[int]struct {
+		result1 driveradmin.VolumesResponse
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeDriverAdmin) Evacuate(arg1 dockerdriver.Env) driveradmin.ErrorResponse {
-	fake.evacuateMutex.Lock()
-	ret, specificReturn := fake.evacuateReturnsOnCall[len(fake.evacuateArgsForCall)]
-	fake.evacuateArgsForCall = append(fake.evacuateArgsForCall, struct {
+func (fake *FakeDriverAdmin) EvacuationStatus(arg1 dockerdriver.Env) driveradmin.EvacuationStatusResponse {
+	fake.evacuationStatusMutex.Lock()
+	ret, specificReturn := fake.evacuationStatusReturnsOnCall[len(fake.evacuationStatusArgsForCall)]
+	fake.evacuationStatusArgsForCall = append(fake.evacuationStatusArgsForCall, struct {
 		arg1 dockerdriver.Env
 	}{arg1})
-	stub := fake.EvacuateStub
-	fakeReturns := fake.evacuateReturns
-	fake.recordInvocation("Evacuate", []interface{}{arg1})
-	fake.evacuateMutex.Unlock()
+	stub := fake.EvacuationStatusStub
+	fakeReturns := fake.evacuationStatusReturns
+	fake.recordInvocation("EvacuationStatus", []interface{}{arg1})
+	fake.evacuationStatusMutex.Unlock()
 	if stub != nil {
 		return stub(arg1)
 	}
@@ -54,45 +134,230 @@ func (fake *FakeDriverAdmin) Evacuate(arg1 dockerdriver.Env) driveradmin.ErrorRe
 	return fakeReturns.result1
 }
 
-func (fake *FakeDriverAdmin) EvacuateCallCount() int {
-	fake.evacuateMutex.RLock()
-	defer fake.evacuateMutex.RUnlock()
-	return len(fake.evacuateArgsForCall)
+func (fake *FakeDriverAdmin) EvacuationStatusCallCount() int {
+	fake.evacuationStatusMutex.RLock()
+	defer fake.evacuationStatusMutex.RUnlock()
+	return len(fake.evacuationStatusArgsForCall)
 }
 
-func (fake *FakeDriverAdmin) EvacuateCalls(stub func(dockerdriver.Env) driveradmin.ErrorResponse) {
-	fake.evacuateMutex.Lock()
-	defer fake.evacuateMutex.Unlock()
-	fake.EvacuateStub = stub
+func (fake *FakeDriverAdmin) EvacuationStatusCalls(stub func(dockerdriver.Env) driveradmin.EvacuationStatusResponse) {
+	fake.evacuationStatusMutex.Lock()
+	defer fake.evacuationStatusMutex.Unlock()
+	fake.EvacuationStatusStub = stub
 }
 
-func (fake *FakeDriverAdmin) EvacuateArgsForCall(i int) dockerdriver.Env {
-	fake.evacuateMutex.RLock()
-	defer fake.evacuateMutex.RUnlock()
-	argsForCall := fake.evacuateArgsForCall[i]
+func (fake *FakeDriverAdmin) EvacuationStatusArgsForCall(i int) dockerdriver.Env {
+	fake.evacuationStatusMutex.RLock()
+	defer fake.evacuationStatusMutex.RUnlock()
+	argsForCall := fake.evacuationStatusArgsForCall[i]
 	return argsForCall.arg1
 }
 
-func (fake *FakeDriverAdmin) EvacuateReturns(result1 driveradmin.ErrorResponse) {
-	fake.evacuateMutex.Lock()
-	defer fake.evacuateMutex.Unlock()
-	fake.EvacuateStub = nil
-	fake.evacuateReturns = struct {
-		result1 driveradmin.ErrorResponse
+func (fake *FakeDriverAdmin) EvacuationStatusReturns(result1 driveradmin.EvacuationStatusResponse) {
+	fake.evacuationStatusMutex.Lock()
+	defer fake.evacuationStatusMutex.Unlock()
+	fake.EvacuationStatusStub = nil
+	fake.evacuationStatusReturns = struct {
+		result1 driveradmin.EvacuationStatusResponse
 	}{result1}
 }
 
-func (fake *FakeDriverAdmin) EvacuateReturnsOnCall(i int, result1 driveradmin.ErrorResponse) {
-	fake.evacuateMutex.Lock()
-	defer fake.evacuateMutex.Unlock()
-	fake.EvacuateStub = nil
-	if fake.evacuateReturnsOnCall == nil {
-		fake.evacuateReturnsOnCall = make(map[int]struct {
-			result1 driveradmin.ErrorResponse
+func (fake *FakeDriverAdmin) EvacuationStatusReturnsOnCall(i int, result1 driveradmin.EvacuationStatusResponse) {
+	fake.evacuationStatusMutex.Lock()
+	defer fake.evacuationStatusMutex.Unlock()
+	fake.EvacuationStatusStub = nil
+	if fake.evacuationStatusReturnsOnCall == nil {
+		fake.evacuationStatusReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.EvacuationStatusResponse
 		})
 	}
-	fake.evacuateReturnsOnCall[i] = struct {
-		result1 driveradmin.ErrorResponse
+	fake.evacuationStatusReturnsOnCall[i] = struct {
+		result1 driveradmin.EvacuationStatusResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) ForceRemount(arg1 dockerdriver.Env, arg2 driveradmin.VolumeSelector) driveradmin.VolumeOperationsResponse {
+	fake.forceRemountMutex.Lock()
+	ret, specificReturn := fake.forceRemountReturnsOnCall[len(fake.forceRemountArgsForCall)]
+	fake.forceRemountArgsForCall = append(fake.forceRemountArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 driveradmin.VolumeSelector
+	}{arg1, arg2})
+	stub := fake.ForceRemountStub
+	fakeReturns := fake.forceRemountReturns
+	fake.recordInvocation("ForceRemount", []interface{}{arg1, arg2})
+	fake.forceRemountMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) ForceRemountCallCount() int {
+	fake.forceRemountMutex.RLock()
+	defer fake.forceRemountMutex.RUnlock()
+	return len(fake.forceRemountArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) ForceRemountCalls(stub func(dockerdriver.Env, driveradmin.VolumeSelector) driveradmin.VolumeOperationsResponse) {
+	fake.forceRemountMutex.Lock()
+	defer fake.forceRemountMutex.Unlock()
+	fake.ForceRemountStub = stub
+}
+
+func (fake *FakeDriverAdmin) ForceRemountArgsForCall(i int) (dockerdriver.Env, driveradmin.VolumeSelector) {
+	fake.forceRemountMutex.RLock()
+	defer fake.forceRemountMutex.RUnlock()
+	argsForCall := fake.forceRemountArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDriverAdmin) ForceRemountReturns(result1 driveradmin.VolumeOperationsResponse) {
+	fake.forceRemountMutex.Lock()
+	defer fake.forceRemountMutex.Unlock()
+	fake.ForceRemountStub = nil
+	fake.forceRemountReturns = struct {
+		result1 driveradmin.VolumeOperationsResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) ForceRemountReturnsOnCall(i int, result1 driveradmin.VolumeOperationsResponse) {
+	fake.forceRemountMutex.Lock()
+	defer fake.forceRemountMutex.Unlock()
+	fake.ForceRemountStub = nil
+	if fake.forceRemountReturnsOnCall == nil {
+		fake.forceRemountReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.VolumeOperationsResponse
+		})
+	}
+	fake.forceRemountReturnsOnCall[i] = struct {
+		result1 driveradmin.VolumeOperationsResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) ForceUnmount(arg1 dockerdriver.Env, arg2 driveradmin.VolumeSelector) driveradmin.VolumeOperationsResponse {
+	fake.forceUnmountMutex.Lock()
+	ret, specificReturn := fake.forceUnmountReturnsOnCall[len(fake.forceUnmountArgsForCall)]
+	fake.forceUnmountArgsForCall = append(fake.forceUnmountArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 driveradmin.VolumeSelector
+	}{arg1, arg2})
+	stub := fake.ForceUnmountStub
+	fakeReturns := fake.forceUnmountReturns
+	fake.recordInvocation("ForceUnmount", []interface{}{arg1, arg2})
+	fake.forceUnmountMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) ForceUnmountCallCount() int {
+	fake.forceUnmountMutex.RLock()
+	defer fake.forceUnmountMutex.RUnlock()
+	return len(fake.forceUnmountArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) ForceUnmountCalls(stub func(dockerdriver.Env, driveradmin.VolumeSelector) driveradmin.VolumeOperationsResponse) {
+	fake.forceUnmountMutex.Lock()
+	defer fake.forceUnmountMutex.Unlock()
+	fake.ForceUnmountStub = stub
+}
+
+func (fake *FakeDriverAdmin) ForceUnmountArgsForCall(i int) (dockerdriver.Env, driveradmin.VolumeSelector) {
+	fake.forceUnmountMutex.RLock()
+	defer fake.forceUnmountMutex.RUnlock()
+	argsForCall := fake.forceUnmountArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDriverAdmin) ForceUnmountReturns(result1 driveradmin.VolumeOperationsResponse) {
+	fake.forceUnmountMutex.Lock()
+	defer fake.forceUnmountMutex.Unlock()
+	fake.ForceUnmountStub = nil
+	fake.forceUnmountReturns = struct {
+		result1 driveradmin.VolumeOperationsResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) ForceUnmountReturnsOnCall(i int, result1 driveradmin.VolumeOperationsResponse) {
+	fake.forceUnmountMutex.Lock()
+	defer fake.forceUnmountMutex.Unlock()
+	fake.ForceUnmountStub = nil
+	if fake.forceUnmountReturnsOnCall == nil {
+		fake.forceUnmountReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.VolumeOperationsResponse
+		})
+	}
+	fake.forceUnmountReturnsOnCall[i] = struct {
+		result1 driveradmin.VolumeOperationsResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) MapfsRestarts(arg1 dockerdriver.Env) driveradmin.MapfsRestartsResponse {
+	fake.mapfsRestartsMutex.Lock()
+	ret, specificReturn := fake.mapfsRestartsReturnsOnCall[len(fake.mapfsRestartsArgsForCall)]
+	fake.mapfsRestartsArgsForCall = append(fake.mapfsRestartsArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.MapfsRestartsStub
+	fakeReturns := fake.mapfsRestartsReturns
+	fake.recordInvocation("MapfsRestarts", []interface{}{arg1})
+	fake.mapfsRestartsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) MapfsRestartsCallCount() int {
+	fake.mapfsRestartsMutex.RLock()
+	defer fake.mapfsRestartsMutex.RUnlock()
+	return len(fake.mapfsRestartsArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) MapfsRestartsCalls(stub func(dockerdriver.Env) driveradmin.MapfsRestartsResponse) {
+	fake.mapfsRestartsMutex.Lock()
+	defer fake.mapfsRestartsMutex.Unlock()
+	fake.MapfsRestartsStub = stub
+}
+
+func (fake *FakeDriverAdmin) MapfsRestartsArgsForCall(i int) dockerdriver.Env {
+	fake.mapfsRestartsMutex.RLock()
+	defer fake.mapfsRestartsMutex.RUnlock()
+	argsForCall := fake.mapfsRestartsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDriverAdmin) MapfsRestartsReturns(result1 driveradmin.MapfsRestartsResponse) {
+	fake.mapfsRestartsMutex.Lock()
+	defer fake.mapfsRestartsMutex.Unlock()
+	fake.MapfsRestartsStub = nil
+	fake.mapfsRestartsReturns = struct {
+		result1 driveradmin.MapfsRestartsResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) MapfsRestartsReturnsOnCall(i int, result1 driveradmin.MapfsRestartsResponse) {
+	fake.mapfsRestartsMutex.Lock()
+	defer fake.mapfsRestartsMutex.Unlock()
+	fake.MapfsRestartsStub = nil
+	if fake.mapfsRestartsReturnsOnCall == nil {
+		fake.mapfsRestartsReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.MapfsRestartsResponse
+		})
+	}
+	fake.mapfsRestartsReturnsOnCall[i] = struct {
+		result1 driveradmin.MapfsRestartsResponse
 	}{result1}
 }
 
@@ -157,13 +422,272 @@ func (fake *FakeDriverAdmin) PingReturnsOnCall(i int, result1 driveradmin.ErrorR
 	}{result1}
 }
 
+func (fake *FakeDriverAdmin) Ready(arg1 dockerdriver.Env) driveradmin.ReadyResponse {
+	fake.readyMutex.Lock()
+	ret, specificReturn := fake.readyReturnsOnCall[len(fake.readyArgsForCall)]
+	fake.readyArgsForCall = append(fake.readyArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.ReadyStub
+	fakeReturns := fake.readyReturns
+	fake.recordInvocation("Ready", []interface{}{arg1})
+	fake.readyMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) ReadyCallCount() int {
+	fake.readyMutex.RLock()
+	defer fake.readyMutex.RUnlock()
+	return len(fake.readyArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) ReadyCalls(stub func(dockerdriver.Env) driveradmin.ReadyResponse) {
+	fake.readyMutex.Lock()
+	defer fake.readyMutex.Unlock()
+	fake.ReadyStub = stub
+}
+
+func (fake *FakeDriverAdmin) ReadyArgsForCall(i int) dockerdriver.Env {
+	fake.readyMutex.RLock()
+	defer fake.readyMutex.RUnlock()
+	argsForCall := fake.readyArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDriverAdmin) ReadyReturns(result1 driveradmin.ReadyResponse) {
+	fake.readyMutex.Lock()
+	defer fake.readyMutex.Unlock()
+	fake.ReadyStub = nil
+	fake.readyReturns = struct {
+		result1 driveradmin.ReadyResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) ReadyReturnsOnCall(i int, result1 driveradmin.ReadyResponse) {
+	fake.readyMutex.Lock()
+	defer fake.readyMutex.Unlock()
+	fake.ReadyStub = nil
+	if fake.readyReturnsOnCall == nil {
+		fake.readyReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.ReadyResponse
+		})
+	}
+	fake.readyReturnsOnCall[i] = struct {
+		result1 driveradmin.ReadyResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) StartEvacuation(arg1 dockerdriver.Env) driveradmin.EvacuationStatusResponse {
+	fake.startEvacuationMutex.Lock()
+	ret, specificReturn := fake.startEvacuationReturnsOnCall[len(fake.startEvacuationArgsForCall)]
+	fake.startEvacuationArgsForCall = append(fake.startEvacuationArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.StartEvacuationStub
+	fakeReturns := fake.startEvacuationReturns
+	fake.recordInvocation("StartEvacuation", []interface{}{arg1})
+	fake.startEvacuationMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) StartEvacuationCallCount() int {
+	fake.startEvacuationMutex.RLock()
+	defer fake.startEvacuationMutex.RUnlock()
+	return len(fake.startEvacuationArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) StartEvacuationCalls(stub func(dockerdriver.Env) driveradmin.EvacuationStatusResponse) {
+	fake.startEvacuationMutex.Lock()
+	defer fake.startEvacuationMutex.Unlock()
+	fake.StartEvacuationStub = stub
+}
+
+func (fake *FakeDriverAdmin) StartEvacuationArgsForCall(i int) dockerdriver.Env {
+	fake.startEvacuationMutex.RLock()
+	defer fake.startEvacuationMutex.RUnlock()
+	argsForCall := fake.startEvacuationArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDriverAdmin) StartEvacuationReturns(result1 driveradmin.EvacuationStatusResponse) {
+	fake.startEvacuationMutex.Lock()
+	defer fake.startEvacuationMutex.Unlock()
+	fake.StartEvacuationStub = nil
+	fake.startEvacuationReturns = struct {
+		result1 driveradmin.EvacuationStatusResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) StartEvacuationReturnsOnCall(i int, result1 driveradmin.EvacuationStatusResponse) {
+	fake.startEvacuationMutex.Lock()
+	defer fake.startEvacuationMutex.Unlock()
+	fake.StartEvacuationStub = nil
+	if fake.startEvacuationReturnsOnCall == nil {
+		fake.startEvacuationReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.EvacuationStatusResponse
+		})
+	}
+	fake.startEvacuationReturnsOnCall[i] = struct {
+		result1 driveradmin.EvacuationStatusResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) Volume(arg1 dockerdriver.Env, arg2 string) driveradmin.VolumeResponse {
+	fake.volumeMutex.Lock()
+	ret, specificReturn := fake.volumeReturnsOnCall[len(fake.volumeArgsForCall)]
+	fake.volumeArgsForCall = append(fake.volumeArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.VolumeStub
+	fakeReturns := fake.volumeReturns
+	fake.recordInvocation("Volume", []interface{}{arg1, arg2})
+	fake.volumeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) VolumeCallCount() int {
+	fake.volumeMutex.RLock()
+	defer fake.volumeMutex.RUnlock()
+	return len(fake.volumeArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) VolumeCalls(stub func(dockerdriver.Env, string) driveradmin.VolumeResponse) {
+	fake.volumeMutex.Lock()
+	defer fake.volumeMutex.Unlock()
+	fake.VolumeStub = stub
+}
+
+func (fake *FakeDriverAdmin) VolumeArgsForCall(i int) (dockerdriver.Env, string) {
+	fake.volumeMutex.RLock()
+	defer fake.volumeMutex.RUnlock()
+	argsForCall := fake.volumeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeDriverAdmin) VolumeReturns(result1 driveradmin.VolumeResponse) {
+	fake.volumeMutex.Lock()
+	defer fake.volumeMutex.Unlock()
+	fake.VolumeStub = nil
+	fake.volumeReturns = struct {
+		result1 driveradmin.VolumeResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) VolumeReturnsOnCall(i int, result1 driveradmin.VolumeResponse) {
+	fake.volumeMutex.Lock()
+	defer fake.volumeMutex.Unlock()
+	fake.VolumeStub = nil
+	if fake.volumeReturnsOnCall == nil {
+		fake.volumeReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.VolumeResponse
+		})
+	}
+	fake.volumeReturnsOnCall[i] = struct {
+		result1 driveradmin.VolumeResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) Volumes(arg1 dockerdriver.Env) driveradmin.VolumesResponse {
+	fake.volumesMutex.Lock()
+	ret, specificReturn := fake.volumesReturnsOnCall[len(fake.volumesArgsForCall)]
+	fake.volumesArgsForCall = append(fake.volumesArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.VolumesStub
+	fakeReturns := fake.volumesReturns
+	fake.recordInvocation("Volumes", []interface{}{arg1})
+	fake.volumesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeDriverAdmin) VolumesCallCount() int {
+	fake.volumesMutex.RLock()
+	defer fake.volumesMutex.RUnlock()
+	return len(fake.volumesArgsForCall)
+}
+
+func (fake *FakeDriverAdmin) VolumesCalls(stub func(dockerdriver.Env) driveradmin.VolumesResponse) {
+	fake.volumesMutex.Lock()
+	defer fake.volumesMutex.Unlock()
+	fake.VolumesStub = stub
+}
+
+func (fake *FakeDriverAdmin) VolumesArgsForCall(i int) dockerdriver.Env {
+	fake.volumesMutex.RLock()
+	defer fake.volumesMutex.RUnlock()
+	argsForCall := fake.volumesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeDriverAdmin) VolumesReturns(result1 driveradmin.VolumesResponse) {
+	fake.volumesMutex.Lock()
+	defer fake.volumesMutex.Unlock()
+	fake.VolumesStub = nil
+	fake.volumesReturns = struct {
+		result1 driveradmin.VolumesResponse
+	}{result1}
+}
+
+func (fake *FakeDriverAdmin) VolumesReturnsOnCall(i int, result1 driveradmin.VolumesResponse) {
+	fake.volumesMutex.Lock()
+	defer fake.volumesMutex.Unlock()
+	fake.VolumesStub = nil
+	if fake.volumesReturnsOnCall == nil {
+		fake.volumesReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.VolumesResponse
+		})
+	}
+	fake.volumesReturnsOnCall[i] = struct {
+		result1 driveradmin.VolumesResponse
+	}{result1}
+}
+
 func (fake *FakeDriverAdmin) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.evacuateMutex.RLock()
-	defer fake.evacuateMutex.RUnlock()
+	fake.evacuationStatusMutex.RLock()
+	defer fake.evacuationStatusMutex.RUnlock()
+	fake.forceRemountMutex.RLock()
+	defer fake.forceRemountMutex.RUnlock()
+	fake.forceUnmountMutex.RLock()
+	defer fake.forceUnmountMutex.RUnlock()
+	fake.mapfsRestartsMutex.RLock()
+	defer fake.mapfsRestartsMutex.RUnlock()
 	fake.pingMutex.RLock()
 	defer fake.pingMutex.RUnlock()
+	fake.readyMutex.RLock()
+	defer fake.readyMutex.RUnlock()
+	fake.startEvacuationMutex.RLock()
+	defer fake.startEvacuationMutex.RUnlock()
+	fake.volumeMutex.RLock()
+	defer fake.volumeMutex.RUnlock()
+	fake.volumesMutex.RLock()
+	defer fake.volumesMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,116 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+type FakeHealthReporter struct {
+	LastHealthCheckStub        func(string) (driveradmin.HealthCheckResult, bool)
+	lastHealthCheckMutex       sync.RWMutex
+	lastHealthCheckArgsForCall []struct {
+		arg1 string
+	}
+	lastHealthCheckReturns struct {
+		result1 driveradmin.HealthCheckResult
+		result2 bool
+	}
+	lastHealthCheckReturnsOnCall map[int]struct {
+		result1 driveradmin.HealthCheckResult
+		result2 bool
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeHealthReporter) LastHealthCheck(arg1 string) (driveradmin.HealthCheckResult, bool) {
+	fake.lastHealthCheckMutex.Lock()
+	ret, specificReturn := fake.lastHealthCheckReturnsOnCall[len(fake.lastHealthCheckArgsForCall)]
+	fake.lastHealthCheckArgsForCall = append(fake.lastHealthCheckArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.LastHealthCheckStub
+	fakeReturns := fake.lastHealthCheckReturns
+	fake.recordInvocation("LastHealthCheck", []interface{}{arg1})
+	fake.lastHealthCheckMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeHealthReporter) LastHealthCheckCallCount() int {
+	fake.lastHealthCheckMutex.RLock()
+	defer fake.lastHealthCheckMutex.RUnlock()
+	return len(fake.lastHealthCheckArgsForCall)
+}
+
+func (fake *FakeHealthReporter) LastHealthCheckCalls(stub func(string) (driveradmin.HealthCheckResult, bool)) {
+	fake.lastHealthCheckMutex.Lock()
+	defer fake.lastHealthCheckMutex.Unlock()
+	fake.LastHealthCheckStub = stub
+}
+
+func (fake *FakeHealthReporter) LastHealthCheckArgsForCall(i int) string {
+	fake.lastHealthCheckMutex.RLock()
+	defer fake.lastHealthCheckMutex.RUnlock()
+	argsForCall := fake.lastHealthCheckArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeHealthReporter) LastHealthCheckReturns(result1 driveradmin.HealthCheckResult, result2 bool) {
+	fake.lastHealthCheckMutex.Lock()
+	defer fake.lastHealthCheckMutex.Unlock()
+	fake.LastHealthCheckStub = nil
+	fake.lastHealthCheckReturns = struct {
+		result1 driveradmin.HealthCheckResult
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeHealthReporter) LastHealthCheckReturnsOnCall(i int, result1 driveradmin.HealthCheckResult, result2 bool) {
+	fake.lastHealthCheckMutex.Lock()
+	defer fake.lastHealthCheckMutex.Unlock()
+	fake.LastHealthCheckStub = nil
+	if fake.lastHealthCheckReturnsOnCall == nil {
+		fake.lastHealthCheckReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.HealthCheckResult
+			result2 bool
+		})
+	}
+	fake.lastHealthCheckReturnsOnCall[i] = struct {
+		result1 driveradmin.HealthCheckResult
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeHealthReporter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.lastHealthCheckMutex.RLock()
+	defer fake.lastHealthCheckMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeHealthReporter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driveradmin.HealthReporter = new(FakeHealthReporter)
@@ -0,0 +1,182 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/nfsv3driver"
+)
+
+type FakeIdMapper struct {
+	MapStub        func(string, string, uint32, uint32) error
+	mapMutex       sync.RWMutex
+	mapArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 uint32
+		arg4 uint32
+	}
+	mapReturns struct {
+		result1 error
+	}
+	mapReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SupportedStub        func() error
+	supportedMutex       sync.RWMutex
+	supportedArgsForCall []struct {
+	}
+	supportedReturns struct {
+		result1 error
+	}
+	supportedReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeIdMapper) Map(arg1 string, arg2 string, arg3 uint32, arg4 uint32) error {
+	fake.mapMutex.Lock()
+	ret, specificReturn := fake.mapReturnsOnCall[len(fake.mapArgsForCall)]
+	fake.mapArgsForCall = append(fake.mapArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 uint32
+		arg4 uint32
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.MapStub
+	fakeReturns := fake.mapReturns
+	fake.recordInvocation("Map", []interface{}{arg1, arg2, arg3, arg4})
+	fake.mapMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeIdMapper) MapCallCount() int {
+	fake.mapMutex.RLock()
+	defer fake.mapMutex.RUnlock()
+	return len(fake.mapArgsForCall)
+}
+
+func (fake *FakeIdMapper) MapCalls(stub func(string, string, uint32, uint32) error) {
+	fake.mapMutex.Lock()
+	defer fake.mapMutex.Unlock()
+	fake.MapStub = stub
+}
+
+func (fake *FakeIdMapper) MapArgsForCall(i int) (string, string, uint32, uint32) {
+	fake.mapMutex.RLock()
+	defer fake.mapMutex.RUnlock()
+	argsForCall := fake.mapArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeIdMapper) MapReturns(result1 error) {
+	fake.mapMutex.Lock()
+	defer fake.mapMutex.Unlock()
+	fake.MapStub = nil
+	fake.mapReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIdMapper) MapReturnsOnCall(i int, result1 error) {
+	fake.mapMutex.Lock()
+	defer fake.mapMutex.Unlock()
+	fake.MapStub = nil
+	if fake.mapReturnsOnCall == nil {
+		fake.mapReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.mapReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIdMapper) Supported() error {
+	fake.supportedMutex.Lock()
+	ret, specificReturn := fake.supportedReturnsOnCall[len(fake.supportedArgsForCall)]
+	fake.supportedArgsForCall = append(fake.supportedArgsForCall, struct {
+	}{})
+	stub := fake.SupportedStub
+	fakeReturns := fake.supportedReturns
+	fake.recordInvocation("Supported", []interface{}{})
+	fake.supportedMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeIdMapper) SupportedCallCount() int {
+	fake.supportedMutex.RLock()
+	defer fake.supportedMutex.RUnlock()
+	return len(fake.supportedArgsForCall)
+}
+
+func (fake *FakeIdMapper) SupportedCalls(stub func() error) {
+	fake.supportedMutex.Lock()
+	defer fake.supportedMutex.Unlock()
+	fake.SupportedStub = stub
+}
+
+func (fake *FakeIdMapper) SupportedReturns(result1 error) {
+	fake.supportedMutex.Lock()
+	defer fake.supportedMutex.Unlock()
+	fake.SupportedStub = nil
+	fake.supportedReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIdMapper) SupportedReturnsOnCall(i int, result1 error) {
+	fake.supportedMutex.Lock()
+	defer fake.supportedMutex.Unlock()
+	fake.SupportedStub = nil
+	if fake.supportedReturnsOnCall == nil {
+		fake.supportedReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.supportedReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeIdMapper) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.mapMutex.RLock()
+	defer fake.mapMutex.RUnlock()
+	fake.supportedMutex.RLock()
+	defer fake.supportedMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeIdMapper) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ nfsv3driver.IdMapper = new(FakeIdMapper)
@@ -9,7 +9,7 @@ import (
 )
 
 type FakeIdResolver struct {
-	ResolveStub        func(dockerdriver.Env, string, string) (string, string, error)
+	ResolveStub        func(dockerdriver.Env, string, string) (string, string, []string, error)
 	resolveMutex       sync.RWMutex
 	resolveArgsForCall []struct {
 		arg1 dockerdriver.Env
@@ -19,18 +19,20 @@ type FakeIdResolver struct {
 	resolveReturns struct {
 		result1 string
 		result2 string
-		result3 error
+		result3 []string
+		result4 error
 	}
 	resolveReturnsOnCall map[int]struct {
 		result1 string
 		result2 string
-		result3 error
+		result3 []string
+		result4 error
 	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeIdResolver) Resolve(arg1 dockerdriver.Env, arg2 string, arg3 string) (string, string, error) {
+func (fake *FakeIdResolver) Resolve(arg1 dockerdriver.Env, arg2 string, arg3 string) (string, string, []string, error) {
 	fake.resolveMutex.Lock()
 	ret, specificReturn := fake.resolveReturnsOnCall[len(fake.resolveArgsForCall)]
 	fake.resolveArgsForCall = append(fake.resolveArgsForCall, struct {
@@ -46,9 +48,9 @@ func (fake *FakeIdResolver) Resolve(arg1 dockerdriver.Env, arg2 string, arg3 str
 		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
-		return ret.result1, ret.result2, ret.result3
+		return ret.result1, ret.result2, ret.result3, ret.result4
 	}
-	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3, fakeReturns.result4
 }
 
 func (fake *FakeIdResolver) ResolveCallCount() int {
@@ -57,7 +59,7 @@ func (fake *FakeIdResolver) ResolveCallCount() int {
 	return len(fake.resolveArgsForCall)
 }
 
-func (fake *FakeIdResolver) ResolveCalls(stub func(dockerdriver.Env, string, string) (string, string, error)) {
+func (fake *FakeIdResolver) ResolveCalls(stub func(dockerdriver.Env, string, string) (string, string, []string, error)) {
 	fake.resolveMutex.Lock()
 	defer fake.resolveMutex.Unlock()
 	fake.ResolveStub = stub
@@ -70,18 +72,19 @@ func (fake *FakeIdResolver) ResolveArgsForCall(i int) (dockerdriver.Env, string,
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
-func (fake *FakeIdResolver) ResolveReturns(result1 string, result2 string, result3 error) {
+func (fake *FakeIdResolver) ResolveReturns(result1 string, result2 string, result3 []string, result4 error) {
 	fake.resolveMutex.Lock()
 	defer fake.resolveMutex.Unlock()
 	fake.ResolveStub = nil
 	fake.resolveReturns = struct {
 		result1 string
 		result2 string
-		result3 error
-	}{result1, result2, result3}
+		result3 []string
+		result4 error
+	}{result1, result2, result3, result4}
 }
 
-func (fake *FakeIdResolver) ResolveReturnsOnCall(i int, result1 string, result2 string, result3 error) {
+func (fake *FakeIdResolver) ResolveReturnsOnCall(i int, result1 string, result2 string, result3 []string, result4 error) {
 	fake.resolveMutex.Lock()
 	defer fake.resolveMutex.Unlock()
 	fake.ResolveStub = nil
@@ -89,14 +92,16 @@ func (fake *FakeIdResolver) ResolveReturnsOnCall(i int, result1 string, result2
 		fake.resolveReturnsOnCall = make(map[int]struct {
 			result1 string
 			result2 string
-			result3 error
+			result3 []string
+			result4 error
 		})
 	}
 	fake.resolveReturnsOnCall[i] = struct {
 		result1 string
 		result2 string
-		result3 error
-	}{result1, result2, result3}
+		result3 []string
+		result4 error
+	}{result1, result2, result3, result4}
 }
 
 func (fake *FakeIdResolver) Invocations() map[string][][]interface{} {
@@ -0,0 +1,210 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/nfsv3driver"
+)
+
+type FakeKerberosCredentials struct {
+	AcquireStub        func(dockerdriver.Env, string, string, []byte, []int) error
+	acquireMutex       sync.RWMutex
+	acquireArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 string
+		arg3 string
+		arg4 []byte
+		arg5 []int
+	}
+	acquireReturns struct {
+		result1 error
+	}
+	acquireReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ReleaseStub        func(dockerdriver.Env, string)
+	releaseMutex       sync.RWMutex
+	releaseArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}
+	ReleaseAllStub        func(dockerdriver.Env)
+	releaseAllMutex       sync.RWMutex
+	releaseAllArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeKerberosCredentials) Acquire(arg1 dockerdriver.Env, arg2 string, arg3 string, arg4 []byte, arg5 []int) error {
+	var arg4Copy []byte
+	if arg4 != nil {
+		arg4Copy = make([]byte, len(arg4))
+		copy(arg4Copy, arg4)
+	}
+	var arg5Copy []int
+	if arg5 != nil {
+		arg5Copy = make([]int, len(arg5))
+		copy(arg5Copy, arg5)
+	}
+	fake.acquireMutex.Lock()
+	ret, specificReturn := fake.acquireReturnsOnCall[len(fake.acquireArgsForCall)]
+	fake.acquireArgsForCall = append(fake.acquireArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 string
+		arg3 string
+		arg4 []byte
+		arg5 []int
+	}{arg1, arg2, arg3, arg4Copy, arg5Copy})
+	stub := fake.AcquireStub
+	fakeReturns := fake.acquireReturns
+	fake.recordInvocation("Acquire", []interface{}{arg1, arg2, arg3, arg4Copy, arg5Copy})
+	fake.acquireMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeKerberosCredentials) AcquireCallCount() int {
+	fake.acquireMutex.RLock()
+	defer fake.acquireMutex.RUnlock()
+	return len(fake.acquireArgsForCall)
+}
+
+func (fake *FakeKerberosCredentials) AcquireCalls(stub func(dockerdriver.Env, string, string, []byte, []int) error) {
+	fake.acquireMutex.Lock()
+	defer fake.acquireMutex.Unlock()
+	fake.AcquireStub = stub
+}
+
+func (fake *FakeKerberosCredentials) AcquireArgsForCall(i int) (dockerdriver.Env, string, string, []byte, []int) {
+	fake.acquireMutex.RLock()
+	defer fake.acquireMutex.RUnlock()
+	argsForCall := fake.acquireArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeKerberosCredentials) AcquireReturns(result1 error) {
+	fake.acquireMutex.Lock()
+	defer fake.acquireMutex.Unlock()
+	fake.AcquireStub = nil
+	fake.acquireReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeKerberosCredentials) AcquireReturnsOnCall(i int, result1 error) {
+	fake.acquireMutex.Lock()
+	defer fake.acquireMutex.Unlock()
+	fake.AcquireStub = nil
+	if fake.acquireReturnsOnCall == nil {
+		fake.acquireReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.acquireReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeKerberosCredentials) Release(arg1 dockerdriver.Env, arg2 string) {
+	fake.releaseMutex.Lock()
+	fake.releaseArgsForCall = append(fake.releaseArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ReleaseStub
+	fake.recordInvocation("Release", []interface{}{arg1, arg2})
+	fake.releaseMutex.Unlock()
+	if stub != nil {
+		fake.ReleaseStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeKerberosCredentials) ReleaseCallCount() int {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	return len(fake.releaseArgsForCall)
+}
+
+func (fake *FakeKerberosCredentials) ReleaseCalls(stub func(dockerdriver.Env, string)) {
+	fake.releaseMutex.Lock()
+	defer fake.releaseMutex.Unlock()
+	fake.ReleaseStub = stub
+}
+
+func (fake *FakeKerberosCredentials) ReleaseArgsForCall(i int) (dockerdriver.Env, string) {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	argsForCall := fake.releaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeKerberosCredentials) ReleaseAll(arg1 dockerdriver.Env) {
+	fake.releaseAllMutex.Lock()
+	fake.releaseAllArgsForCall = append(fake.releaseAllArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.ReleaseAllStub
+	fake.recordInvocation("ReleaseAll", []interface{}{arg1})
+	fake.releaseAllMutex.Unlock()
+	if stub != nil {
+		fake.ReleaseAllStub(arg1)
+	}
+}
+
+func (fake *FakeKerberosCredentials) ReleaseAllCallCount() int {
+	fake.releaseAllMutex.RLock()
+	defer fake.releaseAllMutex.RUnlock()
+	return len(fake.releaseAllArgsForCall)
+}
+
+func (fake *FakeKerberosCredentials) ReleaseAllCalls(stub func(dockerdriver.Env)) {
+	fake.releaseAllMutex.Lock()
+	defer fake.releaseAllMutex.Unlock()
+	fake.ReleaseAllStub = stub
+}
+
+func (fake *FakeKerberosCredentials) ReleaseAllArgsForCall(i int) dockerdriver.Env {
+	fake.releaseAllMutex.RLock()
+	defer fake.releaseAllMutex.RUnlock()
+	argsForCall := fake.releaseAllArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeKerberosCredentials) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.acquireMutex.RLock()
+	defer fake.acquireMutex.RUnlock()
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	fake.releaseAllMutex.RLock()
+	defer fake.releaseAllMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeKerberosCredentials) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ nfsv3driver.KerberosCredentials = new(FakeKerberosCredentials)
@@ -0,0 +1,111 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+type FakeMountInspector struct {
+	InspectMountStub        func(string) driveradmin.MountDetails
+	inspectMountMutex       sync.RWMutex
+	inspectMountArgsForCall []struct {
+		arg1 string
+	}
+	inspectMountReturns struct {
+		result1 driveradmin.MountDetails
+	}
+	inspectMountReturnsOnCall map[int]struct {
+		result1 driveradmin.MountDetails
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeMountInspector) InspectMount(arg1 string) driveradmin.MountDetails {
+	fake.inspectMountMutex.Lock()
+	ret, specificReturn := fake.inspectMountReturnsOnCall[len(fake.inspectMountArgsForCall)]
+	fake.inspectMountArgsForCall = append(fake.inspectMountArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.InspectMountStub
+	fakeReturns := fake.inspectMountReturns
+	fake.recordInvocation("InspectMount", []interface{}{arg1})
+	fake.inspectMountMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeMountInspector) InspectMountCallCount() int {
+	fake.inspectMountMutex.RLock()
+	defer fake.inspectMountMutex.RUnlock()
+	return len(fake.inspectMountArgsForCall)
+}
+
+func (fake *FakeMountInspector) InspectMountCalls(stub func(string) driveradmin.MountDetails) {
+	fake.inspectMountMutex.Lock()
+	defer fake.inspectMountMutex.Unlock()
+	fake.InspectMountStub = stub
+}
+
+func (fake *FakeMountInspector) InspectMountArgsForCall(i int) string {
+	fake.inspectMountMutex.RLock()
+	defer fake.inspectMountMutex.RUnlock()
+	argsForCall := fake.inspectMountArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeMountInspector) InspectMountReturns(result1 driveradmin.MountDetails) {
+	fake.inspectMountMutex.Lock()
+	defer fake.inspectMountMutex.Unlock()
+	fake.InspectMountStub = nil
+	fake.inspectMountReturns = struct {
+		result1 driveradmin.MountDetails
+	}{result1}
+}
+
+func (fake *FakeMountInspector) InspectMountReturnsOnCall(i int, result1 driveradmin.MountDetails) {
+	fake.inspectMountMutex.Lock()
+	defer fake.inspectMountMutex.Unlock()
+	fake.InspectMountStub = nil
+	if fake.inspectMountReturnsOnCall == nil {
+		fake.inspectMountReturnsOnCall = make(map[int]struct {
+			result1 driveradmin.MountDetails
+		})
+	}
+	fake.inspectMountReturnsOnCall[i] = struct {
+		result1 driveradmin.MountDetails
+	}{result1}
+}
+
+func (fake *FakeMountInspector) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.inspectMountMutex.RLock()
+	defer fake.inspectMountMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeMountInspector) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driveradmin.MountInspector = new(FakeMountInspector)
@@ -0,0 +1,190 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+type FakeMountRecoverer struct {
+	ForceUnmountStub        func(dockerdriver.Env, string) error
+	forceUnmountMutex       sync.RWMutex
+	forceUnmountArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}
+	forceUnmountReturns struct {
+		result1 error
+	}
+	forceUnmountReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RemountStub        func(dockerdriver.Env, string) error
+	remountMutex       sync.RWMutex
+	remountArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}
+	remountReturns struct {
+		result1 error
+	}
+	remountReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeMountRecoverer) ForceUnmount(arg1 dockerdriver.Env, arg2 string) error {
+	fake.forceUnmountMutex.Lock()
+	ret, specificReturn := fake.forceUnmountReturnsOnCall[len(fake.forceUnmountArgsForCall)]
+	fake.forceUnmountArgsForCall = append(fake.forceUnmountArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ForceUnmountStub
+	fakeReturns := fake.forceUnmountReturns
+	fake.recordInvocation("ForceUnmount", []interface{}{arg1, arg2})
+	fake.forceUnmountMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeMountRecoverer) ForceUnmountCallCount() int {
+	fake.forceUnmountMutex.RLock()
+	defer fake.forceUnmountMutex.RUnlock()
+	return len(fake.forceUnmountArgsForCall)
+}
+
+func (fake *FakeMountRecoverer) ForceUnmountCalls(stub func(dockerdriver.Env, string) error) {
+	fake.forceUnmountMutex.Lock()
+	defer fake.forceUnmountMutex.Unlock()
+	fake.ForceUnmountStub = stub
+}
+
+func (fake *FakeMountRecoverer) ForceUnmountArgsForCall(i int) (dockerdriver.Env, string) {
+	fake.forceUnmountMutex.RLock()
+	defer fake.forceUnmountMutex.RUnlock()
+	argsForCall := fake.forceUnmountArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeMountRecoverer) ForceUnmountReturns(result1 error) {
+	fake.forceUnmountMutex.Lock()
+	defer fake.forceUnmountMutex.Unlock()
+	fake.ForceUnmountStub = nil
+	fake.forceUnmountReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMountRecoverer) ForceUnmountReturnsOnCall(i int, result1 error) {
+	fake.forceUnmountMutex.Lock()
+	defer fake.forceUnmountMutex.Unlock()
+	fake.ForceUnmountStub = nil
+	if fake.forceUnmountReturnsOnCall == nil {
+		fake.forceUnmountReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.forceUnmountReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMountRecoverer) Remount(arg1 dockerdriver.Env, arg2 string) error {
+	fake.remountMutex.Lock()
+	ret, specificReturn := fake.remountReturnsOnCall[len(fake.remountArgsForCall)]
+	fake.remountArgsForCall = append(fake.remountArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.RemountStub
+	fakeReturns := fake.remountReturns
+	fake.recordInvocation("Remount", []interface{}{arg1, arg2})
+	fake.remountMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeMountRecoverer) RemountCallCount() int {
+	fake.remountMutex.RLock()
+	defer fake.remountMutex.RUnlock()
+	return len(fake.remountArgsForCall)
+}
+
+func (fake *FakeMountRecoverer) RemountCalls(stub func(dockerdriver.Env, string) error) {
+	fake.remountMutex.Lock()
+	defer fake.remountMutex.Unlock()
+	fake.RemountStub = stub
+}
+
+func (fake *FakeMountRecoverer) RemountArgsForCall(i int) (dockerdriver.Env, string) {
+	fake.remountMutex.RLock()
+	defer fake.remountMutex.RUnlock()
+	argsForCall := fake.remountArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeMountRecoverer) RemountReturns(result1 error) {
+	fake.remountMutex.Lock()
+	defer fake.remountMutex.Unlock()
+	fake.RemountStub = nil
+	fake.remountReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMountRecoverer) RemountReturnsOnCall(i int, result1 error) {
+	fake.remountMutex.Lock()
+	defer fake.remountMutex.Unlock()
+	fake.RemountStub = nil
+	if fake.remountReturnsOnCall == nil {
+		fake.remountReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.remountReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMountRecoverer) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.forceUnmountMutex.RLock()
+	defer fake.forceUnmountMutex.RUnlock()
+	fake.remountMutex.RLock()
+	defer fake.remountMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeMountRecoverer) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driveradmin.MountRecoverer = new(FakeMountRecoverer)
@@ -0,0 +1,272 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+	"syscall"
+
+	"code.cloudfoundry.org/nfsv3driver"
+)
+
+type FakeMountSyscall struct {
+	MountStub        func(string, string, string, uintptr, string) error
+	mountMutex       sync.RWMutex
+	mountArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 uintptr
+		arg5 string
+	}
+	mountReturns struct {
+		result1 error
+	}
+	mountReturnsOnCall map[int]struct {
+		result1 error
+	}
+	StatfsStub        func(string, *syscall.Statfs_t) error
+	statfsMutex       sync.RWMutex
+	statfsArgsForCall []struct {
+		arg1 string
+		arg2 *syscall.Statfs_t
+	}
+	statfsReturns struct {
+		result1 error
+	}
+	statfsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UnmountStub        func(string, int) error
+	unmountMutex       sync.RWMutex
+	unmountArgsForCall []struct {
+		arg1 string
+		arg2 int
+	}
+	unmountReturns struct {
+		result1 error
+	}
+	unmountReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeMountSyscall) Mount(arg1 string, arg2 string, arg3 string, arg4 uintptr, arg5 string) error {
+	fake.mountMutex.Lock()
+	ret, specificReturn := fake.mountReturnsOnCall[len(fake.mountArgsForCall)]
+	fake.mountArgsForCall = append(fake.mountArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+		arg4 uintptr
+		arg5 string
+	}{arg1, arg2, arg3, arg4, arg5})
+	stub := fake.MountStub
+	fakeReturns := fake.mountReturns
+	fake.recordInvocation("Mount", []interface{}{arg1, arg2, arg3, arg4, arg5})
+	fake.mountMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4, arg5)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeMountSyscall) MountCallCount() int {
+	fake.mountMutex.RLock()
+	defer fake.mountMutex.RUnlock()
+	return len(fake.mountArgsForCall)
+}
+
+func (fake *FakeMountSyscall) MountCalls(stub func(string, string, string, uintptr, string) error) {
+	fake.mountMutex.Lock()
+	defer fake.mountMutex.Unlock()
+	fake.MountStub = stub
+}
+
+func (fake *FakeMountSyscall) MountArgsForCall(i int) (string, string, string, uintptr, string) {
+	fake.mountMutex.RLock()
+	defer fake.mountMutex.RUnlock()
+	argsForCall := fake.mountArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
+}
+
+func (fake *FakeMountSyscall) MountReturns(result1 error) {
+	fake.mountMutex.Lock()
+	defer fake.mountMutex.Unlock()
+	fake.MountStub = nil
+	fake.mountReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMountSyscall) MountReturnsOnCall(i int, result1 error) {
+	fake.mountMutex.Lock()
+	defer fake.mountMutex.Unlock()
+	fake.MountStub = nil
+	if fake.mountReturnsOnCall == nil {
+		fake.mountReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.mountReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMountSyscall) Statfs(arg1 string, arg2 *syscall.Statfs_t) error {
+	fake.statfsMutex.Lock()
+	ret, specificReturn := fake.statfsReturnsOnCall[len(fake.statfsArgsForCall)]
+	fake.statfsArgsForCall = append(fake.statfsArgsForCall, struct {
+		arg1 string
+		arg2 *syscall.Statfs_t
+	}{arg1, arg2})
+	stub := fake.StatfsStub
+	fakeReturns := fake.statfsReturns
+	fake.recordInvocation("Statfs", []interface{}{arg1, arg2})
+	fake.statfsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeMountSyscall) StatfsCallCount() int {
+	fake.statfsMutex.RLock()
+	defer fake.statfsMutex.RUnlock()
+	return len(fake.statfsArgsForCall)
+}
+
+func (fake *FakeMountSyscall) StatfsCalls(stub func(string, *syscall.Statfs_t) error) {
+	fake.statfsMutex.Lock()
+	defer fake.statfsMutex.Unlock()
+	fake.StatfsStub = stub
+}
+
+func (fake *FakeMountSyscall) StatfsArgsForCall(i int) (string, *syscall.Statfs_t) {
+	fake.statfsMutex.RLock()
+	defer fake.statfsMutex.RUnlock()
+	argsForCall := fake.statfsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeMountSyscall) StatfsReturns(result1 error) {
+	fake.statfsMutex.Lock()
+	defer fake.statfsMutex.Unlock()
+	fake.StatfsStub = nil
+	fake.statfsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMountSyscall) StatfsReturnsOnCall(i int, result1 error) {
+	fake.statfsMutex.Lock()
+	defer fake.statfsMutex.Unlock()
+	fake.StatfsStub = nil
+	if fake.statfsReturnsOnCall == nil {
+		fake.statfsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.statfsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMountSyscall) Unmount(arg1 string, arg2 int) error {
+	fake.unmountMutex.Lock()
+	ret, specificReturn := fake.unmountReturnsOnCall[len(fake.unmountArgsForCall)]
+	fake.unmountArgsForCall = append(fake.unmountArgsForCall, struct {
+		arg1 string
+		arg2 int
+	}{arg1, arg2})
+	stub := fake.UnmountStub
+	fakeReturns := fake.unmountReturns
+	fake.recordInvocation("Unmount", []interface{}{arg1, arg2})
+	fake.unmountMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeMountSyscall) UnmountCallCount() int {
+	fake.unmountMutex.RLock()
+	defer fake.unmountMutex.RUnlock()
+	return len(fake.unmountArgsForCall)
+}
+
+func (fake *FakeMountSyscall) UnmountCalls(stub func(string, int) error) {
+	fake.unmountMutex.Lock()
+	defer fake.unmountMutex.Unlock()
+	fake.UnmountStub = stub
+}
+
+func (fake *FakeMountSyscall) UnmountArgsForCall(i int) (string, int) {
+	fake.unmountMutex.RLock()
+	defer fake.unmountMutex.RUnlock()
+	argsForCall := fake.unmountArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeMountSyscall) UnmountReturns(result1 error) {
+	fake.unmountMutex.Lock()
+	defer fake.unmountMutex.Unlock()
+	fake.UnmountStub = nil
+	fake.unmountReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMountSyscall) UnmountReturnsOnCall(i int, result1 error) {
+	fake.unmountMutex.Lock()
+	defer fake.unmountMutex.Unlock()
+	fake.UnmountStub = nil
+	if fake.unmountReturnsOnCall == nil {
+		fake.unmountReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.unmountReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMountSyscall) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.mountMutex.RLock()
+	defer fake.mountMutex.RUnlock()
+	fake.statfsMutex.RLock()
+	defer fake.statfsMutex.RUnlock()
+	fake.unmountMutex.RLock()
+	defer fake.unmountMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeMountSyscall) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ nfsv3driver.MountSyscall = new(FakeMountSyscall)
@@ -0,0 +1,112 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+type FakeReadinessChecker struct {
+	CheckReadinessStub        func(dockerdriver.Env) []driveradmin.ReadinessCheck
+	checkReadinessMutex       sync.RWMutex
+	checkReadinessArgsForCall []struct {
+		arg1 dockerdriver.Env
+	}
+	checkReadinessReturns struct {
+		result1 []driveradmin.ReadinessCheck
+	}
+	checkReadinessReturnsOnCall map[int]struct {
+		result1 []driveradmin.ReadinessCheck
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeReadinessChecker) CheckReadiness(arg1 dockerdriver.Env) []driveradmin.ReadinessCheck {
+	fake.checkReadinessMutex.Lock()
+	ret, specificReturn := fake.checkReadinessReturnsOnCall[len(fake.checkReadinessArgsForCall)]
+	fake.checkReadinessArgsForCall = append(fake.checkReadinessArgsForCall, struct {
+		arg1 dockerdriver.Env
+	}{arg1})
+	stub := fake.CheckReadinessStub
+	fakeReturns := fake.checkReadinessReturns
+	fake.recordInvocation("CheckReadiness", []interface{}{arg1})
+	fake.checkReadinessMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeReadinessChecker) CheckReadinessCallCount() int {
+	fake.checkReadinessMutex.RLock()
+	defer fake.checkReadinessMutex.RUnlock()
+	return len(fake.checkReadinessArgsForCall)
+}
+
+func (fake *FakeReadinessChecker) CheckReadinessCalls(stub func(dockerdriver.Env) []driveradmin.ReadinessCheck) {
+	fake.checkReadinessMutex.Lock()
+	defer fake.checkReadinessMutex.Unlock()
+	fake.CheckReadinessStub = stub
+}
+
+func (fake *FakeReadinessChecker) CheckReadinessArgsForCall(i int) dockerdriver.Env {
+	fake.checkReadinessMutex.RLock()
+	defer fake.checkReadinessMutex.RUnlock()
+	argsForCall := fake.checkReadinessArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeReadinessChecker) CheckReadinessReturns(result1 []driveradmin.ReadinessCheck) {
+	fake.checkReadinessMutex.Lock()
+	defer fake.checkReadinessMutex.Unlock()
+	fake.CheckReadinessStub = nil
+	fake.checkReadinessReturns = struct {
+		result1 []driveradmin.ReadinessCheck
+	}{result1}
+}
+
+func (fake *FakeReadinessChecker) CheckReadinessReturnsOnCall(i int, result1 []driveradmin.ReadinessCheck) {
+	fake.checkReadinessMutex.Lock()
+	defer fake.checkReadinessMutex.Unlock()
+	fake.CheckReadinessStub = nil
+	if fake.checkReadinessReturnsOnCall == nil {
+		fake.checkReadinessReturnsOnCall = make(map[int]struct {
+			result1 []driveradmin.ReadinessCheck
+		})
+	}
+	fake.checkReadinessReturnsOnCall[i] = struct {
+		result1 []driveradmin.ReadinessCheck
+	}{result1}
+}
+
+func (fake *FakeReadinessChecker) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.checkReadinessMutex.RLock()
+	defer fake.checkReadinessMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeReadinessChecker) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driveradmin.ReadinessChecker = new(FakeReadinessChecker)
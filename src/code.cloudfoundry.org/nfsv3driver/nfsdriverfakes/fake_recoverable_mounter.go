@@ -0,0 +1,389 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/nfsv3driver"
+)
+
+type FakeRecoverableMounter struct {
+	CheckStub        func(dockerdriver.Env, string, string) bool
+	checkMutex       sync.RWMutex
+	checkArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 string
+		arg3 string
+	}
+	checkReturns struct {
+		result1 bool
+	}
+	checkReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	MountStub        func(dockerdriver.Env, string, string, map[string]interface{}) error
+	mountMutex       sync.RWMutex
+	mountArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 string
+		arg3 string
+		arg4 map[string]interface{}
+	}
+	mountReturns struct {
+		result1 error
+	}
+	mountReturnsOnCall map[int]struct {
+		result1 error
+	}
+	PurgeStub        func(dockerdriver.Env, string)
+	purgeMutex       sync.RWMutex
+	purgeArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}
+	RemountStub        func(dockerdriver.Env, string) error
+	remountMutex       sync.RWMutex
+	remountArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}
+	remountReturns struct {
+		result1 error
+	}
+	remountReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UnmountStub        func(dockerdriver.Env, string) error
+	unmountMutex       sync.RWMutex
+	unmountArgsForCall []struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}
+	unmountReturns struct {
+		result1 error
+	}
+	unmountReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeRecoverableMounter) Check(arg1 dockerdriver.Env, arg2 string, arg3 string) bool {
+	fake.checkMutex.Lock()
+	ret, specificReturn := fake.checkReturnsOnCall[len(fake.checkArgsForCall)]
+	fake.checkArgsForCall = append(fake.checkArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.CheckStub
+	fakeReturns := fake.checkReturns
+	fake.recordInvocation("Check", []interface{}{arg1, arg2, arg3})
+	fake.checkMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRecoverableMounter) CheckCallCount() int {
+	fake.checkMutex.RLock()
+	defer fake.checkMutex.RUnlock()
+	return len(fake.checkArgsForCall)
+}
+
+func (fake *FakeRecoverableMounter) CheckCalls(stub func(dockerdriver.Env, string, string) bool) {
+	fake.checkMutex.Lock()
+	defer fake.checkMutex.Unlock()
+	fake.CheckStub = stub
+}
+
+func (fake *FakeRecoverableMounter) CheckArgsForCall(i int) (dockerdriver.Env, string, string) {
+	fake.checkMutex.RLock()
+	defer fake.checkMutex.RUnlock()
+	argsForCall := fake.checkArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeRecoverableMounter) CheckReturns(result1 bool) {
+	fake.checkMutex.Lock()
+	defer fake.checkMutex.Unlock()
+	fake.CheckStub = nil
+	fake.checkReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeRecoverableMounter) CheckReturnsOnCall(i int, result1 bool) {
+	fake.checkMutex.Lock()
+	defer fake.checkMutex.Unlock()
+	fake.CheckStub = nil
+	if fake.checkReturnsOnCall == nil {
+		fake.checkReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.checkReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeRecoverableMounter) Mount(arg1 dockerdriver.Env, arg2 string, arg3 string, arg4 map[string]interface{}) error {
+	fake.mountMutex.Lock()
+	ret, specificReturn := fake.mountReturnsOnCall[len(fake.mountArgsForCall)]
+	fake.mountArgsForCall = append(fake.mountArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 string
+		arg3 string
+		arg4 map[string]interface{}
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.MountStub
+	fakeReturns := fake.mountReturns
+	fake.recordInvocation("Mount", []interface{}{arg1, arg2, arg3, arg4})
+	fake.mountMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRecoverableMounter) MountCallCount() int {
+	fake.mountMutex.RLock()
+	defer fake.mountMutex.RUnlock()
+	return len(fake.mountArgsForCall)
+}
+
+func (fake *FakeRecoverableMounter) MountCalls(stub func(dockerdriver.Env, string, string, map[string]interface{}) error) {
+	fake.mountMutex.Lock()
+	defer fake.mountMutex.Unlock()
+	fake.MountStub = stub
+}
+
+func (fake *FakeRecoverableMounter) MountArgsForCall(i int) (dockerdriver.Env, string, string, map[string]interface{}) {
+	fake.mountMutex.RLock()
+	defer fake.mountMutex.RUnlock()
+	argsForCall := fake.mountArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeRecoverableMounter) MountReturns(result1 error) {
+	fake.mountMutex.Lock()
+	defer fake.mountMutex.Unlock()
+	fake.MountStub = nil
+	fake.mountReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRecoverableMounter) MountReturnsOnCall(i int, result1 error) {
+	fake.mountMutex.Lock()
+	defer fake.mountMutex.Unlock()
+	fake.MountStub = nil
+	if fake.mountReturnsOnCall == nil {
+		fake.mountReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.mountReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRecoverableMounter) Purge(arg1 dockerdriver.Env, arg2 string) {
+	fake.purgeMutex.Lock()
+	fake.purgeArgsForCall = append(fake.purgeArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.PurgeStub
+	fake.recordInvocation("Purge", []interface{}{arg1, arg2})
+	fake.purgeMutex.Unlock()
+	if stub != nil {
+		fake.PurgeStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeRecoverableMounter) PurgeCallCount() int {
+	fake.purgeMutex.RLock()
+	defer fake.purgeMutex.RUnlock()
+	return len(fake.purgeArgsForCall)
+}
+
+func (fake *FakeRecoverableMounter) PurgeCalls(stub func(dockerdriver.Env, string)) {
+	fake.purgeMutex.Lock()
+	defer fake.purgeMutex.Unlock()
+	fake.PurgeStub = stub
+}
+
+func (fake *FakeRecoverableMounter) PurgeArgsForCall(i int) (dockerdriver.Env, string) {
+	fake.purgeMutex.RLock()
+	defer fake.purgeMutex.RUnlock()
+	argsForCall := fake.purgeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRecoverableMounter) Remount(arg1 dockerdriver.Env, arg2 string) error {
+	fake.remountMutex.Lock()
+	ret, specificReturn := fake.remountReturnsOnCall[len(fake.remountArgsForCall)]
+	fake.remountArgsForCall = append(fake.remountArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.RemountStub
+	fakeReturns := fake.remountReturns
+	fake.recordInvocation("Remount", []interface{}{arg1, arg2})
+	fake.remountMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRecoverableMounter) RemountCallCount() int {
+	fake.remountMutex.RLock()
+	defer fake.remountMutex.RUnlock()
+	return len(fake.remountArgsForCall)
+}
+
+func (fake *FakeRecoverableMounter) RemountCalls(stub func(dockerdriver.Env, string) error) {
+	fake.remountMutex.Lock()
+	defer fake.remountMutex.Unlock()
+	fake.RemountStub = stub
+}
+
+func (fake *FakeRecoverableMounter) RemountArgsForCall(i int) (dockerdriver.Env, string) {
+	fake.remountMutex.RLock()
+	defer fake.remountMutex.RUnlock()
+	argsForCall := fake.remountArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRecoverableMounter) RemountReturns(result1 error) {
+	fake.remountMutex.Lock()
+	defer fake.remountMutex.Unlock()
+	fake.RemountStub = nil
+	fake.remountReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRecoverableMounter) RemountReturnsOnCall(i int, result1 error) {
+	fake.remountMutex.Lock()
+	defer fake.remountMutex.Unlock()
+	fake.RemountStub = nil
+	if fake.remountReturnsOnCall == nil {
+		fake.remountReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.remountReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRecoverableMounter) Unmount(arg1 dockerdriver.Env, arg2 string) error {
+	fake.unmountMutex.Lock()
+	ret, specificReturn := fake.unmountReturnsOnCall[len(fake.unmountArgsForCall)]
+	fake.unmountArgsForCall = append(fake.unmountArgsForCall, struct {
+		arg1 dockerdriver.Env
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.UnmountStub
+	fakeReturns := fake.unmountReturns
+	fake.recordInvocation("Unmount", []interface{}{arg1, arg2})
+	fake.unmountMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRecoverableMounter) UnmountCallCount() int {
+	fake.unmountMutex.RLock()
+	defer fake.unmountMutex.RUnlock()
+	return len(fake.unmountArgsForCall)
+}
+
+func (fake *FakeRecoverableMounter) UnmountCalls(stub func(dockerdriver.Env, string) error) {
+	fake.unmountMutex.Lock()
+	defer fake.unmountMutex.Unlock()
+	fake.UnmountStub = stub
+}
+
+func (fake *FakeRecoverableMounter) UnmountArgsForCall(i int) (dockerdriver.Env, string) {
+	fake.unmountMutex.RLock()
+	defer fake.unmountMutex.RUnlock()
+	argsForCall := fake.unmountArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeRecoverableMounter) UnmountReturns(result1 error) {
+	fake.unmountMutex.Lock()
+	defer fake.unmountMutex.Unlock()
+	fake.UnmountStub = nil
+	fake.unmountReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRecoverableMounter) UnmountReturnsOnCall(i int, result1 error) {
+	fake.unmountMutex.Lock()
+	defer fake.unmountMutex.Unlock()
+	fake.UnmountStub = nil
+	if fake.unmountReturnsOnCall == nil {
+		fake.unmountReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.unmountReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRecoverableMounter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.checkMutex.RLock()
+	defer fake.checkMutex.RUnlock()
+	fake.mountMutex.RLock()
+	defer fake.mountMutex.RUnlock()
+	fake.purgeMutex.RLock()
+	defer fake.purgeMutex.RUnlock()
+	fake.remountMutex.RLock()
+	defer fake.remountMutex.RUnlock()
+	fake.unmountMutex.RLock()
+	defer fake.unmountMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeRecoverableMounter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ nfsv3driver.RecoverableMounter = new(FakeRecoverableMounter)
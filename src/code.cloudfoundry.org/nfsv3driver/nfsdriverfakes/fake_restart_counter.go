@@ -0,0 +1,102 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+type FakeRestartCounter struct {
+	MapfsRestartsStub        func() map[string]int
+	mapfsRestartsMutex       sync.RWMutex
+	mapfsRestartsArgsForCall []struct {
+	}
+	mapfsRestartsReturns struct {
+		result1 map[string]int
+	}
+	mapfsRestartsReturnsOnCall map[int]struct {
+		result1 map[string]int
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeRestartCounter) MapfsRestarts() map[string]int {
+	fake.mapfsRestartsMutex.Lock()
+	ret, specificReturn := fake.mapfsRestartsReturnsOnCall[len(fake.mapfsRestartsArgsForCall)]
+	fake.mapfsRestartsArgsForCall = append(fake.mapfsRestartsArgsForCall, struct {
+	}{})
+	stub := fake.MapfsRestartsStub
+	fakeReturns := fake.mapfsRestartsReturns
+	fake.recordInvocation("MapfsRestarts", []interface{}{})
+	fake.mapfsRestartsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeRestartCounter) MapfsRestartsCallCount() int {
+	fake.mapfsRestartsMutex.RLock()
+	defer fake.mapfsRestartsMutex.RUnlock()
+	return len(fake.mapfsRestartsArgsForCall)
+}
+
+func (fake *FakeRestartCounter) MapfsRestartsCalls(stub func() map[string]int) {
+	fake.mapfsRestartsMutex.Lock()
+	defer fake.mapfsRestartsMutex.Unlock()
+	fake.MapfsRestartsStub = stub
+}
+
+func (fake *FakeRestartCounter) MapfsRestartsReturns(result1 map[string]int) {
+	fake.mapfsRestartsMutex.Lock()
+	defer fake.mapfsRestartsMutex.Unlock()
+	fake.MapfsRestartsStub = nil
+	fake.mapfsRestartsReturns = struct {
+		result1 map[string]int
+	}{result1}
+}
+
+func (fake *FakeRestartCounter) MapfsRestartsReturnsOnCall(i int, result1 map[string]int) {
+	fake.mapfsRestartsMutex.Lock()
+	defer fake.mapfsRestartsMutex.Unlock()
+	fake.MapfsRestartsStub = nil
+	if fake.mapfsRestartsReturnsOnCall == nil {
+		fake.mapfsRestartsReturnsOnCall = make(map[int]struct {
+			result1 map[string]int
+		})
+	}
+	fake.mapfsRestartsReturnsOnCall[i] = struct {
+		result1 map[string]int
+	}{result1}
+}
+
+func (fake *FakeRestartCounter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.mapfsRestartsMutex.RLock()
+	defer fake.mapfsRestartsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeRestartCounter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driveradmin.RestartCounter = new(FakeRestartCounter)
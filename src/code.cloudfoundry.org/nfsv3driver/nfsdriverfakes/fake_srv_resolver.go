@@ -0,0 +1,129 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"code.cloudfoundry.org/nfsv3driver"
+)
+
+type FakeSrvResolver struct {
+	LookupSRVStub        func(context.Context, string, string, string) (string, []*net.SRV, error)
+	lookupSRVMutex       sync.RWMutex
+	lookupSRVArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+	}
+	lookupSRVReturns struct {
+		result1 string
+		result2 []*net.SRV
+		result3 error
+	}
+	lookupSRVReturnsOnCall map[int]struct {
+		result1 string
+		result2 []*net.SRV
+		result3 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeSrvResolver) LookupSRV(arg1 context.Context, arg2 string, arg3 string, arg4 string) (string, []*net.SRV, error) {
+	fake.lookupSRVMutex.Lock()
+	ret, specificReturn := fake.lookupSRVReturnsOnCall[len(fake.lookupSRVArgsForCall)]
+	fake.lookupSRVArgsForCall = append(fake.lookupSRVArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 string
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.LookupSRVStub
+	fakeReturns := fake.lookupSRVReturns
+	fake.recordInvocation("LookupSRV", []interface{}{arg1, arg2, arg3, arg4})
+	fake.lookupSRVMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *FakeSrvResolver) LookupSRVCallCount() int {
+	fake.lookupSRVMutex.RLock()
+	defer fake.lookupSRVMutex.RUnlock()
+	return len(fake.lookupSRVArgsForCall)
+}
+
+func (fake *FakeSrvResolver) LookupSRVCalls(stub func(context.Context, string, string, string) (string, []*net.SRV, error)) {
+	fake.lookupSRVMutex.Lock()
+	defer fake.lookupSRVMutex.Unlock()
+	fake.LookupSRVStub = stub
+}
+
+func (fake *FakeSrvResolver) LookupSRVArgsForCall(i int) (context.Context, string, string, string) {
+	fake.lookupSRVMutex.RLock()
+	defer fake.lookupSRVMutex.RUnlock()
+	argsForCall := fake.lookupSRVArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeSrvResolver) LookupSRVReturns(result1 string, result2 []*net.SRV, result3 error) {
+	fake.lookupSRVMutex.Lock()
+	defer fake.lookupSRVMutex.Unlock()
+	fake.LookupSRVStub = nil
+	fake.lookupSRVReturns = struct {
+		result1 string
+		result2 []*net.SRV
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeSrvResolver) LookupSRVReturnsOnCall(i int, result1 string, result2 []*net.SRV, result3 error) {
+	fake.lookupSRVMutex.Lock()
+	defer fake.lookupSRVMutex.Unlock()
+	fake.LookupSRVStub = nil
+	if fake.lookupSRVReturnsOnCall == nil {
+		fake.lookupSRVReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 []*net.SRV
+			result3 error
+		})
+	}
+	fake.lookupSRVReturnsOnCall[i] = struct {
+		result1 string
+		result2 []*net.SRV
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeSrvResolver) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.lookupSRVMutex.RLock()
+	defer fake.lookupSRVMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeSrvResolver) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ nfsv3driver.SrvResolver = new(FakeSrvResolver)
@@ -0,0 +1,112 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package nfsdriverfakes
+
+import (
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/nfsv3driver/driveradmin"
+)
+
+type FakeUnmountFailureReporter struct {
+	UnmountFailuresStub        func(time.Time) map[string]string
+	unmountFailuresMutex       sync.RWMutex
+	unmountFailuresArgsForCall []struct {
+		arg1 time.Time
+	}
+	unmountFailuresReturns struct {
+		result1 map[string]string
+	}
+	unmountFailuresReturnsOnCall map[int]struct {
+		result1 map[string]string
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeUnmountFailureReporter) UnmountFailures(arg1 time.Time) map[string]string {
+	fake.unmountFailuresMutex.Lock()
+	ret, specificReturn := fake.unmountFailuresReturnsOnCall[len(fake.unmountFailuresArgsForCall)]
+	fake.unmountFailuresArgsForCall = append(fake.unmountFailuresArgsForCall, struct {
+		arg1 time.Time
+	}{arg1})
+	stub := fake.UnmountFailuresStub
+	fakeReturns := fake.unmountFailuresReturns
+	fake.recordInvocation("UnmountFailures", []interface{}{arg1})
+	fake.unmountFailuresMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeUnmountFailureReporter) UnmountFailuresCallCount() int {
+	fake.unmountFailuresMutex.RLock()
+	defer fake.unmountFailuresMutex.RUnlock()
+	return len(fake.unmountFailuresArgsForCall)
+}
+
+func (fake *FakeUnmountFailureReporter) UnmountFailuresCalls(stub func(time.Time) map[string]string) {
+	fake.unmountFailuresMutex.Lock()
+	defer fake.unmountFailuresMutex.Unlock()
+	fake.UnmountFailuresStub = stub
+}
+
+func (fake *FakeUnmountFailureReporter) UnmountFailuresArgsForCall(i int) time.Time {
+	fake.unmountFailuresMutex.RLock()
+	defer fake.unmountFailuresMutex.RUnlock()
+	argsForCall := fake.unmountFailuresArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeUnmountFailureReporter) UnmountFailuresReturns(result1 map[string]string) {
+	fake.unmountFailuresMutex.Lock()
+	defer fake.unmountFailuresMutex.Unlock()
+	fake.UnmountFailuresStub = nil
+	fake.unmountFailuresReturns = struct {
+		result1 map[string]string
+	}{result1}
+}
+
+func (fake *FakeUnmountFailureReporter) UnmountFailuresReturnsOnCall(i int, result1 map[string]string) {
+	fake.unmountFailuresMutex.Lock()
+	defer fake.unmountFailuresMutex.Unlock()
+	fake.UnmountFailuresStub = nil
+	if fake.unmountFailuresReturnsOnCall == nil {
+		fake.unmountFailuresReturnsOnCall = make(map[int]struct {
+			result1 map[string]string
+		})
+	}
+	fake.unmountFailuresReturnsOnCall[i] = struct {
+		result1 map[string]string
+	}{result1}
+}
+
+func (fake *FakeUnmountFailureReporter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.unmountFailuresMutex.RLock()
+	defer fake.unmountFailuresMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeUnmountFailureReporter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ driveradmin.UnmountFailureReporter = new(FakeUnmountFailureReporter)
@@ -0,0 +1,135 @@
+package nfsv3driver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+//counterfeiter:generate -o nfsdriverfakes/fake_host_resolver.go . HostResolver
+
+// HostResolver resolves server names; *net.Resolver implements it.
+type HostResolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// ServerAllowlist restricts the NFS servers that volumes may be mounted from.
+type ServerAllowlist struct {
+	resolver HostResolver
+	hosts    map[string]bool
+	domains  []string
+	networks []*net.IPNet
+}
+
+// NewServerAllowlist parses entries that are either a host name ("nfs.example.com"), a domain whose
+// hosts are all allowed (".example.com" or "*.example.com"), an IP address or a CIDR ("10.0.0.0/16").
+func NewServerAllowlist(resolver HostResolver, entries []string) (*ServerAllowlist, error) {
+	allowlist := &ServerAllowlist{resolver: resolver, hosts: map[string]bool{}}
+
+	for _, entry := range entries {
+		entry = normalizeHost(entry)
+		switch {
+		case entry == "":
+			continue
+		case strings.Contains(entry, "/"):
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid allowed server %q: %s", entry, err.Error())
+			}
+			allowlist.networks = append(allowlist.networks, network)
+		case net.ParseIP(entry) != nil:
+			ip := net.ParseIP(entry)
+			bits := 8 * len(ip)
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			allowlist.networks = append(allowlist.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		case strings.HasPrefix(entry, "*.") || strings.HasPrefix(entry, "."):
+			allowlist.domains = append(allowlist.domains, "."+strings.TrimLeft(entry, "*."))
+		default:
+			allowlist.hosts[entry] = true
+		}
+	}
+
+	return allowlist, nil
+}
+
+// WithServerAllowlist rejects mounts of shares whose server is not allowed by allowlist.
+func WithServerAllowlist(allowlist *ServerAllowlist) MapfsMounterOption {
+	return func(m *mapfsMounter) {
+		m.allowlist = allowlist
+	}
+}
+
+// Check returns a SafeError unless the server of remote, a share of the form host:/path, is allowed, and
+// otherwise the share to mount. A server name is allowed when it matches a host or domain entry, and is
+// then mounted by name: those entries trust DNS to resolve it. Any other name is allowed when every
+// address it resolves to is in an allowed network, and the share is then mounted from the first of those
+// addresses so that the kernel does not resolve the name again to an address that was not checked.
+func (a *ServerAllowlist) Check(env dockerdriver.Env, remote string) (string, error) {
+	host, path, err := splitRemote(remote)
+	if err != nil {
+		return "", dockerdriver.SafeError{SafeDescription: "Invalid 'share' option"}
+	}
+	host = normalizeHost(host)
+
+	if ip := net.ParseIP(host); ip != nil {
+		if a.allowedIP(ip) {
+			return remote, nil
+		}
+		return "", a.notAllowed(host)
+	}
+
+	if a.hosts[host] {
+		return remote, nil
+	}
+	for _, domain := range a.domains {
+		if strings.HasSuffix(host, domain) {
+			return remote, nil
+		}
+	}
+
+	if len(a.networks) == 0 {
+		return "", a.notAllowed(host)
+	}
+
+	addrs, err := a.resolver.LookupIPAddr(env.Context(), host)
+	if err != nil {
+		env.Logger().Error("resolve-server-failed", err, lager.Data{"host": host})
+		return "", dockerdriver.SafeError{SafeDescription: fmt.Sprintf("NFS server %q could not be resolved to check it against the allowed servers", host)}
+	}
+	if len(addrs) == 0 {
+		return "", a.notAllowed(host)
+	}
+	for _, addr := range addrs {
+		if !a.allowedIP(addr.IP) {
+			return "", a.notAllowed(host)
+		}
+	}
+
+	if addrs[0].IP.To4() == nil {
+		return "[" + addrs[0].IP.String() + "]:" + path, nil
+	}
+	return addrs[0].IP.String() + ":" + path, nil
+}
+
+func (a *ServerAllowlist) allowedIP(ip net.IP) bool {
+	for _, network := range a.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *ServerAllowlist) notAllowed(host string) error {
+	return dockerdriver.SafeError{SafeDescription: fmt.Sprintf("NFS server %q is not in the list of servers allowed on this cell", host)}
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
@@ -0,0 +1,112 @@
+package nfsv3driver_test
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"code.cloudfoundry.org/dockerdriver"
+	"code.cloudfoundry.org/dockerdriver/driverhttp"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/nfsv3driver"
+	"code.cloudfoundry.org/nfsv3driver/nfsdriverfakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ServerAllowlist", func() {
+	var (
+		env          dockerdriver.Env
+		fakeResolver *nfsdriverfakes.FakeHostResolver
+		allowlist    *nfsv3driver.ServerAllowlist
+	)
+
+	BeforeEach(func() {
+		env = driverhttp.NewHttpDriverEnv(lagertest.NewTestLogger("server-allowlist"), context.TODO())
+		fakeResolver = &nfsdriverfakes.FakeHostResolver{}
+
+		var err error
+		allowlist, err = nfsv3driver.NewServerAllowlist(fakeResolver, []string{
+			"nfs.example.com",
+			"*.storage.example.com",
+			".backup.example.org",
+			"10.10.0.0/16",
+			"192.168.1.5",
+			"fd00::/64",
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	DescribeTable("allowed servers",
+		func(remote string) {
+			allowed, err := allowlist.Check(env, remote)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allowed).To(Equal(remote))
+			Expect(fakeResolver.LookupIPAddrCallCount()).To(Equal(0))
+		},
+		Entry("an allowed host", "nfs.example.com:/export"),
+		Entry("an allowed host in another case", "NFS.Example.COM.:/export"),
+		Entry("a host in an allowed domain", "filer1.storage.example.com:/export"),
+		Entry("a host in a domain given with a leading dot", "nas.backup.example.org:/export"),
+		Entry("an address in an allowed network", "10.10.3.4:/export"),
+		Entry("an allowed address", "192.168.1.5:/export"),
+		Entry("an ipv6 address in an allowed network", "[fd00::12]:/export"),
+	)
+
+	DescribeTable("servers that are not allowed",
+		func(remote string, host string) {
+			_, err := allowlist.Check(env, remote)
+			Expect(err).To(MatchError(`NFS server "` + host + `" is not in the list of servers allowed on this cell`))
+			_, ok := err.(dockerdriver.SafeError)
+			Expect(ok).To(BeTrue())
+		},
+		Entry("an address outside the allowed networks", "10.11.0.1:/export", "10.11.0.1"),
+		Entry("an address next to an allowed address", "192.168.1.6:/export", "192.168.1.6"),
+		Entry("the domain itself", "storage.example.com:/export", "storage.example.com"),
+		Entry("a host sharing the suffix of an allowed domain", "evilstorage.example.com:/export", "evilstorage.example.com"),
+	)
+
+	Context("when a server name is not allowed by name", func() {
+		It("allows it from the first of its addresses when all of them are in allowed networks", func() {
+			fakeResolver.LookupIPAddrReturns([]net.IPAddr{{IP: net.ParseIP("10.10.0.1")}, {IP: net.ParseIP("fd00::1")}}, nil)
+
+			allowed, err := allowlist.Check(env, "filer.internal:/export")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allowed).To(Equal("10.10.0.1:/export"))
+			_, host := fakeResolver.LookupIPAddrArgsForCall(0)
+			Expect(host).To(Equal("filer.internal"))
+		})
+
+		It("brackets an ipv6 address to allow it from", func() {
+			fakeResolver.LookupIPAddrReturns([]net.IPAddr{{IP: net.ParseIP("fd00::1")}}, nil)
+
+			allowed, err := allowlist.Check(env, "filer.internal:/export/data")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allowed).To(Equal("[fd00::1]:/export/data"))
+		})
+
+		It("rejects it when any of its addresses is outside the allowed networks", func() {
+			fakeResolver.LookupIPAddrReturns([]net.IPAddr{{IP: net.ParseIP("10.10.0.1")}, {IP: net.ParseIP("169.254.169.254")}}, nil)
+
+			_, err := allowlist.Check(env, "filer.internal:/export")
+			Expect(err).To(MatchError(ContainSubstring("is not in the list of servers allowed")))
+		})
+
+		It("rejects it when it cannot be resolved", func() {
+			fakeResolver.LookupIPAddrReturns(nil, errors.New("no such host"))
+
+			_, err := allowlist.Check(env, "filer.internal:/export")
+			Expect(err).To(MatchError(ContainSubstring("could not be resolved")))
+		})
+	})
+
+	It("rejects shares without a server", func() {
+		_, err := allowlist.Check(env, "export")
+		Expect(err).To(MatchError("Invalid 'share' option"))
+	})
+
+	It("rejects invalid networks", func() {
+		_, err := nfsv3driver.NewServerAllowlist(fakeResolver, []string{"10.0.0.0/33"})
+		Expect(err).To(MatchError(ContainSubstring(`invalid allowed server "10.0.0.0/33"`)))
+	})
+})
@@ -0,0 +1,250 @@
+// Package sunrpc is a minimal ONC RPC (RFC 5531) client over TCP, with just enough of the portmapper
+// and MOUNT protocols to check an NFS server before mounting from it.
+package sunrpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	rpcVersion = 2
+
+	msgCall  = 0
+	msgReply = 1
+
+	replyAccepted = 0
+	replyDenied   = 1
+
+	lastFragment    = 0x80000000
+	maxRecordSize   = 1 << 20
+	minReservedPort = 600
+	maxReservedPort = 1023
+)
+
+type AcceptStat uint32
+
+const (
+	Success      AcceptStat = 0
+	ProgUnavail  AcceptStat = 1
+	ProgMismatch AcceptStat = 2
+	ProcUnavail  AcceptStat = 3
+	GarbageArgs  AcceptStat = 4
+	SystemErr    AcceptStat = 5
+)
+
+var (
+	ErrProgramUnavailable   = errors.New("rpc: program unavailable")
+	ErrProgramMismatch      = errors.New("rpc: program version mismatch")
+	ErrProcedureUnavailable = errors.New("rpc: procedure unavailable")
+	ErrGarbageArgs          = errors.New("rpc: server could not decode arguments")
+	ErrSystemError          = errors.New("rpc: system error on server")
+	ErrDenied               = errors.New("rpc: call denied by server")
+	ErrMalformedReply       = errors.New("rpc: malformed reply")
+)
+
+func (s AcceptStat) err() error {
+	switch s {
+	case Success:
+		return nil
+	case ProgUnavail:
+		return ErrProgramUnavailable
+	case ProgMismatch:
+		return ErrProgramMismatch
+	case ProcUnavail:
+		return ErrProcedureUnavailable
+	case GarbageArgs:
+		return ErrGarbageArgs
+	default:
+		return ErrSystemError
+	}
+}
+
+type AuthFlavor uint32
+
+const (
+	AuthNullFlavor AuthFlavor = 0
+	AuthSysFlavor  AuthFlavor = 1
+)
+
+// Auth is an opaque_auth structure: a flavor and its flavor-specific body.
+type Auth struct {
+	Flavor AuthFlavor
+	Body   []byte
+}
+
+var AuthNull = Auth{Flavor: AuthNullFlavor}
+
+// AuthSys returns AUTH_SYS credentials for uid/gid on machine.
+func AuthSys(machine string, uid uint32, gid uint32) Auth {
+	e := &Encoder{}
+	e.Uint32(uint32(time.Now().Unix()))
+	e.String(machine)
+	e.Uint32(uid)
+	e.Uint32(gid)
+	e.Uint32(0)
+	return Auth{Flavor: AuthSysFlavor, Body: e.Bytes()}
+}
+
+type Client struct {
+	conn net.Conn
+	Auth Auth
+
+	lock sync.Mutex
+	xid  uint32
+}
+
+// Dial connects to the RPC service at addr over TCP.
+func Dial(addr string, timeout time.Duration) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// DialPrivileged connects from a reserved source port, which servers that only accept requests from
+// root (for example MOUNT on an export with the "secure" option) require. It falls back to an ordinary
+// source port when the caller is not allowed to bind one.
+func DialPrivileged(addr string, timeout time.Duration) (*Client, error) {
+	start := rand.Intn(maxReservedPort - minReservedPort + 1)
+	for i := 0; i <= maxReservedPort-minReservedPort; i++ {
+		port := minReservedPort + (start+i)%(maxReservedPort-minReservedPort+1)
+		dialer := net.Dialer{Timeout: timeout, LocalAddr: &net.TCPAddr{Port: port}}
+		conn, err := dialer.Dial("tcp", addr)
+		if err == nil {
+			return NewClient(conn), nil
+		}
+		if errors.Is(err, syscall.EADDRINUSE) {
+			continue
+		}
+		if errors.Is(err, syscall.EACCES) || errors.Is(err, syscall.EPERM) {
+			break
+		}
+		return nil, err
+	}
+	return Dial(addr, timeout)
+}
+
+func NewClient(conn net.Conn) *Client {
+	return &Client{conn: conn, Auth: AuthNull, xid: rand.Uint32()}
+}
+
+func (c *Client) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Null calls procedure 0 of prog/vers, which every RPC service implements as a no-op.
+func (c *Client) Null(prog uint32, vers uint32) error {
+	_, err := c.Call(prog, vers, 0, nil)
+	return err
+}
+
+// Call invokes proc of prog/vers with XDR encoded args and returns the XDR encoded results.
+func (c *Client) Call(prog uint32, vers uint32, proc uint32, args []byte) ([]byte, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.xid++
+	xid := c.xid
+
+	e := &Encoder{}
+	e.Uint32(xid)
+	e.Uint32(msgCall)
+	e.Uint32(rpcVersion)
+	e.Uint32(prog)
+	e.Uint32(vers)
+	e.Uint32(proc)
+	e.Uint32(uint32(c.Auth.Flavor))
+	e.Opaque(c.Auth.Body)
+	e.Uint32(uint32(AuthNullFlavor))
+	e.Opaque(nil)
+
+	err := writeRecord(c.conn, append(e.Bytes(), args...))
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		reply, err := readRecord(c.conn)
+		if err != nil {
+			return nil, err
+		}
+
+		d := NewDecoder(reply)
+		if d.Uint32() != xid {
+			// a late reply to an earlier call
+			continue
+		}
+		if d.Uint32() != msgReply {
+			return nil, ErrMalformedReply
+		}
+
+		switch d.Uint32() {
+		case replyAccepted:
+			d.Uint32() // verifier flavor
+			d.Opaque() // verifier body
+			stat := AcceptStat(d.Uint32())
+			if d.Err() != nil {
+				return nil, ErrMalformedReply
+			}
+			if stat == ProgMismatch {
+				low, high := d.Uint32(), d.Uint32()
+				return nil, fmt.Errorf("%w (supported versions %d-%d)", ErrProgramMismatch, low, high)
+			}
+			if err := stat.err(); err != nil {
+				return nil, err
+			}
+			return d.Remaining(), nil
+		case replyDenied:
+			return nil, ErrDenied
+		default:
+			return nil, ErrMalformedReply
+		}
+	}
+}
+
+func writeRecord(w io.Writer, data []byte) error {
+	record := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(record, lastFragment|uint32(len(data)))
+	copy(record[4:], data)
+	_, err := w.Write(record)
+	return err
+}
+
+func readRecord(r io.Reader) ([]byte, error) {
+	var record []byte
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, err
+		}
+
+		marker := binary.BigEndian.Uint32(header[:])
+		size := int(marker &^ lastFragment)
+		if len(record)+size > maxRecordSize {
+			return nil, fmt.Errorf("rpc: record of more than %d bytes", maxRecordSize)
+		}
+
+		fragment := make([]byte, size)
+		if _, err := io.ReadFull(r, fragment); err != nil {
+			return nil, err
+		}
+		record = append(record, fragment...)
+
+		if marker&lastFragment != 0 {
+			return record, nil
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package sunrpc_test
+
+import (
+	"net"
+	"time"
+
+	"code.cloudfoundry.org/nfsv3driver/sunrpc"
+	"code.cloudfoundry.org/nfsv3driver/sunrpc/sunrpctest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		server *sunrpctest.Server
+		client *sunrpc.Client
+		err    error
+	)
+
+	BeforeEach(func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		server = sunrpctest.NewServer(listener)
+		server.Register(sunrpc.PortmapperProgram, sunrpc.PortmapperVersion, sunrpctest.PortmapperHandler([]sunrpc.Mapping{
+			{Program: sunrpc.NfsProgram, Version: 3, Protocol: sunrpc.ProtoTCP, Port: 2049},
+			{Program: sunrpc.MountProgram, Version: sunrpc.MountVersion, Protocol: sunrpc.ProtoTCP, Port: 20048},
+		}))
+		server.Register(sunrpc.MountProgram, sunrpc.MountVersion, sunrpctest.MountHandler(map[string]sunrpc.MountStatus{
+			"/export":  sunrpc.MountOK,
+			"/private": sunrpc.MountAccess,
+		}))
+		go func(server *sunrpctest.Server) { _ = server.Serve() }(server)
+
+		client, err = sunrpc.Dial(server.Addr().String(), time.Second)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.SetDeadline(time.Now().Add(5 * time.Second))).To(Succeed())
+	})
+
+	AfterEach(func() {
+		_ = client.Close()
+		_ = server.Close()
+	})
+
+	Context("Null", func() {
+		It("succeeds for registered programs", func() {
+			Expect(client.Null(sunrpc.MountProgram, sunrpc.MountVersion)).To(Succeed())
+		})
+
+		It("reports unregistered programs", func() {
+			Expect(client.Null(sunrpc.NfsProgram, 3)).To(MatchError(sunrpc.ErrProgramUnavailable))
+		})
+
+		It("reports unregistered versions of registered programs", func() {
+			err = client.Null(sunrpc.MountProgram, 1)
+			Expect(err).To(MatchError(sunrpc.ErrProgramMismatch))
+			Expect(err.Error()).To(ContainSubstring("supported versions 3-3"))
+		})
+	})
+
+	It("reports unknown procedures", func() {
+		_, err = client.Call(sunrpc.MountProgram, sunrpc.MountVersion, 99, nil)
+		Expect(err).To(MatchError(sunrpc.ErrProcedureUnavailable))
+	})
+
+	Context("GetPort", func() {
+		It("returns the registered port", func() {
+			port, err := client.GetPort(sunrpc.NfsProgram, 3, sunrpc.ProtoTCP)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(port).To(Equal(uint32(2049)))
+		})
+
+		It("returns 0 for programs that are not registered", func() {
+			port, err := client.GetPort(sunrpc.NfsProgram, 4, sunrpc.ProtoTCP)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(port).To(BeZero())
+		})
+	})
+
+	It("dumps the portmapper registrations", func() {
+		mappings, err := client.Dump()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mappings).To(ConsistOf(
+			sunrpc.Mapping{Program: sunrpc.NfsProgram, Version: 3, Protocol: sunrpc.ProtoTCP, Port: 2049},
+			sunrpc.Mapping{Program: sunrpc.MountProgram, Version: sunrpc.MountVersion, Protocol: sunrpc.ProtoTCP, Port: 20048},
+		))
+	})
+
+	Context("Mnt", func() {
+		BeforeEach(func() {
+			client.Auth = sunrpc.AuthSys("cell", 0, 0)
+		})
+
+		It("returns a file handle for exported paths", func() {
+			handle, err := client.Mnt("/export")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(handle).NotTo(BeEmpty())
+			Expect(client.Umnt("/export")).To(Succeed())
+		})
+
+		It("returns the mount status when the server refuses", func() {
+			_, err = client.Mnt("/private")
+			Expect(err).To(Equal(sunrpc.MountAccess))
+			Expect(err.Error()).To(Equal("mount: permission denied"))
+
+			_, err = client.Mnt("/missing")
+			Expect(err).To(Equal(sunrpc.MountNoEnt))
+		})
+	})
+
+	It("lists the exports", func() {
+		exports, err := client.Export()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(exports).To(ConsistOf(sunrpc.Export{Path: "/export"}, sunrpc.Export{Path: "/private"}))
+	})
+
+	Context("when the server does not answer", func() {
+		It("times out at the deadline", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer listener.Close()
+
+			silent, err := sunrpc.Dial(listener.Addr().String(), time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			defer silent.Close()
+
+			Expect(silent.SetDeadline(time.Now().Add(50 * time.Millisecond))).To(Succeed())
+			err = silent.Null(sunrpc.MountProgram, sunrpc.MountVersion)
+			Expect(err).To(HaveOccurred())
+
+			netErr, ok := err.(net.Error)
+			Expect(ok).To(BeTrue())
+			Expect(netErr.Timeout()).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,113 @@
+package sunrpc
+
+import "fmt"
+
+const (
+	NfsProgram      = 100003
+	MountProgram    = 100005
+	MountVersion    = 3
+	NlockmgrProgram = 100021
+	StatusProgram   = 100024
+
+	mountProcMnt    = 1
+	mountProcUmnt   = 3
+	mountProcExport = 5
+)
+
+// MountStatus is a mountstat3 value returned by MNT.
+type MountStatus uint32
+
+const (
+	MountOK          MountStatus = 0
+	MountPerm        MountStatus = 1
+	MountNoEnt       MountStatus = 2
+	MountIO          MountStatus = 5
+	MountAccess      MountStatus = 13
+	MountNotDir      MountStatus = 20
+	MountInval       MountStatus = 22
+	MountNameTooLong MountStatus = 63
+	MountNotSupp     MountStatus = 10004
+	MountServerFault MountStatus = 10006
+)
+
+var mountStatusText = map[MountStatus]string{
+	MountPerm:        "not owner",
+	MountNoEnt:       "no such file or directory",
+	MountIO:          "I/O error",
+	MountAccess:      "permission denied",
+	MountNotDir:      "not a directory",
+	MountInval:       "invalid argument",
+	MountNameTooLong: "filename too long",
+	MountNotSupp:     "operation not supported",
+	MountServerFault: "server fault",
+}
+
+func (s MountStatus) Error() string {
+	if text, ok := mountStatusText[s]; ok {
+		return "mount: " + text
+	}
+	return fmt.Sprintf("mount: status %d", uint32(s))
+}
+
+// Mnt asks the MOUNT service for the root file handle of path. A refusal is returned as a MountStatus.
+func (c *Client) Mnt(path string) ([]byte, error) {
+	e := &Encoder{}
+	e.String(path)
+
+	result, err := c.Call(MountProgram, MountVersion, mountProcMnt, e.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	d := NewDecoder(result)
+	status := MountStatus(d.Uint32())
+	if d.Err() != nil {
+		return nil, ErrMalformedReply
+	}
+	if status != MountOK {
+		return nil, status
+	}
+
+	handle := d.Opaque()
+	if d.Err() != nil {
+		return nil, ErrMalformedReply
+	}
+	return handle, nil
+}
+
+// Umnt tells the MOUNT service that path is no longer mounted by this client.
+func (c *Client) Umnt(path string) error {
+	e := &Encoder{}
+	e.String(path)
+
+	_, err := c.Call(MountProgram, MountVersion, mountProcUmnt, e.Bytes())
+	return err
+}
+
+// Export is an entry of the MOUNT service's export list.
+type Export struct {
+	Path   string
+	Groups []string
+}
+
+// Export lists the paths exported by the server and the clients each is exported to.
+func (c *Client) Export() ([]Export, error) {
+	result, err := c.Call(MountProgram, MountVersion, mountProcExport, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var exports []Export
+	d := NewDecoder(result)
+	for d.Bool() {
+		export := Export{Path: d.String()}
+		for d.Bool() {
+			export.Groups = append(export.Groups, d.String())
+		}
+		exports = append(exports, export)
+	}
+	if d.Err() != nil {
+		return nil, ErrMalformedReply
+	}
+	return exports, nil
+}
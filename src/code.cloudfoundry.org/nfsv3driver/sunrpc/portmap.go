@@ -0,0 +1,69 @@
+package sunrpc
+
+const (
+	PortmapperProgram = 100000
+	PortmapperVersion = 2
+	PortmapperPort    = 111
+
+	portmapProcGetPort = 3
+	portmapProcDump    = 4
+
+	ProtoTCP = 6
+	ProtoUDP = 17
+)
+
+// Mapping is a registration held by the portmapper.
+type Mapping struct {
+	Program  uint32
+	Version  uint32
+	Protocol uint32
+	Port     uint32
+}
+
+func (m Mapping) encode(e *Encoder) {
+	e.Uint32(m.Program)
+	e.Uint32(m.Version)
+	e.Uint32(m.Protocol)
+	e.Uint32(m.Port)
+}
+
+func decodeMapping(d *Decoder) Mapping {
+	return Mapping{Program: d.Uint32(), Version: d.Uint32(), Protocol: d.Uint32(), Port: d.Uint32()}
+}
+
+// GetPort asks the portmapper for the port of prog/vers over protocol. A port of 0 means that the
+// program is not registered.
+func (c *Client) GetPort(prog uint32, vers uint32, protocol uint32) (uint32, error) {
+	e := &Encoder{}
+	Mapping{Program: prog, Version: vers, Protocol: protocol}.encode(e)
+
+	result, err := c.Call(PortmapperProgram, PortmapperVersion, portmapProcGetPort, e.Bytes())
+	if err != nil {
+		return 0, err
+	}
+
+	d := NewDecoder(result)
+	port := d.Uint32()
+	if d.Err() != nil {
+		return 0, ErrMalformedReply
+	}
+	return port, nil
+}
+
+// Dump lists every registration held by the portmapper.
+func (c *Client) Dump() ([]Mapping, error) {
+	result, err := c.Call(PortmapperProgram, PortmapperVersion, portmapProcDump, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var mappings []Mapping
+	d := NewDecoder(result)
+	for d.Bool() {
+		mappings = append(mappings, decodeMapping(d))
+	}
+	if d.Err() != nil {
+		return nil, ErrMalformedReply
+	}
+	return mappings, nil
+}
@@ -0,0 +1,13 @@
+package sunrpc_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSunrpc(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Sun RPC Suite")
+}
@@ -0,0 +1,50 @@
+package sunrpctest
+
+import "code.cloudfoundry.org/nfsv3driver/sunrpc"
+
+const (
+	mountProcMnt    = 1
+	mountProcUmnt   = 3
+	mountProcExport = 5
+)
+
+// MountHandler serves MNT, UMNT and EXPORT, answering MNT for each path with its status in exports.
+// Paths that are not listed are answered with MountNoEnt.
+func MountHandler(exports map[string]sunrpc.MountStatus) Handler {
+	return func(proc uint32, args []byte) ([]byte, sunrpc.AcceptStat) {
+		e := &sunrpc.Encoder{}
+		switch proc {
+		case mountProcMnt, mountProcUmnt:
+		case mountProcExport:
+			for path := range exports {
+				e.Bool(true)
+				e.String(path)
+				e.Bool(false)
+			}
+			e.Bool(false)
+			return e.Bytes(), sunrpc.Success
+		default:
+			return nil, sunrpc.ProcUnavail
+		}
+
+		d := sunrpc.NewDecoder(args)
+		path := d.String()
+		if d.Err() != nil {
+			return nil, sunrpc.GarbageArgs
+		}
+
+		if proc == mountProcMnt {
+			status, ok := exports[path]
+			if !ok {
+				status = sunrpc.MountNoEnt
+			}
+			e.Uint32(uint32(status))
+			if status == sunrpc.MountOK {
+				e.Opaque([]byte(path))
+				e.Uint32(1)
+				e.Uint32(uint32(sunrpc.AuthSysFlavor))
+			}
+		}
+		return e.Bytes(), sunrpc.Success
+	}
+}
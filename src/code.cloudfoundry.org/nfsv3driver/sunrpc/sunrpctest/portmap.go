@@ -0,0 +1,42 @@
+package sunrpctest
+
+import "code.cloudfoundry.org/nfsv3driver/sunrpc"
+
+const (
+	portmapProcGetPort = 3
+	portmapProcDump    = 4
+)
+
+// PortmapperHandler serves GETPORT and DUMP from a fixed set of mappings.
+func PortmapperHandler(mappings []sunrpc.Mapping) Handler {
+	return func(proc uint32, args []byte) ([]byte, sunrpc.AcceptStat) {
+		e := &sunrpc.Encoder{}
+		switch proc {
+		case portmapProcGetPort:
+			d := sunrpc.NewDecoder(args)
+			want := sunrpc.Mapping{Program: d.Uint32(), Version: d.Uint32(), Protocol: d.Uint32(), Port: d.Uint32()}
+			if d.Err() != nil {
+				return nil, sunrpc.GarbageArgs
+			}
+			port := uint32(0)
+			for _, m := range mappings {
+				if m.Program == want.Program && m.Version == want.Version && m.Protocol == want.Protocol {
+					port = m.Port
+				}
+			}
+			e.Uint32(port)
+		case portmapProcDump:
+			for _, m := range mappings {
+				e.Bool(true)
+				e.Uint32(m.Program)
+				e.Uint32(m.Version)
+				e.Uint32(m.Protocol)
+				e.Uint32(m.Port)
+			}
+			e.Bool(false)
+		default:
+			return nil, sunrpc.ProcUnavail
+		}
+		return e.Bytes(), sunrpc.Success
+	}
+}
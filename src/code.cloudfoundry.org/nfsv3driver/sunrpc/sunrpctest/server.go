@@ -0,0 +1,218 @@
+// Package sunrpctest provides an ONC RPC server with portmapper and MOUNT services, for testing the
+// sunrpc client and its users against.
+package sunrpctest
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+
+	"code.cloudfoundry.org/nfsv3driver/sunrpc"
+)
+
+const (
+	rpcVersion = 2
+
+	msgCall  = 0
+	msgReply = 1
+
+	replyAccepted = 0
+	replyDenied   = 1
+
+	lastFragment = 0x80000000
+)
+
+// Handler serves one procedure call. It returns the XDR encoded results, or a non-Success AcceptStat to
+// reject the call.
+type Handler func(proc uint32, args []byte) ([]byte, sunrpc.AcceptStat)
+
+// Server is a small RPC server over TCP that stands in for an NFS server in tests.
+type Server struct {
+	listener net.Listener
+
+	lock     sync.Mutex
+	programs map[[2]uint32]Handler
+	conns    map[net.Conn]struct{}
+	wg       sync.WaitGroup
+}
+
+func NewServer(listener net.Listener) *Server {
+	return &Server{
+		listener: listener,
+		programs: map[[2]uint32]Handler{},
+		conns:    map[net.Conn]struct{}{},
+	}
+}
+
+func (s *Server) Register(prog uint32, vers uint32, handler Handler) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.programs[[2]uint32{prog, vers}] = handler
+}
+
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Serve accepts connections until the server is closed.
+func (s *Server) Serve() error {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		s.lock.Lock()
+		s.conns[conn] = struct{}{}
+		s.lock.Unlock()
+
+		s.wg.Add(1)
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) Close() error {
+	err := s.listener.Close()
+
+	s.lock.Lock()
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.lock.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.lock.Lock()
+		delete(s.conns, conn)
+		s.lock.Unlock()
+		_ = conn.Close()
+	}()
+
+	for {
+		call, err := readRecord(conn)
+		if err != nil {
+			return
+		}
+
+		reply, ok := s.dispatch(call)
+		if !ok {
+			return
+		}
+
+		if err := writeRecord(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) dispatch(call []byte) ([]byte, bool) {
+	d := sunrpc.NewDecoder(call)
+	xid := d.Uint32()
+	if d.Uint32() != msgCall {
+		return nil, false
+	}
+
+	e := &sunrpc.Encoder{}
+	e.Uint32(xid)
+	e.Uint32(msgReply)
+
+	if d.Uint32() != rpcVersion {
+		e.Uint32(replyDenied)
+		e.Uint32(0) // RPC_MISMATCH
+		e.Uint32(rpcVersion)
+		e.Uint32(rpcVersion)
+		return e.Bytes(), true
+	}
+
+	prog, vers, proc := d.Uint32(), d.Uint32(), d.Uint32()
+	d.Uint32() // credential flavor
+	d.Opaque() // credential body
+	d.Uint32() // verifier flavor
+	d.Opaque() // verifier body
+	if d.Err() != nil {
+		return nil, false
+	}
+
+	e.Uint32(replyAccepted)
+	e.Uint32(uint32(sunrpc.AuthNullFlavor))
+	e.Opaque(nil)
+
+	s.lock.Lock()
+	handler, ok := s.programs[[2]uint32{prog, vers}]
+	low, high, known := s.versions(prog)
+	s.lock.Unlock()
+
+	switch {
+	case ok:
+		if proc == 0 {
+			e.Uint32(uint32(sunrpc.Success))
+			break
+		}
+		result, stat := handler(proc, d.Remaining())
+		e.Uint32(uint32(stat))
+		if stat == sunrpc.Success {
+			return append(e.Bytes(), result...), true
+		}
+	case known:
+		e.Uint32(uint32(sunrpc.ProgMismatch))
+		e.Uint32(low)
+		e.Uint32(high)
+	default:
+		e.Uint32(uint32(sunrpc.ProgUnavail))
+	}
+
+	return e.Bytes(), true
+}
+
+func (s *Server) versions(prog uint32) (uint32, uint32, bool) {
+	var low, high uint32
+	known := false
+	for key := range s.programs {
+		if key[0] != prog {
+			continue
+		}
+		if !known || key[1] < low {
+			low = key[1]
+		}
+		if !known || key[1] > high {
+			high = key[1]
+		}
+		known = true
+	}
+	return low, high, known
+}
+
+func writeRecord(w io.Writer, data []byte) error {
+	record := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(record, lastFragment|uint32(len(data)))
+	copy(record[4:], data)
+	_, err := w.Write(record)
+	return err
+}
+
+func readRecord(r io.Reader) ([]byte, error) {
+	var record []byte
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, err
+		}
+
+		marker := binary.BigEndian.Uint32(header[:])
+		fragment := make([]byte, marker&^lastFragment)
+		if _, err := io.ReadFull(r, fragment); err != nil {
+			return nil, err
+		}
+		record = append(record, fragment...)
+
+		if marker&lastFragment != 0 {
+			return record, nil
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package sunrpc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var ErrShortBuffer = errors.New("xdr: not enough data to decode")
+
+// Encoder writes XDR (RFC 4506) encoded values.
+type Encoder struct {
+	buf bytes.Buffer
+}
+
+func (e *Encoder) Uint32(v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	e.buf.Write(b[:])
+}
+
+func (e *Encoder) Bool(v bool) {
+	if v {
+		e.Uint32(1)
+	} else {
+		e.Uint32(0)
+	}
+}
+
+// Opaque writes variable length opaque data, padded to a multiple of four bytes.
+func (e *Encoder) Opaque(v []byte) {
+	e.Uint32(uint32(len(v)))
+	e.buf.Write(v)
+	e.buf.Write(make([]byte, padding(len(v))))
+}
+
+func (e *Encoder) String(v string) {
+	e.Opaque([]byte(v))
+}
+
+func (e *Encoder) Bytes() []byte {
+	return e.buf.Bytes()
+}
+
+// Decoder reads XDR encoded values. The first error is sticky and reported by Err.
+type Decoder struct {
+	data []byte
+	err  error
+}
+
+func NewDecoder(data []byte) *Decoder {
+	return &Decoder{data: data}
+}
+
+func (d *Decoder) Uint32() uint32 {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.data) < 4 {
+		d.err = ErrShortBuffer
+		return 0
+	}
+	v := binary.BigEndian.Uint32(d.data)
+	d.data = d.data[4:]
+	return v
+}
+
+func (d *Decoder) Bool() bool {
+	return d.Uint32() != 0
+}
+
+func (d *Decoder) Opaque() []byte {
+	n := int(d.Uint32())
+	if d.err != nil {
+		return nil
+	}
+	if n+padding(n) > len(d.data) {
+		d.err = ErrShortBuffer
+		return nil
+	}
+	v := d.data[:n]
+	d.data = d.data[n+padding(n):]
+	return v
+}
+
+func (d *Decoder) String() string {
+	return string(d.Opaque())
+}
+
+// Remaining returns the bytes that have not been decoded yet.
+func (d *Decoder) Remaining() []byte {
+	return d.data
+}
+
+func (d *Decoder) Err() error {
+	return d.err
+}
+
+func padding(n int) int {
+	return (4 - n%4) % 4
+}
@@ -0,0 +1,43 @@
+package sunrpc_test
+
+import (
+	"code.cloudfoundry.org/nfsv3driver/sunrpc"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("XDR", func() {
+	It("pads opaque data to a multiple of four bytes", func() {
+		e := &sunrpc.Encoder{}
+		e.String("abcde")
+		e.Uint32(7)
+
+		Expect(e.Bytes()).To(Equal([]byte{0, 0, 0, 5, 'a', 'b', 'c', 'd', 'e', 0, 0, 0, 0, 0, 0, 7}))
+	})
+
+	It("decodes what it encodes", func() {
+		e := &sunrpc.Encoder{}
+		e.Bool(true)
+		e.String("/export")
+		e.Opaque([]byte{1, 2})
+		e.Uint32(42)
+
+		d := sunrpc.NewDecoder(e.Bytes())
+		Expect(d.Bool()).To(BeTrue())
+		Expect(d.String()).To(Equal("/export"))
+		Expect(d.Opaque()).To(Equal([]byte{1, 2}))
+		Expect(d.Uint32()).To(Equal(uint32(42)))
+		Expect(d.Err()).NotTo(HaveOccurred())
+		Expect(d.Remaining()).To(BeEmpty())
+	})
+
+	Context("when the data is truncated", func() {
+		It("reports ErrShortBuffer and keeps returning zero values", func() {
+			d := sunrpc.NewDecoder([]byte{0, 0, 0, 8, 'a'})
+
+			Expect(d.String()).To(Equal(""))
+			Expect(d.Uint32()).To(Equal(uint32(0)))
+			Expect(d.Err()).To(MatchError(sunrpc.ErrShortBuffer))
+		})
+	})
+})